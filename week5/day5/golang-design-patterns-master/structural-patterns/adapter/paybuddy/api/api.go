@@ -0,0 +1,551 @@
+// Package api exposes the paybuddy shop as a JSON REST API
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/payment"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/shop"
+)
+
+// OpenAPI is the OpenAPI 3 specification of the API
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// MaxBodySize is the largest request body accepted by the Server
+const MaxBodySize = 64 << 10
+
+// Currency of the shop prices
+const Currency = "USD"
+
+// CartStatus is the checkout status of a cart
+type CartStatus string
+
+const (
+	// CartStatusOpen is a cart that accepts items
+	CartStatusOpen CartStatus = "open"
+	// CartStatusCheckingOut is a cart whose payment is in progress
+	CartStatusCheckingOut CartStatus = "checking_out"
+	// CartStatusCheckedOut is a paid cart
+	CartStatusCheckedOut CartStatus = "checked_out"
+)
+
+// Item of a cart
+type Item struct {
+	// Name of the item
+	Name string `json:"name"`
+	// Price of the item
+	Price float64 `json:"price"`
+}
+
+// Cart resource
+type Cart struct {
+	// ID of the cart
+	ID string `json:"id"`
+	// Items in the cart
+	Items []Item `json:"items"`
+	// Total price of the items
+	Total float64 `json:"total"`
+	// Currency of the prices
+	Currency string `json:"currency"`
+	// Status of the cart
+	Status CartStatus `json:"status"`
+	// PaymentID is the payment of a checked out cart
+	PaymentID string `json:"payment_id,omitempty"`
+}
+
+// Payment resource
+type Payment struct {
+	// ID of the payment
+	ID string `json:"id"`
+	// CartID is the paid cart
+	CartID string `json:"cart_id"`
+	// Method used to pay
+	Method string `json:"method"`
+	// PayerEmail is the email of the paying customer
+	PayerEmail string `json:"payer_email"`
+	// Amount paid
+	Amount float64 `json:"amount"`
+	// Currency of the amount
+	Currency string `json:"currency"`
+	// Status of the payment
+	Status shop.PaymentStatus `json:"status"`
+	// Reference of the payment at the provider, if tracked
+	Reference string `json:"reference,omitempty"`
+	// CreatedAt is when the payment was made
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Balance resource of a bank account
+type Balance struct {
+	// Email of the account
+	Email string `json:"email"`
+	// Owner of the account
+	Owner string `json:"owner"`
+	// Balance of the account
+	Balance float64 `json:"balance"`
+	// Currency of the balance
+	Currency string `json:"currency"`
+}
+
+// Error is the body of a failed request
+type Error struct {
+	// Code is the machine readable error code
+	Code string `json:"code"`
+	// Message is the human readable error description
+	Message string `json:"message"`
+}
+
+// Server serves the paybuddy REST API
+type Server struct {
+	// ShopEmailAddress receives the payments
+	ShopEmailAddress string
+	// PaymentMethods available at checkout by name, e.g. "bank" or "paypal"
+	PaymentMethods map[string]shop.Payment
+	// Bank that keeps the account balances
+	Bank *bank.Gateway
+
+	mu       sync.Mutex
+	seq      int
+	carts    map[string]*Cart
+	payments map[string]*Payment
+}
+
+// NewServer creates a server paying the shop through the payment methods
+func NewServer(shopEmail string, gateway *bank.Gateway, methods map[string]shop.Payment) *Server {
+	return &Server{
+		ShopEmailAddress: shopEmail,
+		PaymentMethods:   methods,
+		Bank:             gateway,
+		carts:            make(map[string]*Cart),
+		payments:         make(map[string]*Payment),
+	}
+}
+
+type route struct {
+	method  string
+	pattern []string
+	handle  func(s *Server, w http.ResponseWriter, r *http.Request, params []string)
+}
+
+var routes = []route{
+	{http.MethodGet, []string{"openapi.yaml"}, (*Server).openAPI},
+	{http.MethodPost, []string{"carts"}, (*Server).createCart},
+	{http.MethodGet, []string{"carts", "*"}, (*Server).getCart},
+	{http.MethodPost, []string{"carts", "*", "items"}, (*Server).addItem},
+	{http.MethodDelete, []string{"carts", "*", "items", "*"}, (*Server).removeItem},
+	{http.MethodPost, []string{"carts", "*", "checkout"}, (*Server).checkout},
+	{http.MethodGet, []string{"payments", "*"}, (*Server).getPayment},
+	{http.MethodGet, []string{"accounts", "*", "balance"}, (*Server).getBalance},
+}
+
+// ServeHTTP routes the request to its handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+
+	var allowed []string
+	for _, route := range routes {
+		params, ok := match(route.pattern, segments)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+		route.handle(s, w, r, params)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+	writeError(w, http.StatusNotFound, "not_found", "The requested resource does not exist")
+}
+
+func match(pattern, segments []string) ([]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	var params []string
+	for i, part := range pattern {
+		if part == "*" {
+			param, err := url.PathUnescape(segments[i])
+			if err != nil || param == "" {
+				return nil, false
+			}
+			params = append(params, param)
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request, _ []string) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(OpenAPI)
+}
+
+func (s *Server) createCart(w http.ResponseWriter, r *http.Request, _ []string) {
+	request := struct {
+		Items []Item `json:"items"`
+	}{}
+
+	if r.ContentLength != 0 {
+		if !decode(w, r, &request) {
+			return
+		}
+	}
+
+	for _, item := range request.Items {
+		if msg := validateItem(item); msg != "" {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_item", msg)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.seq++
+	cart := &Cart{
+		ID:       "cart-" + strconv.Itoa(s.seq),
+		Items:    append([]Item{}, request.Items...),
+		Currency: Currency,
+		Status:   CartStatusOpen,
+	}
+	cart.Total = total(cart.Items)
+	s.carts[cart.ID] = cart
+	response := copyCart(cart)
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/carts/"+url.PathEscape(cart.ID))
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (s *Server) getCart(w http.ResponseWriter, r *http.Request, params []string) {
+	s.mu.Lock()
+	cart, ok := s.carts[params[0]]
+	var response Cart
+	if ok {
+		response = copyCart(cart)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "cart_not_found", "The cart does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) addItem(w http.ResponseWriter, r *http.Request, params []string) {
+	item := Item{}
+	if !decode(w, r, &item) {
+		return
+	}
+
+	if msg := validateItem(item); msg != "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_item", msg)
+		return
+	}
+
+	s.updateCart(w, params[0], func(cart *Cart) bool {
+		cart.Items = append(cart.Items, item)
+		return true
+	})
+}
+
+func (s *Server) removeItem(w http.ResponseWriter, r *http.Request, params []string) {
+	index, err := strconv.Atoi(params[1])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "item_not_found", "The item does not exist")
+		return
+	}
+
+	s.updateCart(w, params[0], func(cart *Cart) bool {
+		if index < 0 || index >= len(cart.Items) {
+			return false
+		}
+		cart.Items = append(cart.Items[:index], cart.Items[index+1:]...)
+		return true
+	})
+}
+
+// updateCart changes an open cart. The change reports false when it
+// addresses a missing item.
+func (s *Server) updateCart(w http.ResponseWriter, id string, change func(*Cart) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cart, ok := s.carts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "cart_not_found", "The cart does not exist")
+		return
+	}
+
+	if cart.Status != CartStatusOpen {
+		writeError(w, http.StatusConflict, "cart_closed", "The cart is already checked out")
+		return
+	}
+
+	if !change(cart) {
+		writeError(w, http.StatusNotFound, "item_not_found", "The item does not exist")
+		return
+	}
+	cart.Total = total(cart.Items)
+	writeJSON(w, http.StatusOK, copyCart(cart))
+}
+
+func (s *Server) checkout(w http.ResponseWriter, r *http.Request, params []string) {
+	request := struct {
+		PaymentMethod string `json:"payment_method"`
+		PayerEmail    string `json:"payer_email"`
+	}{}
+
+	if !decode(w, r, &request) {
+		return
+	}
+
+	method, ok := s.PaymentMethods[request.PaymentMethod]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_payment_method",
+			fmt.Sprintf("The payment method must be one of %s", strings.Join(s.methodNames(), ", ")))
+		return
+	}
+
+	payer, err := paypal.ParseAddress(request.PayerEmail)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_payer_email", err.Error())
+		return
+	}
+	payerEmail := payer.String()
+
+	card, ok := s.beginCheckout(w, params[0], method)
+	if !ok {
+		return
+	}
+
+	p := &Payment{
+		CartID:     params[0],
+		Method:     request.PaymentMethod,
+		PayerEmail: payerEmail,
+		Amount:     card.Total(),
+		Currency:   Currency,
+		Status:     shop.PaymentStatusCompleted,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if tracked, ok := method.(shop.TrackedPayment); ok {
+		p.Status = shop.PaymentStatusPending
+		p.Reference, err = tracked.PayTracked(payerEmail, card.ShopEmailAddress, p.Amount)
+	} else {
+		err = card.Checkout(payerEmail)
+	}
+
+	s.mu.Lock()
+	cart := s.carts[params[0]]
+	if err != nil {
+		cart.Status = CartStatusOpen
+		s.mu.Unlock()
+		writePaymentError(w, err)
+		return
+	}
+
+	s.seq++
+	p.ID = "payment-" + strconv.Itoa(s.seq)
+	s.payments[p.ID] = p
+	cart.Status = CartStatusCheckedOut
+	cart.PaymentID = p.ID
+	response := *p
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/payments/"+url.PathEscape(p.ID))
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// beginCheckout reserves an open cart for the payment and returns the
+// shopping card to pay
+func (s *Server) beginCheckout(w http.ResponseWriter, id string, method shop.Payment) (*shop.ShoppingCard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cart, ok := s.carts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "cart_not_found", "The cart does not exist")
+		return nil, false
+	}
+
+	if cart.Status != CartStatusOpen {
+		writeError(w, http.StatusConflict, "cart_closed", "The cart is already checked out")
+		return nil, false
+	}
+
+	if len(cart.Items) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "cart_empty", "The cart has no items")
+		return nil, false
+	}
+
+	cart.Status = CartStatusCheckingOut
+
+	card := &shop.ShoppingCard{
+		PaymentMethod:    method,
+		ShopEmailAddress: s.ShopEmailAddress,
+	}
+	for _, item := range cart.Items {
+		card.Items = append(card.Items, &shop.Item{Name: item.Name, Price: item.Price})
+	}
+	return card, true
+}
+
+func (s *Server) getPayment(w http.ResponseWriter, r *http.Request, params []string) {
+	s.mu.Lock()
+	p, ok := s.payments[params[0]]
+	var response Payment
+	if ok {
+		response = *p
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "payment_not_found", "The payment does not exist")
+		return
+	}
+
+	if response.Status == shop.PaymentStatusPending {
+		if tracked, ok := s.PaymentMethods[response.Method].(shop.TrackedPayment); ok {
+			status, err := tracked.Status(response.Reference)
+			if err != nil {
+				writePaymentError(w, err)
+				return
+			}
+
+			s.mu.Lock()
+			p.Status = status
+			s.mu.Unlock()
+			response.Status = status
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getBalance(w http.ResponseWriter, r *http.Request, params []string) {
+	if s.Bank == nil {
+		writeError(w, http.StatusNotFound, "account_not_found", "The account does not exist")
+		return
+	}
+
+	email, err := paypal.ParseAddress(params[0])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "account_not_found", "The account does not exist")
+		return
+	}
+
+	account, err := s.Bank.AccountByEmail(email.String())
+	if err != nil {
+		writeError(w, http.StatusNotFound, "account_not_found", "The account does not exist")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Balance{
+		Email:    account.Email,
+		Owner:    account.Owner,
+		Balance:  account.Balance,
+		Currency: account.Currency,
+	})
+}
+
+func (s *Server) methodNames() []string {
+	names := make([]string, 0, len(s.PaymentMethods))
+	for name := range s.PaymentMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateItem(item Item) string {
+	switch {
+	case strings.TrimSpace(item.Name) == "":
+		return "The item name must be provided"
+	case item.Price <= 0 || math.IsInf(item.Price, 0) || math.IsNaN(item.Price):
+		return "The item price must be a positive number"
+	default:
+		return ""
+	}
+}
+
+func total(items []Item) float64 {
+	var sum float64
+	for _, item := range items {
+		sum += item.Price
+	}
+	return sum
+}
+
+func copyCart(cart *Cart) Cart {
+	response := *cart
+	response.Items = append([]Item{}, cart.Items...)
+	return response
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "The request body must be JSON")
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "malformed_request", "The request body is malformed: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payment.ErrDeclined):
+		writeError(w, http.StatusPaymentRequired, "payment_declined", err.Error())
+	case errors.Is(err, payment.ErrInvalidInput):
+		writeError(w, http.StatusUnprocessableEntity, "invalid_payment", err.Error())
+	case errors.Is(err, payment.ErrProviderUnavailable):
+		writeError(w, http.StatusServiceUnavailable, "provider_unavailable", err.Error())
+	case errors.Is(err, payment.ErrProviderMisconfigured):
+		writeError(w, http.StatusBadGateway, "provider_misconfigured", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, struct {
+		Error Error `json:"error"`
+	}{Error{Code: code, Message: message}})
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal/paypaltest"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/shop"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/webhook"
+)
+
+const shopEmail = "shop@example.com"
+
+func newGateway() *bank.Gateway {
+	return &bank.Gateway{
+		Accounts: []*bank.Account{
+			{Owner: "iShop", Email: shopEmail, Balance: 1000, Currency: "USD"},
+			{Owner: "Mike Meadows", Email: "mike@example.com", Balance: 100, Currency: "USD"},
+		},
+	}
+}
+
+func serve(t *testing.T, handler http.Handler, method, path, body string, out interface{}) int {
+	t.Helper()
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s responded %q: %v", method, path, w.Body, err)
+		}
+	}
+	return w.Code
+}
+
+// checkout creates a cart of an item of the price and checks it out
+func checkout(t *testing.T, server *Server, price, method, payer string) (int, Payment, Error) {
+	t.Helper()
+
+	var cart Cart
+	serve(t, server, http.MethodPost, "/carts", `{"items":[{"name":"Book","price":`+price+`}]}`, &cart)
+
+	var response struct {
+		Payment
+		Error Error `json:"error"`
+	}
+	code := serve(t, server, http.MethodPost, "/carts/"+cart.ID+"/checkout",
+		`{"payment_method":"`+method+`","payer_email":"`+payer+`"}`, &response)
+	return code, response.Payment, response.Error
+}
+
+func TestCheckout(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(fake *paypaltest.Server)
+		method  string
+		payer   string
+		price   string
+		code    int
+		status  shop.PaymentStatus
+		errCode string
+		balance float64
+	}{
+		{name: "bank", method: "bank", payer: "mike@example.com", price: "40", code: http.StatusCreated, status: shop.PaymentStatusCompleted, balance: 60},
+		{name: "bank payer in mixed case", method: "bank", payer: "Mike@Example.COM", price: "40", code: http.StatusCreated, status: shop.PaymentStatusCompleted, balance: 60},
+		{name: "bank insufficient funds", method: "bank", payer: "mike@example.com", price: "400", code: http.StatusPaymentRequired, errCode: "payment_declined", balance: 100},
+		{name: "bank unknown account", method: "bank", payer: "jane@example.com", price: "40", code: http.StatusUnprocessableEntity, errCode: "invalid_payment", balance: 100},
+		{name: "paypal", method: "paypal", payer: "mike@example.com", price: "40", code: http.StatusCreated, status: shop.PaymentStatusPending, balance: 100},
+		{
+			name:    "paypal declined",
+			setup:   func(fake *paypaltest.Server) { fake.Decline("mike@example.com") },
+			method:  "paypal",
+			payer:   "mike@example.com",
+			price:   "40",
+			code:    http.StatusPaymentRequired,
+			errCode: "payment_declined",
+			balance: 100,
+		},
+		{
+			name:    "paypal unavailable",
+			setup:   func(fake *paypaltest.Server) { fake.SetUnavailable(true) },
+			method:  "paypal",
+			payer:   "mike@example.com",
+			price:   "40",
+			code:    http.StatusServiceUnavailable,
+			errCode: "provider_unavailable",
+			balance: 100,
+		},
+		{name: "unknown method", method: "cash", payer: "mike@example.com", price: "40", code: http.StatusUnprocessableEntity, errCode: "invalid_payment_method", balance: 100},
+		{name: "invalid payer", method: "bank", payer: "mike", price: "40", code: http.StatusUnprocessableEntity, errCode: "invalid_payer_email", balance: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := paypaltest.NewServer("key", "secret")
+			defer fake.Close()
+			if test.setup != nil {
+				test.setup(fake)
+			}
+
+			gateway := newGateway()
+			server := NewServer(shopEmail, gateway, map[string]shop.Payment{
+				"bank":   &shop.BankAdapter{Gateway: gateway},
+				"paypal": &shop.PayPalAdapter{Payment: fake.Payment()},
+			})
+
+			code, payment, apiErr := checkout(t, server, test.price, test.method, test.payer)
+			if code != test.code {
+				t.Fatalf("responded %d %+v, want %d", code, apiErr, test.code)
+			}
+			if apiErr.Code != test.errCode {
+				t.Errorf("error is %q, want %q", apiErr.Code, test.errCode)
+			}
+			if payment.Status != test.status {
+				t.Errorf("the payment is %q, want %q", payment.Status, test.status)
+			}
+
+			var balance Balance
+			serve(t, server, http.MethodGet, "/accounts/Mike@example.com/balance", "", &balance)
+			if balance.Balance != test.balance {
+				t.Errorf("the balance is %v, want %v", balance.Balance, test.balance)
+			}
+
+			var cart Cart
+			serve(t, server, http.MethodGet, "/carts/cart-1", "", &cart)
+			if want := code == http.StatusCreated; (cart.Status == CartStatusCheckedOut) != want {
+				t.Errorf("the cart is %s after the checkout", cart.Status)
+			}
+		})
+	}
+}
+
+func TestPayPalPaymentStatus(t *testing.T) {
+	fake := paypaltest.NewServer("key", "secret")
+	defer fake.Close()
+
+	orders := webhook.NewOrders()
+	server := NewServer(shopEmail, newGateway(), map[string]shop.Payment{
+		"paypal": &shop.PayPalAdapter{Payment: fake.Payment(), Orders: orders},
+	})
+
+	code, payment, _ := checkout(t, server, "40", "paypal", "mike@example.com")
+	if code != http.StatusCreated || payment.Status != shop.PaymentStatusPending {
+		t.Fatalf("responded %d %+v", code, payment)
+	}
+
+	// no webhook is delivered, the order awaiting its payment asks PayPal
+	if err := fake.SetState(payment.Reference, paypal.PaymentStateCompleted); err != nil {
+		t.Fatal(err)
+	}
+
+	var status Payment
+	serve(t, server, http.MethodGet, "/payments/"+payment.ID, "", &status)
+	if status.Status != shop.PaymentStatusCompleted {
+		t.Errorf("the payment is %s, want %s", status.Status, shop.PaymentStatusCompleted)
+	}
+}
+
+func TestPayPalPaymentStatusFromWebhook(t *testing.T) {
+	fake := paypaltest.NewServer("key", "secret")
+	defer fake.Close()
+
+	orders := webhook.NewOrders()
+	receiver := httptest.NewServer(&webhook.Handler{Secret: "webhook-secret", Store: &webhook.MemoryStore{}, Payments: orders})
+	defer receiver.Close()
+	fake.SetWebhook(receiver.URL, "webhook-secret")
+
+	server := NewServer(shopEmail, newGateway(), map[string]shop.Payment{
+		"paypal": &shop.PayPalAdapter{Payment: fake.Payment(), Orders: orders},
+	})
+
+	_, payment, _ := checkout(t, server, "40", "paypal", "mike@example.com")
+	if err := fake.SetState(payment.Reference, paypal.PaymentStateFailed); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := orders.Order(payment.Reference); order.Status != webhook.OrderStatusPaymentFailed {
+		t.Fatalf("the order is %s", order.Status)
+	}
+
+	// PayPal is not asked about the orders the webhook settled
+	fake.SetUnavailable(true)
+	var status Payment
+	if code := serve(t, server, http.MethodGet, "/payments/"+payment.ID, "", &status); code != http.StatusOK {
+		t.Fatalf("responded %d", code)
+	}
+	if status.Status != shop.PaymentStatusFailed {
+		t.Errorf("the payment is %s, want %s", status.Status, shop.PaymentStatusFailed)
+	}
+}
+
+func TestCheckoutDoesNotWaitForPayPal(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+
+	gateway := newGateway()
+	server := NewServer(shopEmail, gateway, map[string]shop.Payment{
+		"bank":   &shop.BankAdapter{Gateway: gateway},
+		"paypal": &shop.PayPalAdapter{Payment: &paypal.Payment{APIKey: "key", BaseURL: slow.URL}},
+	})
+
+	payPalDone := make(chan int)
+	go func() {
+		code, _, _ := checkout(t, server, "40", "paypal", "mike@example.com")
+		payPalDone <- code
+	}()
+
+	bankDone := make(chan int)
+	go func() {
+		code, _, _ := checkout(t, server, "40", "bank", "mike@example.com")
+		bankDone <- code
+	}()
+
+	select {
+	case code := <-bankDone:
+		if code != http.StatusCreated {
+			t.Errorf("the bank checkout responded %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the bank checkout waits for PayPal")
+	}
+
+	close(release)
+	if code := <-payPalDone; code != http.StatusServiceUnavailable {
+		t.Errorf("the PayPal checkout responded %d", code)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	server := NewServer(shopEmail, newGateway(), nil)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "/openapi.yaml", "", http.StatusOK},
+		{http.MethodPost, "/carts", "", http.StatusCreated},
+		{http.MethodGet, "/carts/cart-1", "", http.StatusOK},
+		{http.MethodPost, "/carts/cart-1/items", `{"name":"Pen","price":2}`, http.StatusOK},
+		{http.MethodPost, "/carts/cart-1/items", `{"name":"","price":2}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/carts/cart-1/items", `{"name":"Pen","price":2,"color":"red"}`, http.StatusBadRequest},
+		{http.MethodDelete, "/carts/cart-1/items/0", "", http.StatusOK},
+		{http.MethodDelete, "/carts/cart-1/items/0", "", http.StatusNotFound},
+		{http.MethodPost, "/carts/cart-1/checkout", `{"payment_method":"bank","payer_email":"mike@example.com"}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/carts/cart-9", "", http.StatusNotFound},
+		{http.MethodDelete, "/carts/cart-1", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/payments/payment-9", "", http.StatusNotFound},
+		{http.MethodGet, "/accounts/jane@example.com/balance", "", http.StatusNotFound},
+		{http.MethodGet, "/shelves", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		if code := serve(t, server, test.method, test.path, test.body, nil); code != test.code {
+			t.Errorf("%s %s responded %d, want %d", test.method, test.path, code, test.code)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package bank
+
+import (
+	"math"
+	"time"
+)
+
+// DaysPerYear is the day count convention of the interest rates
+const DaysPerYear = 365
+
+// InterestPosting is the interest credited to an account
+type InterestPosting struct {
+	// Account credited
+	Account *Account
+	// Amount credited
+	Amount float64
+	// Date of the posting, the first day of the month following the accrual
+	Date time.Time
+}
+
+// Available returns the amount that can be debited from the account,
+// including the overdraft of a current account
+func (a *Account) Available() float64 {
+	if a.Type == AccountTypeCurrent {
+		return a.Balance + a.OverdraftLimit
+	}
+	return a.Balance
+}
+
+func (a *Account) canWithdraw(amount float64, date time.Time) bool {
+	if a.WithdrawalLimit <= 0 {
+		return true
+	}
+
+	withdrawn := a.withdrawn
+	if !sameDay(a.withdrawnOn, date) {
+		withdrawn = 0
+	}
+	return withdrawn+amount <= a.WithdrawalLimit
+}
+
+func (a *Account) withdraw(amount float64, date time.Time) {
+	if !sameDay(a.withdrawnOn, date) {
+		a.withdrawnOn = date
+		a.withdrawn = 0
+	}
+	a.withdrawn += amount
+}
+
+// AccrueInterest accrues the interest of the saving accounts day by day up
+// to the date, compounding daily, and posts the interest accrued in every
+// month completed meanwhile. The postings made when the balances changed
+// since the last call are returned too.
+func (g *Gateway) AccrueInterest(date time.Time) []InterestPosting {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var postings []InterestPosting
+
+	for _, account := range g.Accounts {
+		if account.Type != AccountTypeSaving {
+			continue
+		}
+		postings = append(postings, account.postings...)
+		account.postings = nil
+		postings = append(postings, account.accrue(date)...)
+	}
+	return postings
+}
+
+// settle accrues the interest of a saving account up to the day before its
+// balance changes on the date, so that every day earns interest on its
+// closing balance
+func (a *Account) settle(date time.Time) {
+	if a.Type != AccountTypeSaving || a.AccruedThrough.IsZero() {
+		return
+	}
+	a.postings = append(a.postings, a.accrue(startOfDay(date).AddDate(0, 0, -1))...)
+}
+
+func (a *Account) accrue(date time.Time) []InterestPosting {
+	date = startOfDay(date)
+	if a.AccruedThrough.IsZero() {
+		a.AccruedThrough = date
+		return nil
+	}
+
+	var postings []InterestPosting
+	daily := a.InterestRate / DaysPerYear
+
+	for day := startOfDay(a.AccruedThrough).AddDate(0, 0, 1); !day.After(date); day = day.AddDate(0, 0, 1) {
+		if day.Day() == 1 {
+			if posting, ok := a.post(day); ok {
+				postings = append(postings, posting)
+			}
+		}
+		a.AccruedInterest += (a.Balance + a.AccruedInterest) * daily
+		a.AccruedThrough = day
+	}
+
+	return postings
+}
+
+// post credits the whole cents of the accrued interest, keeping the rest
+// accrued
+func (a *Account) post(date time.Time) (InterestPosting, bool) {
+	amount := math.Floor(a.AccruedInterest*100) / 100
+	if amount <= 0 {
+		return InterestPosting{}, false
+	}
+
+	a.Balance += amount
+	a.AccruedInterest -= amount
+	return InterestPosting{Account: a, Amount: amount, Date: date}, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.In(a.Location()).Date()
+	return ay == by && am == bm && ad == bd
+}
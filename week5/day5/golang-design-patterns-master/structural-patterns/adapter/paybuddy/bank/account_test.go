@@ -0,0 +1,158 @@
+package bank
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProcessTransactionLimits(t *testing.T) {
+	day := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		account Account
+		amounts []float64
+		dates   []time.Time
+		err     error
+		balance float64
+	}{
+		{
+			name:    "within the balance",
+			account: Account{Balance: 100},
+			amounts: []float64{100},
+			balance: 0,
+		},
+		{
+			name:    "insufficient funds",
+			account: Account{Balance: 100},
+			amounts: []float64{101},
+			err:     ErrInsufficientFunds,
+			balance: 100,
+		},
+		{
+			name:    "overdraft of a current account",
+			account: Account{Balance: 100, OverdraftLimit: 50},
+			amounts: []float64{150},
+			balance: -50,
+		},
+		{
+			name:    "no overdraft of a saving account",
+			account: Account{Type: AccountTypeSaving, Balance: 100, OverdraftLimit: 50},
+			amounts: []float64{150},
+			err:     ErrInsufficientFunds,
+			balance: 100,
+		},
+		{
+			name:    "daily withdrawal limit",
+			account: Account{Balance: 1000, WithdrawalLimit: 120},
+			amounts: []float64{100, 30},
+			err:     ErrWithdrawalLimit,
+			balance: 900,
+		},
+		{
+			name:    "withdrawal limit renewed the next day",
+			account: Account{Balance: 1000, WithdrawalLimit: 120},
+			amounts: []float64{100, 30},
+			dates:   []time.Time{day, day.AddDate(0, 0, 1)},
+			balance: 870,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from := test.account
+			from.Email = "mike@example.com"
+			to := &Account{Email: "shop@example.com"}
+			gateway := &Gateway{Accounts: []*Account{&from, to}}
+
+			var err error
+			for i, amount := range test.amounts {
+				date := day
+				if test.dates != nil {
+					date = test.dates[i]
+				}
+				err = gateway.ProcessTransaction(&Transaction{
+					FromAccount: &from,
+					ToAccount:   to,
+					Amount:      amount,
+					Date:        date,
+					Reason:      "Payment",
+				})
+			}
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("error is %v, want %v", err, test.err)
+			}
+			if from.Balance != test.balance {
+				t.Errorf("balance is %v, want %v", from.Balance, test.balance)
+			}
+		})
+	}
+}
+
+func TestAccrueInterestOnClosingBalances(t *testing.T) {
+	const rate = 0.0365
+	daily := rate / DaysPerYear
+
+	saving := &Account{Email: "mike@example.com", Type: AccountTypeSaving, Balance: 10000, InterestRate: rate}
+	shop := &Account{Email: "shop@example.com"}
+	gateway := &Gateway{Accounts: []*Account{saving, shop}}
+
+	gateway.AccrueInterest(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	err := gateway.ProcessTransaction(&Transaction{
+		FromAccount: saving,
+		ToAccount:   shop,
+		Amount:      5000,
+		Date:        time.Date(2024, 1, 11, 15, 0, 0, 0, time.UTC),
+		Reason:      "Payment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if postings := gateway.AccrueInterest(time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)); len(postings) != 0 {
+		t.Fatalf("posted %+v within the month", postings)
+	}
+
+	// January 2nd to 10th close on the whole balance, the 11th to 21st on
+	// the balance left after the payment
+	before := 10000*math.Pow(1+daily, 9) - 10000
+	want := (5000+before)*math.Pow(1+daily, 11) - 5000
+	if math.Abs(saving.AccruedInterest-want) > 1e-9 {
+		t.Errorf("accrued %v, want %v", saving.AccruedInterest, want)
+	}
+}
+
+func TestAccrueInterestPostsOnBalanceChange(t *testing.T) {
+	saving := &Account{Email: "mike@example.com", Type: AccountTypeSaving, Balance: 100000, InterestRate: 0.05}
+	shop := &Account{Email: "shop@example.com"}
+	gateway := &Gateway{Accounts: []*Account{saving, shop}}
+
+	gateway.AccrueInterest(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	err := gateway.ProcessTransaction(&Transaction{
+		FromAccount: saving,
+		ToAccount:   shop,
+		Amount:      1000,
+		Date:        time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+		Reason:      "Payment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postings := gateway.AccrueInterest(time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC))
+	if len(postings) != 1 {
+		t.Fatalf("posted %+v, want the January interest", postings)
+	}
+	if date := postings[0].Date; !date.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("posted on %v", date)
+	}
+	if postings[0].Amount <= 0 || saving.Balance != 99000+postings[0].Amount {
+		t.Errorf("posted %v to a balance of %v", postings[0].Amount, saving.Balance)
+	}
+
+	if postings := gateway.AccrueInterest(time.Date(2024, 2, 21, 0, 0, 0, 0, time.UTC)); len(postings) != 0 {
+		t.Errorf("posted %+v twice", postings)
+	}
+}
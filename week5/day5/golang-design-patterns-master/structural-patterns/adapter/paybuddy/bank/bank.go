@@ -1,8 +1,8 @@
 package bank
 
 import (
-	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -26,6 +26,24 @@ type Account struct {
 	Balance float64
 	// Currency of the account
 	Currency string
+	// Type of the account, a current account when not set
+	Type AccountType
+	// OverdraftLimit is how far below zero a current account may go
+	OverdraftLimit float64
+	// WithdrawalLimit is the largest amount debited per day. Zero means no
+	// limit.
+	WithdrawalLimit float64
+	// InterestRate is the yearly interest rate of a saving account, e.g. 0.02
+	InterestRate float64
+	// AccruedInterest is the interest accrued but not posted yet
+	AccruedInterest float64
+	// AccruedThrough is the day the interest is accrued up to. Accrual starts
+	// with the first call of AccrueInterest when it is zero.
+	AccruedThrough time.Time
+
+	withdrawnOn time.Time
+	withdrawn   float64
+	postings    []InterestPosting
 }
 
 // Transaction is the bank transaction
@@ -35,50 +53,103 @@ type Transaction struct {
 	Amount      float64
 	Date        time.Time
 	Reason      string
+	// Reference of the transaction in an external system, e.g. a PayPal
+	// payment id
+	Reference string
 }
 
-// Gateway for the Bank
+// Gateway for the Bank. It guards the balances of its accounts, so that
+// transactions may be processed concurrently.
 type Gateway struct {
 	// Token Key
 	Token string
 	// Accounts
 	Accounts []*Account
+
+	mu sync.Mutex
 }
 
 // FindAccountByEmail finds a bank account
 func (g *Gateway) FindAccountByEmail(email string) (*Account, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.findAccount(email)
+}
+
+// AccountByEmail returns a copy of the bank account, consistent with the
+// transactions processed concurrently
+func (g *Gateway) AccountByEmail(email string) (Account, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	account, err := g.findAccount(email)
+	if err != nil {
+		return Account{}, err
+	}
+	return *account, nil
+}
+
+func (g *Gateway) findAccount(email string) (*Account, error) {
 	for _, account := range g.Accounts {
 		if account.Email == email {
 			return account, nil
 		}
 	}
-	return nil, errors.New("Account Not Found")
+	return nil, &AccountError{Email: email, Err: ErrAccountNotFound}
 }
 
 // ProcessTransaction processes a bank transaction
 func (g *Gateway) ProcessTransaction(t *Transaction) error {
-	if t.FromAccount == nil {
-		return errors.New("FromAccount is missing")
-	}
-	if t.ToAccount == nil {
-		return errors.New("ToAccount is missing")
+	if err := t.validate(); err != nil {
+		return err
 	}
 
-	if t.Reason == "" {
-		return errors.New("Reason is not provided")
-	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
-	if t.Amount <= 0 {
-		return errors.New("Invalid amount")
+	if t.Amount > t.FromAccount.Available() {
+		return t.error(ErrInsufficientFunds)
 	}
 
-	if t.Amount > t.FromAccount.Balance {
-		return errors.New("Insufficient funds")
+	if !t.FromAccount.canWithdraw(t.Amount, t.Date) {
+		return t.error(ErrWithdrawalLimit)
 	}
 
 	fmt.Printf("Transfered %f %s from %s to %s at %v", t.Amount,
 		t.FromAccount.Currency, t.FromAccount.Owner, t.ToAccount.Owner, t.Date)
 
+	t.FromAccount.settle(t.Date)
 	t.FromAccount.Balance -= t.Amount
+	t.FromAccount.withdraw(t.Amount, t.Date)
+	return nil
+}
+
+// validate checks the transaction regardless of the account balances
+func (t *Transaction) validate() error {
+	if t.FromAccount == nil {
+		return ErrMissingFromAccount
+	}
+	if t.ToAccount == nil {
+		return ErrMissingToAccount
+	}
+
+	if t.Reason == "" {
+		return ErrMissingReason
+	}
+
+	if t.Amount <= 0 {
+		return t.error(ErrInvalidAmount)
+	}
+
 	return nil
 }
+
+func (t *Transaction) error(err error) error {
+	return &TransactionError{
+		Account:  t.FromAccount.Email,
+		Amount:   t.Amount,
+		Currency: t.FromAccount.Currency,
+		Err:      err,
+	}
+}
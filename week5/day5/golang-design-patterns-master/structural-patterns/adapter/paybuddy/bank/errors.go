@@ -0,0 +1,63 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrAccountNotFound is returned when no account matches the lookup
+	ErrAccountNotFound = errors.New("Account Not Found")
+	// ErrMissingFromAccount is returned when a transaction has no source account
+	ErrMissingFromAccount = errors.New("FromAccount is missing")
+	// ErrMissingToAccount is returned when a transaction has no target account
+	ErrMissingToAccount = errors.New("ToAccount is missing")
+	// ErrMissingReason is returned when a transaction has no reason
+	ErrMissingReason = errors.New("Reason is not provided")
+	// ErrInvalidAmount is returned when a transaction amount is not positive
+	ErrInvalidAmount = errors.New("Invalid amount")
+	// ErrInsufficientFunds is returned when the source account cannot cover
+	// the transaction amount
+	ErrInsufficientFunds = errors.New("Insufficient funds")
+	// ErrWithdrawalLimit is returned when the transaction exceeds the daily
+	// withdrawal limit of the source account
+	ErrWithdrawalLimit = errors.New("Withdrawal limit exceeded")
+)
+
+// AccountError is a failed account lookup
+type AccountError struct {
+	// Email used for the lookup
+	Email string
+	// Err is the reason, e.g. ErrAccountNotFound
+	Err error
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Email)
+}
+
+// Unwrap returns the reason of the error
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+// TransactionError is a transaction rejected by the bank
+type TransactionError struct {
+	// Account is the email of the account the transaction is debited from
+	Account string
+	// Amount of the transaction
+	Amount float64
+	// Currency of the amount
+	Currency string
+	// Err is the reason, e.g. ErrInsufficientFunds
+	Err error
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("%v: %f %s from %s", e.Err, e.Amount, e.Currency, e.Account)
+}
+
+// Unwrap returns the reason of the error
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,335 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. It lets the Scheduler run on a fake time.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the operating system
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Frequency determines how often a standing order is executed
+type Frequency uint8
+
+const (
+	// FrequencyOnce executes the standing order a single time
+	FrequencyOnce Frequency = iota
+	// FrequencyDaily executes the standing order every day
+	FrequencyDaily
+	// FrequencyWeekly executes the standing order every week
+	FrequencyWeekly
+	// FrequencyMonthly executes the standing order every month. Orders
+	// starting on a day missing in a shorter month run on its last day.
+	FrequencyMonthly
+)
+
+// ErrOrderNotFound is returned when no standing order has the given id
+var ErrOrderNotFound = errors.New("Standing order not found")
+
+// StandingOrder is a transaction executed on a schedule
+type StandingOrder struct {
+	// ID of the standing order
+	ID string
+	// Transaction executed by the order. Its Date is the first execution.
+	Transaction Transaction
+	// Frequency of the executions
+	Frequency Frequency
+	// Until is the last time the order may execute. Zero means forever.
+	Until time.Time
+	// Next is when the next execution is due
+	Next time.Time
+	// Executions is the number of occurrences run so far, failed or not
+	Executions int
+	// Attempts is the number of failed attempts of the current occurrence
+	Attempts int
+	// Done is set when no more executions are due
+	Done bool
+
+	retryAt time.Time
+}
+
+// Execution is the outcome of a standing order attempt
+type Execution struct {
+	// OrderID is the executed standing order
+	OrderID string
+	// Transaction processed by the attempt
+	Transaction Transaction
+	// Due is when the occurrence was due
+	Due time.Time
+	// Attempt is the number of the attempt, starting at 1
+	Attempt int
+	// Err is the reason of a failed attempt
+	Err error
+	// Final is set when the occurrence will not be attempted again
+	Final bool
+}
+
+// Notifier is told about the standing order executions
+type Notifier interface {
+	// Notify reports the outcome of an attempt
+	Notify(execution Execution)
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(execution Execution)
+
+// Notify calls f(execution)
+func (f NotifierFunc) Notify(execution Execution) {
+	f(execution)
+}
+
+// RetryPolicy determines how failed executions are retried
+type RetryPolicy struct {
+	// MaxAttempts of an occurrence including the first one
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled on every other one
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy tries an occurrence three times within three hours
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Hour,
+}
+
+// Scheduler executes the standing orders through a Gateway
+type Scheduler struct {
+	// Gateway processing the transactions
+	Gateway *Gateway
+	// Clock of the scheduler. The system clock is used when nil.
+	Clock Clock
+	// Retry policy of the failed executions
+	Retry RetryPolicy
+	// Notifier of the execution outcomes, optional
+	Notifier Notifier
+
+	mu     sync.Mutex
+	seq    int
+	orders map[string]*StandingOrder
+}
+
+// NewScheduler creates a scheduler running on the system clock with the
+// default retry policy
+func NewScheduler(gateway *Gateway) *Scheduler {
+	return &Scheduler{
+		Gateway: gateway,
+		Clock:   SystemClock{},
+		Retry:   DefaultRetryPolicy,
+		orders:  make(map[string]*StandingOrder),
+	}
+}
+
+// Schedule registers a standing order executing the transaction from its
+// Date on, at the given frequency, until the given time. A zero Date means
+// now.
+func (s *Scheduler) Schedule(t Transaction, frequency Frequency, until time.Time) (StandingOrder, error) {
+	if err := t.validate(); err != nil {
+		return StandingOrder{}, err
+	}
+
+	if frequency > FrequencyMonthly {
+		return StandingOrder{}, fmt.Errorf("Unknown frequency %d", frequency)
+	}
+
+	if t.Date.IsZero() {
+		t.Date = s.now()
+	}
+
+	if !until.IsZero() && until.Before(t.Date) {
+		return StandingOrder{}, errors.New("The standing order ends before it starts")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.orders == nil {
+		s.orders = make(map[string]*StandingOrder)
+	}
+
+	s.seq++
+	order := &StandingOrder{
+		ID:          fmt.Sprintf("SO-%06d", s.seq),
+		Transaction: t,
+		Frequency:   frequency,
+		Until:       until,
+		Next:        t.Date,
+	}
+	s.orders[order.ID] = order
+	return *order, nil
+}
+
+// Cancel stops a standing order
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	order.Done = true
+	return nil
+}
+
+// Orders returns a copy of the standing orders ordered by id
+func (s *Scheduler) Orders() []StandingOrder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders := make([]StandingOrder, 0, len(s.orders))
+	for _, order := range s.orders {
+		orders = append(orders, *order)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+	return orders
+}
+
+// RunDue executes the standing orders due at the current clock time,
+// catching up with the occurrences missed since the last run, and returns
+// the outcomes of the attempts
+func (s *Scheduler) RunDue() []Execution {
+	s.mu.Lock()
+
+	now := s.now()
+	ids := make([]string, 0, len(s.orders))
+	for id := range s.orders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var executions []Execution
+	for _, id := range ids {
+		executions = append(executions, s.runOrder(s.orders[id], now)...)
+	}
+	s.mu.Unlock()
+
+	if s.Notifier != nil {
+		for _, execution := range executions {
+			s.Notifier.Notify(execution)
+		}
+	}
+	return executions
+}
+
+// Run executes the due standing orders every interval until the context is
+// done
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOrder(order *StandingOrder, now time.Time) []Execution {
+	var executions []Execution
+
+	for !order.Done && !order.Next.After(now) && !order.retryAt.After(now) {
+		if !order.Until.IsZero() && order.Next.After(order.Until) {
+			order.Done = true
+			break
+		}
+
+		t := order.Transaction
+		t.Date = now
+		err := s.Gateway.ProcessTransaction(&t)
+
+		order.Attempts++
+		execution := Execution{
+			OrderID:     order.ID,
+			Transaction: t,
+			Due:         order.Next,
+			Attempt:     order.Attempts,
+			Err:         err,
+			Final:       err == nil || order.Attempts >= s.maxAttempts(),
+		}
+		executions = append(executions, execution)
+
+		if !execution.Final {
+			order.retryAt = now.Add(s.backoff(order.Attempts))
+			continue
+		}
+
+		order.Executions++
+		order.Attempts = 0
+		order.retryAt = time.Time{}
+		s.advance(order)
+	}
+
+	return executions
+}
+
+// advance moves the order to its next occurrence
+func (s *Scheduler) advance(order *StandingOrder) {
+	start := order.Transaction.Date
+
+	switch order.Frequency {
+	case FrequencyDaily:
+		order.Next = start.AddDate(0, 0, order.Executions)
+	case FrequencyWeekly:
+		order.Next = start.AddDate(0, 0, 7*order.Executions)
+	case FrequencyMonthly:
+		order.Next = addMonths(start, order.Executions)
+	default:
+		order.Done = true
+		return
+	}
+
+	if !order.Until.IsZero() && order.Next.After(order.Until) {
+		order.Done = true
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Clock == nil {
+		return SystemClock{}.Now()
+	}
+	return s.Clock.Now()
+}
+
+func (s *Scheduler) maxAttempts() int {
+	if s.Retry.MaxAttempts < 1 {
+		return 1
+	}
+	return s.Retry.MaxAttempts
+}
+
+func (s *Scheduler) backoff(attempt int) time.Duration {
+	return s.Retry.Backoff << uint(attempt-1)
+}
+
+// addMonths adds months to t keeping its day of month, or using the last
+// day of the month when it is shorter
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
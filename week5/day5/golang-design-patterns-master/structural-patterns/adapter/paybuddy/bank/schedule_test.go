@@ -0,0 +1,179 @@
+package bank
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	tests := []struct {
+		name       string
+		frequency  Frequency
+		start      time.Time
+		until      time.Time
+		now        time.Time
+		balance    float64
+		executions int
+		next       time.Time
+		done       bool
+	}{
+		{
+			name:       "once",
+			frequency:  FrequencyOnce,
+			start:      time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			balance:    900,
+			executions: 1,
+			next:       time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			done:       true,
+		},
+		{
+			name:       "daily catching up",
+			frequency:  FrequencyDaily,
+			start:      time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC),
+			balance:    700,
+			executions: 3,
+			next:       time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "weekly until",
+			frequency:  FrequencyWeekly,
+			start:      time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			until:      time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			now:        time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			balance:    800,
+			executions: 2,
+			next:       time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+			done:       true,
+		},
+		{
+			name:       "monthly on the last day of shorter months",
+			frequency:  FrequencyMonthly,
+			start:      time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+			now:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			balance:    800,
+			executions: 2,
+			next:       time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "not due yet",
+			frequency: FrequencyDaily,
+			start:     time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			now:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			balance:   1000,
+			next:      time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from := &Account{Email: "mike@example.com", Balance: 1000, Currency: "USD"}
+			to := &Account{Email: "shop@example.com", Currency: "USD"}
+			scheduler := NewScheduler(&Gateway{Accounts: []*Account{from, to}})
+			scheduler.Clock = &fakeClock{now: test.now}
+
+			t0 := Transaction{FromAccount: from, ToAccount: to, Amount: 100, Reason: "Rent", Date: test.start}
+			order, err := scheduler.Schedule(t0, test.frequency, test.until)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, execution := range scheduler.RunDue() {
+				if execution.Err != nil {
+					t.Errorf("execution of %v failed: %v", execution.Due, execution.Err)
+				}
+			}
+
+			order = scheduler.Orders()[0]
+			if from.Balance != test.balance {
+				t.Errorf("balance is %v, want %v", from.Balance, test.balance)
+			}
+			if order.Executions != test.executions {
+				t.Errorf("executed %d times, want %d", order.Executions, test.executions)
+			}
+			if !order.Next.Equal(test.next) {
+				t.Errorf("next execution at %v, want %v", order.Next, test.next)
+			}
+			if order.Done != test.done {
+				t.Errorf("done is %v, want %v", order.Done, test.done)
+			}
+		})
+	}
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	from := &Account{Email: "mike@example.com", Balance: 50, Currency: "USD"}
+	to := &Account{Email: "shop@example.com", Currency: "USD"}
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+
+	scheduler := NewScheduler(&Gateway{Accounts: []*Account{from, to}})
+	scheduler.Clock = clock
+
+	var notified []Execution
+	scheduler.Notifier = NotifierFunc(func(execution Execution) {
+		notified = append(notified, execution)
+	})
+
+	t0 := Transaction{FromAccount: from, ToAccount: to, Amount: 100, Reason: "Rent"}
+	if _, err := scheduler.Schedule(t0, FrequencyOnce, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first retry is due an hour later, the second two hours after it
+	steps := []time.Duration{0, 30 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+	for _, step := range steps {
+		clock.now = clock.now.Add(step)
+		scheduler.RunDue()
+	}
+
+	if len(notified) != 3 {
+		t.Fatalf("notified %d executions, want 3", len(notified))
+	}
+	for i, execution := range notified {
+		if execution.Attempt != i+1 || !errors.Is(execution.Err, ErrInsufficientFunds) {
+			t.Errorf("execution %d is %+v", i, execution)
+		}
+		if execution.Final != (i == 2) {
+			t.Errorf("execution %d final is %v", i, execution.Final)
+		}
+	}
+
+	if order := scheduler.Orders()[0]; !order.Done {
+		t.Errorf("the order is not done after its last attempt")
+	}
+}
+
+func TestSchedulerZeroValue(t *testing.T) {
+	from := &Account{Email: "mike@example.com", Balance: 100, Currency: "USD"}
+	to := &Account{Email: "shop@example.com", Currency: "USD"}
+	scheduler := &Scheduler{Gateway: &Gateway{Accounts: []*Account{from, to}}}
+
+	order, err := scheduler.Schedule(Transaction{FromAccount: from, ToAccount: to, Amount: 10, Reason: "Rent"}, FrequencyOnce, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Next.IsZero() {
+		t.Errorf("the order is not scheduled now")
+	}
+
+	if executions := scheduler.RunDue(); len(executions) != 1 || executions[0].Err != nil {
+		t.Errorf("executions are %+v", executions)
+	}
+	if from.Balance != 90 {
+		t.Errorf("balance is %v, want 90", from.Balance)
+	}
+
+	if err := scheduler.Cancel("SO-999999"); err != ErrOrderNotFound {
+		t.Errorf("cancelling an unknown order returned %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/reconcile"
+)
+
+func main() {
+	var (
+		bankFile        = flag.String("bank", "", "JSON file with the bank ledger entries")
+		payPalFile      = flag.String("paypal", "", "JSON file with the PayPal payment records")
+		format          = flag.String("format", "csv", "report format, csv or json")
+		output          = flag.String("o", "", "report file, standard output when empty")
+		dateTolerance   = flag.Duration("date-tolerance", reconcile.DefaultOptions.DateTolerance, "largest date difference of records matched without reference")
+		amountTolerance = flag.Float64("amount-tolerance", reconcile.DefaultOptions.AmountTolerance, "largest amount difference of matched records")
+	)
+	flag.Parse()
+
+	if *bankFile == "" || *payPalFile == "" {
+		fmt.Fprintln(os.Stderr, "The -bank and -paypal flags must be provided")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*bankFile, *payPalFile, *format, *output, reconcile.Options{
+		DateTolerance:   *dateTolerance,
+		AmountTolerance: *amountTolerance,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(bankFile, payPalFile, format, output string, opts reconcile.Options) error {
+	write, err := writer(format)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(bankFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	transactions, err := reconcile.ReadBankTransactions(file)
+	if err != nil {
+		return fmt.Errorf("Reading %s: %v", bankFile, err)
+	}
+
+	file, err = os.Open(payPalFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	payments, err := reconcile.ReadPayPalPayments(file)
+	if err != nil {
+		return fmt.Errorf("Reading %s: %v", payPalFile, err)
+	}
+
+	report := reconcile.Reconcile(transactions, payments, opts)
+
+	if output == "" {
+		return write(report, os.Stdout)
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := write(report, out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func writer(format string) (func(*reconcile.Report, io.Writer) error, error) {
+	switch format {
+	case "csv":
+		return (*reconcile.Report).WriteCSV, nil
+	case "json":
+		return (*reconcile.Report).WriteJSON, nil
+	default:
+		return nil, fmt.Errorf("Unknown report format '%s'", format)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/webhook"
+)
+
+func main() {
+	var (
+		events = flag.String("events", "webhook-events.log", "event log written by the webhook handler")
+		url    = flag.String("url", "", "webhook endpoint the events are re-sent to")
+		secret = flag.String("secret", os.Getenv("PAYBUDDY_WEBHOOK_SECRET"), "webhook signing secret")
+		id     = flag.String("id", "", "replay only the event with this id")
+		failed = flag.Bool("failed", false, "replay only the events that were never processed successfully")
+		dryRun = flag.Bool("dry-run", false, "list the events without sending them")
+	)
+	flag.Parse()
+
+	stored, err := webhook.ReadEvents(*events)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	selected := selectEvents(stored, *id, *failed)
+	if len(selected) == 0 {
+		fmt.Println("No events to replay")
+		return
+	}
+
+	if !*dryRun && *url == "" {
+		fmt.Fprintln(os.Stderr, "The -url flag must be provided")
+		os.Exit(2)
+	}
+
+	for _, event := range selected {
+		if *dryRun {
+			fmt.Printf("%s received at %v\n", event.ID, event.ReceivedAt)
+			continue
+		}
+
+		if err := send(*url, *secret, event); err != nil {
+			fmt.Fprintf(os.Stderr, "Replaying %s failed: %v\n", event.ID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Replayed %s\n", event.ID)
+	}
+}
+
+// selectEvents picks the latest attempt of every event matching the filters
+func selectEvents(stored []webhook.StoredEvent, id string, failedOnly bool) []webhook.StoredEvent {
+	processed := make(map[string]bool)
+	latest := make(map[string]int)
+	var order []string
+
+	for i, event := range stored {
+		if id != "" && event.ID != id {
+			continue
+		}
+		if _, ok := latest[event.ID]; !ok {
+			order = append(order, event.ID)
+		}
+		latest[event.ID] = i
+		if event.Error == "" {
+			processed[event.ID] = true
+		}
+	}
+
+	var selected []webhook.StoredEvent
+	for _, eventID := range order {
+		if failedOnly && processed[eventID] {
+			continue
+		}
+		selected = append(selected, stored[latest[eventID]])
+	}
+	return selected
+}
+
+func send(url, secret string, event webhook.StoredEvent) error {
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(event.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(paypal.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(paypal.WebhookSignatureHeader, webhook.SignReplay(secret, now, event.Payload))
+	req.Header.Set(webhook.ReplayHeader, "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("The webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/api"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal/paypaltest"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/shop"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/webhook"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the API until the server fails, closing the webhook event
+// store on the way out
+func run() error {
+	var (
+		addr          = flag.String("addr", ":8080", "address to listen on")
+		shopEmail     = flag.String("shop-email", "shop@example.com", "email of the shop account receiving the payments")
+		payPalURL     = flag.String("paypal-url", paypal.DefaultBaseURL, "PayPal REST API address")
+		payPalKey     = flag.String("paypal-key", os.Getenv("PAYPAL_API_KEY"), "PayPal API key, an in-process fake PayPal is used when empty")
+		payPalSecret  = flag.String("paypal-secret", os.Getenv("PAYPAL_SECRET"), "PayPal API secret")
+		webhookSecret = flag.String("webhook-secret", os.Getenv("PAYBUDDY_WEBHOOK_SECRET"), "secret of the PayPal webhook, the webhook is disabled when empty")
+		webhookEvents = flag.String("webhook-events", "webhook-events.log", "file keeping the received webhook events")
+	)
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	payPalPayment := &paypal.Payment{
+		APIKey:  *payPalKey,
+		Secret:  *payPalSecret,
+		BaseURL: *payPalURL,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+
+	var fake *paypaltest.Server
+	if *payPalKey == "" {
+		fake = paypaltest.NewServer("paybuddy-key", "paybuddy-secret")
+		defer fake.Close()
+		payPalPayment = fake.Payment()
+		log.Printf("No PayPal API key is provided, using a fake PayPal at %s", fake.URL)
+	}
+
+	gateway := &bank.Gateway{
+		Token: "bank-token",
+		Accounts: []*bank.Account{
+			&bank.Account{
+				Owner:    "iShop",
+				Email:    *shopEmail,
+				Balance:  1000000,
+				Currency: "USD",
+			},
+			&bank.Account{
+				Owner:    "Mike Meadows",
+				Email:    "mike@example.com",
+				Balance:  890300,
+				Currency: "USD",
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	payPalAdapter := &shop.PayPalAdapter{Payment: payPalPayment}
+
+	if *webhookSecret != "" {
+		store, err := webhook.OpenFileStore(*webhookEvents)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		payPalAdapter.Orders = webhook.NewOrders()
+		mux.Handle("/webhooks/paypal", &webhook.Handler{
+			Secret:      *webhookSecret,
+			Tolerance:   5 * time.Minute,
+			Store:       store,
+			Payments:    payPalAdapter.Orders,
+			AllowReplay: true,
+		})
+
+		if fake != nil {
+			fake.SetWebhook(webhookURL(listener.Addr()), *webhookSecret)
+		}
+	}
+
+	mux.Handle("/", api.NewServer(*shopEmail, gateway, map[string]shop.Payment{
+		"bank":   &shop.BankAdapter{Gateway: gateway},
+		"paypal": payPalAdapter,
+	}))
+
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("paybuddy is listening on %s", listener.Addr())
+	return httpServer.Serve(listener)
+}
+
+// webhookURL returns the address of the PayPal webhook served on the
+// listener address
+func webhookURL(addr net.Addr) string {
+	host := "localhost"
+	if tcp, ok := addr.(*net.TCPAddr); ok && !tcp.IP.IsUnspecified() {
+		host = tcp.IP.String()
+	}
+
+	_, port, _ := net.SplitHostPort(addr.String())
+	return "http://" + net.JoinHostPort(host, port) + "/webhooks/paypal"
+}
@@ -2,95 +2,37 @@ package main
 
 import (
 	"fmt"
+	"net/http/httptest"
 	"time"
 
 	"github.com/going/toolkit/log"
 	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
 	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal/paypaltest"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/shop"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/webhook"
 )
 
-// Payment checkouts order
-type Payment interface {
-	// Pay from email to email this amount
-	Pay(fromEmail, toEmail string, amount float64) error
-}
-
-// Item in the shopping card
-type Item struct {
-	// Name of the item
-	Name string
-	// Price of the item
-	Price float64
-}
-
-// ShoppingCard in online store
-type ShoppingCard struct {
-	// Items im the ShoppingCard
-	Items []*Item
-	// PaymentMethod selected
-	PaymentMethod Payment
-	// ShopEmailAddress address of the shop
-	ShopEmailAddress string
-}
-
-// Checkout checkouts a shopping card
-func (c *ShoppingCard) Checkout(payeeEmail string) error {
-	var total float64
-
-	for _, item := range c.Items {
-		total += item.Price
-	}
-
-	return c.PaymentMethod.Pay(payeeEmail, c.ShopEmailAddress, total)
-}
-
-// BankAdapter adapts bank API
-type BankAdapter struct {
-	// Gateway of the bank
-	Gateway *bank.Gateway
-}
-
-// Pay from email to email this amount
-func (b *BankAdapter) Pay(fromEmail, toEmail string, amount float64) error {
-	fromAccount, err := b.Gateway.FindAccountByEmail(fromEmail)
-	if err != nil {
-		return err
-	}
-
-	toAccount, err := b.Gateway.FindAccountByEmail(toEmail)
-	if err != nil {
-		return err
-	}
-
-	t := &bank.Transaction{
-		FromAccount: fromAccount,
-		ToAccount:   toAccount,
-		Amount:      amount,
-		Date:        time.Now(),
-		Reason:      "Payment to Online Store",
-	}
-
-	return b.Gateway.ProcessTransaction(t)
-}
-
-// PayPalAdapter adapts PayPal API
-type PayPalAdapter struct {
-	Payment *paypal.Payment
-}
-
-// Pay from email to email this amount
-func (p *PayPalAdapter) Pay(fromEmail, toEmail string, amount float64) error {
-	return p.Payment.Send(fromEmail, toEmail, &paypal.Money{Amount: amount, Currency: "USD"})
-}
-
 func main() {
-	payPalAdapter := &PayPalAdapter{
-		Payment: &paypal.Payment{
-			APIKey: "pay-paly-api-key",
-		},
+	payPalServer := paypaltest.NewServer("pay-paly-api-key", "pay-pal-secret")
+	defer payPalServer.Close()
+
+	orders := webhook.NewOrders()
+	webhookServer := httptest.NewServer(&webhook.Handler{
+		Secret:    "webhook-secret",
+		Tolerance: 5 * time.Minute,
+		Store:     &webhook.MemoryStore{},
+		Payments:  orders,
+	})
+	defer webhookServer.Close()
+	payPalServer.SetWebhook(webhookServer.URL, "webhook-secret")
+
+	payPalAdapter := &shop.PayPalAdapter{
+		Payment: payPalServer.Payment(),
+		Orders:  orders,
 	}
 
-	bankAdapter := &BankAdapter{
+	bankAdapter := &shop.BankAdapter{
 		Gateway: &bank.Gateway{
 			Token: "bank-token",
 			Accounts: []*bank.Account{
@@ -110,17 +52,17 @@ func main() {
 		},
 	}
 
-	card := &ShoppingCard{
-		Items: []*Item{
-			&Item{
+	card := &shop.ShoppingCard{
+		Items: []*shop.Item{
+			&shop.Item{
 				Name:  "Tablet",
 				Price: 1000,
 			},
-			&Item{
+			&shop.Item{
 				Name:  "Headphones",
 				Price: 50,
 			},
-			&Item{
+			&shop.Item{
 				Name:  "Smart Watch",
 				Price: 550,
 			},
@@ -134,6 +76,18 @@ func main() {
 		log.Error(err)
 	}
 
+	for _, record := range payPalServer.Payments() {
+		fmt.Printf("Sent %f %s from %s to %s as %s\n", record.Amount.Amount, record.Amount.Currency,
+			record.Sender, record.Recipient, record.ID)
+
+		if err := payPalServer.SetState(record.ID, paypal.PaymentStateCompleted); err != nil {
+			log.Error(err)
+		}
+		if order, ok := orders.Order(record.ID); ok {
+			fmt.Printf("Order paid by %s is %s", order.PaymentID, order.Status)
+		}
+	}
+
 	fmt.Println()
 
 	fmt.Println("Bank transaction")
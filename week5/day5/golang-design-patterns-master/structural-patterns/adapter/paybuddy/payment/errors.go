@@ -0,0 +1,52 @@
+// Package payment defines the error taxonomy shared by the payment adapters
+package payment
+
+import "errors"
+
+var (
+	// ErrDeclined is a payment refused by the provider, e.g. for lack of funds
+	ErrDeclined = errors.New("Payment declined")
+	// ErrInvalidInput is a payment rejected because of malformed details
+	ErrInvalidInput = errors.New("Invalid payment details")
+	// ErrProviderUnavailable is a payment that could not reach the provider.
+	// Such payments may be retried.
+	ErrProviderUnavailable = errors.New("Payment provider unavailable")
+	// ErrProviderMisconfigured is a payment the provider refused to serve,
+	// e.g. for wrong credentials. Retrying does not help until the
+	// configuration is fixed.
+	ErrProviderMisconfigured = errors.New("Payment provider misconfigured")
+)
+
+// Error is a payment failure reported by a provider
+type Error struct {
+	// Kind is one of ErrDeclined, ErrInvalidInput, ErrProviderUnavailable or
+	// ErrProviderMisconfigured
+	Kind error
+	// Provider that failed the payment, e.g. "bank" or "paypal"
+	Provider string
+	// Err is the error returned by the provider
+	Err error
+}
+
+// NewError classifies a provider error
+func NewError(kind error, provider string, err error) *Error {
+	return &Error{
+		Kind:     kind,
+		Provider: provider,
+		Err:      err,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + " by " + e.Provider + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the provider
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
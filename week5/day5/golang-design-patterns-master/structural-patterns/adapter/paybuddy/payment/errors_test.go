@@ -0,0 +1,36 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	errNoFunds := errors.New("No funds")
+	err := fmt.Errorf("Checkout failed: %w", NewError(ErrDeclined, "bank", errNoFunds))
+
+	tests := []struct {
+		target error
+		want   bool
+	}{
+		{ErrDeclined, true},
+		{errNoFunds, true},
+		{ErrInvalidInput, false},
+		{ErrProviderUnavailable, false},
+	}
+
+	for _, test := range tests {
+		if errors.Is(err, test.target) != test.want {
+			t.Errorf("%v is %v: %t, want %t", err, test.target, !test.want, test.want)
+		}
+	}
+
+	var paymentErr *Error
+	if !errors.As(err, &paymentErr) || paymentErr.Provider != "bank" {
+		t.Fatalf("%v is not a payment error of the bank", err)
+	}
+	if want := "Payment declined by bank: No funds"; paymentErr.Error() != want {
+		t.Errorf("the message is %q, want %q", paymentErr.Error(), want)
+	}
+}
@@ -0,0 +1,271 @@
+package paypal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PaymentState is the processing state of a PayPal payment
+type PaymentState string
+
+const (
+	// PaymentStatePending is a payment accepted but not settled yet
+	PaymentStatePending PaymentState = "pending"
+	// PaymentStateCompleted is a settled payment
+	PaymentStateCompleted PaymentState = "completed"
+	// PaymentStateFailed is a payment rejected by PayPal
+	PaymentStateFailed PaymentState = "failed"
+)
+
+// PaymentRecord is a payment as stored by PayPal
+type PaymentRecord struct {
+	// ID of the payment
+	ID string `json:"id"`
+	// State of the payment
+	State PaymentState `json:"state"`
+	// Sender email address
+	Sender string `json:"sender"`
+	// Recipient email address
+	Recipient string `json:"recipient"`
+	// Amount sent
+	Amount Money `json:"amount"`
+	// CreateTime is when the payment was created
+	CreateTime time.Time `json:"create_time"`
+	// UpdateTime is when the payment state last changed
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// APIError is an error response returned by the PayPal API
+type APIError struct {
+	// StatusCode of the HTTP response
+	StatusCode int
+	// Name is the machine readable error name
+	Name string `json:"name"`
+	// Message is the human readable error description
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("PayPal API error %d %s: %s", e.StatusCode, e.Name, e.Message)
+}
+
+type accessToken struct {
+	Value     string `json:"access_token"`
+	Type      string `json:"token_type"`
+	ExpiresIn int    `json:"expires_in"`
+	expiresAt time.Time
+}
+
+// tokenFetch is an access token request shared by the concurrent callers
+type tokenFetch struct {
+	done  chan struct{}
+	token *accessToken
+	err   error
+}
+
+// CreatePayment sends money and returns the payment created by PayPal
+func (p *Payment) CreatePayment(senderEmail, recipientEmail string, money *Money) (*PaymentRecord, error) {
+	sender, recipient, err := validate(senderEmail, recipientEmail, money)
+	if err != nil {
+		return nil, err
+	}
+
+	request := struct {
+		Sender    string `json:"sender"`
+		Recipient string `json:"recipient"`
+		Amount    *Money `json:"amount"`
+	}{
+		Sender:    sender.String(),
+		Recipient: recipient.String(),
+		Amount:    money,
+	}
+
+	record := &PaymentRecord{}
+	if err := p.call(http.MethodPost, "/v1/payments", request, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// PaymentStatus returns the current state of a payment
+func (p *Payment) PaymentStatus(id string) (*PaymentRecord, error) {
+	if id == "" {
+		return nil, ErrMissingPaymentID
+	}
+
+	record := &PaymentRecord{}
+	if err := p.call(http.MethodGet, "/v1/payments/"+url.PathEscape(id), nil, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// call performs an authorized API request, renewing the access token once
+// when the API rejects it
+func (p *Payment) call(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := p.accessToken()
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(method, p.baseURL()+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		err = p.do(req, out)
+		if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			p.resetToken(token)
+			continue
+		}
+		return err
+	}
+}
+
+// accessToken returns a cached OAuth token or exchanges the API key for a
+// new one. Concurrent callers share a single token request, made without
+// holding the lock.
+func (p *Payment) accessToken() (string, error) {
+	p.mu.Lock()
+	if p.token != nil && time.Now().Before(p.token.expiresAt) {
+		value := p.token.Value
+		p.mu.Unlock()
+		return value, nil
+	}
+
+	fetch := p.fetch
+	if fetch != nil {
+		p.mu.Unlock()
+		<-fetch.done
+		return fetch.value()
+	}
+
+	fetch = &tokenFetch{done: make(chan struct{})}
+	p.fetch = fetch
+	p.mu.Unlock()
+
+	fetch.token, fetch.err = p.fetchToken()
+
+	p.mu.Lock()
+	if fetch.err == nil {
+		p.token = fetch.token
+	}
+	p.fetch = nil
+	p.mu.Unlock()
+	close(fetch.done)
+
+	return fetch.value()
+}
+
+func (f *tokenFetch) value() (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.token.Value, nil
+}
+
+// fetchToken exchanges the API key for a new OAuth token
+func (p *Payment) fetchToken() (*accessToken, error) {
+	if p.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, p.baseURL()+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(p.APIKey, p.Secret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	token := &accessToken{}
+	if err := p.do(req, token); err != nil {
+		return nil, err
+	}
+	if token.Value == "" {
+		return nil, errors.New("The PayPal API returned an empty access token")
+	}
+
+	token.expiresAt = time.Now().Add(tokenLifetime(token.ExpiresIn))
+	return token, nil
+}
+
+// tokenLifetime returns how long a token expiring in the seconds is used.
+// It is renewed 30 seconds early to avoid racing the expiry, or half way
+// through the lifetime of short lived tokens.
+func tokenLifetime(expiresIn int) time.Duration {
+	lifetime := time.Duration(expiresIn)*time.Second - 30*time.Second
+	if half := time.Duration(expiresIn) * time.Second / 2; lifetime < half {
+		lifetime = half
+	}
+	return lifetime
+}
+
+func (p *Payment) resetToken(value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != nil && p.token.Value == value {
+		p.token = nil
+	}
+}
+
+func (p *Payment) do(req *http.Request, out interface{}) error {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (p *Payment) baseURL() string {
+	if p.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimSuffix(p.BaseURL, "/")
+}
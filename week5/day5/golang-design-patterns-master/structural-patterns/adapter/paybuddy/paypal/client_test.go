@@ -0,0 +1,207 @@
+package paypal_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal/paypaltest"
+)
+
+var tenDollars = &paypal.Money{Amount: 10, Currency: "USD"}
+
+func TestPaymentCreateAndStatus(t *testing.T) {
+	server := paypaltest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := server.Payment()
+	record, err := client.CreatePayment("Mike@Example.com", "shop@example.com", tenDollars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ID == "" || record.State != paypal.PaymentStatePending || record.Sender != "mike@example.com" {
+		t.Errorf("created %+v", record)
+	}
+
+	if err := server.SetState(record.ID, paypal.PaymentStateCompleted); err != nil {
+		t.Fatal(err)
+	}
+	status, err := client.PaymentStatus(record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != paypal.PaymentStateCompleted {
+		t.Errorf("the payment is %s", status.State)
+	}
+}
+
+func TestPaymentRetriesRejectedToken(t *testing.T) {
+	server := paypaltest.NewServer("key", "secret")
+	defer server.Close()
+
+	client := server.Payment()
+	record, err := client.CreatePayment("mike@example.com", "shop@example.com", tenDollars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.ExpireTokens()
+	if _, err := client.PaymentStatus(record.ID); err != nil {
+		t.Errorf("the request with an expired token failed: %v", err)
+	}
+}
+
+func TestPaymentErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(server *paypaltest.Server, client *paypal.Payment)
+		statusCode int
+		errName    string
+		err        error
+	}{
+		{
+			name:       "declined",
+			setup:      func(server *paypaltest.Server, client *paypal.Payment) { server.Decline("mike@example.com") },
+			statusCode: http.StatusUnprocessableEntity,
+			errName:    "INSTRUMENT_DECLINED",
+		},
+		{
+			name:       "unavailable",
+			setup:      func(server *paypaltest.Server, client *paypal.Payment) { server.SetUnavailable(true) },
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "wrong credentials",
+			setup:      func(server *paypaltest.Server, client *paypal.Payment) { client.Secret = "wrong" },
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:  "missing API key",
+			setup: func(server *paypaltest.Server, client *paypal.Payment) { client.APIKey = "" },
+			err:   paypal.ErrMissingAPIKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := paypaltest.NewServer("key", "secret")
+			defer server.Close()
+
+			client := server.Payment()
+			test.setup(server, client)
+
+			_, err := client.CreatePayment("mike@example.com", "shop@example.com", tenDollars)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("error is %v, want %v", err, test.err)
+				}
+				return
+			}
+
+			var apiErr *paypal.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error is %v, want an API error", err)
+			}
+			if apiErr.StatusCode != test.statusCode {
+				t.Errorf("status code is %d, want %d", apiErr.StatusCode, test.statusCode)
+			}
+			if test.errName != "" && apiErr.Name != test.errName {
+				t.Errorf("error name is %s, want %s", apiErr.Name, test.errName)
+			}
+		})
+	}
+}
+
+func TestPaymentValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		sender    string
+		recipient string
+		money     *paypal.Money
+		err       error
+	}{
+		{"invalid sender", "mike", "shop@example.com", tenDollars, paypal.ErrInvalidSender},
+		{"invalid recipient", "mike@example.com", "shop@", tenDollars, paypal.ErrInvalidRecipient},
+		{"same account", "Mike@example.com", "Mike@EXAMPLE.com", tenDollars, paypal.ErrSameAccount},
+		{"missing money", "mike@example.com", "shop@example.com", nil, paypal.ErrMissingMoney},
+		{"negative amount", "mike@example.com", "shop@example.com", &paypal.Money{Amount: -1, Currency: "USD"}, paypal.ErrInvalidAmount},
+		{"missing currency", "mike@example.com", "shop@example.com", &paypal.Money{Amount: 1}, paypal.ErrMissingCurrency},
+	}
+
+	client := &paypal.Payment{APIKey: "key", BaseURL: "http://127.0.0.1:1"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := client.Send(test.sender, test.recipient, test.money); !errors.Is(err, test.err) {
+				t.Errorf("error is %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+// tokenServer is a PayPal API issuing tokens expiring in the given seconds
+// and counting them
+func tokenServer(expiresIn int, delay time.Duration, tokens *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/oauth2/token" {
+			n := atomic.AddInt32(tokens, 1)
+			time.Sleep(delay)
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+			return
+		}
+		fmt.Fprint(w, `{"id":"PAY-1","state":"pending"}`)
+	}))
+}
+
+func TestPaymentReusesShortLivedToken(t *testing.T) {
+	var tokens int32
+	server := tokenServer(10, 0, &tokens)
+	defer server.Close()
+
+	client := &paypal.Payment{APIKey: "key", BaseURL: server.URL}
+	for i := 0; i < 3; i++ {
+		if _, err := client.PaymentStatus("PAY-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tokens != 1 {
+		t.Errorf("requested %d tokens, want 1", tokens)
+	}
+}
+
+func TestPaymentSharesTokenRequest(t *testing.T) {
+	var tokens int32
+	server := tokenServer(3600, 50*time.Millisecond, &tokens)
+	defer server.Close()
+
+	client := &paypal.Payment{APIKey: "key", BaseURL: server.URL}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.PaymentStatus("PAY-1")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokens != 1 {
+		t.Errorf("requested %d tokens, want 1", tokens)
+	}
+}
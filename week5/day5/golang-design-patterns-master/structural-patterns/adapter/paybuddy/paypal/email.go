@@ -0,0 +1,374 @@
+package paypal
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxAddressLength   = 254
+	maxLocalPartLength = 64
+	maxDomainLength    = 253
+	maxLabelLength     = 63
+)
+
+var (
+	// ErrInvalidAddress matches every email address validation error
+	ErrInvalidAddress = errors.New("Invalid email address")
+	// ErrInvalidSender matches the validation errors of a sender address
+	ErrInvalidSender = errors.New("Invalid sender email address")
+	// ErrInvalidRecipient matches the validation errors of a recipient address
+	ErrInvalidRecipient = errors.New("Invalid recipient email address")
+
+	// ErrEmptyAddress is returned when no address is provided
+	ErrEmptyAddress = errors.New("The address is empty")
+	// ErrAddressTooLong is returned when the address exceeds 254 octets
+	ErrAddressTooLong = errors.New("The address is too long")
+	// ErrMissingAt is returned when the address has no '@' separator
+	ErrMissingAt = errors.New("The address has no '@' separator")
+	// ErrInvalidLocalPart is returned when the part before '@' is malformed
+	ErrInvalidLocalPart = errors.New("The local part is invalid")
+	// ErrInvalidDomain is returned when the part after '@' is malformed
+	ErrInvalidDomain = errors.New("The domain is invalid")
+)
+
+// AddressError describes an email address that failed validation
+type AddressError struct {
+	// Field that holds the address, "sender" or "recipient". It is empty for
+	// addresses parsed directly with ParseAddress.
+	Field string
+	// Address as provided by the caller
+	Address string
+	// Err is the reason, one of ErrEmptyAddress, ErrAddressTooLong,
+	// ErrMissingAt, ErrInvalidLocalPart or ErrInvalidDomain
+	Err error
+}
+
+func (e *AddressError) Error() string {
+	if e.Field == "" {
+		return "Invalid email address: " + e.Err.Error()
+	}
+	return "Invalid " + e.Field + " email address: " + e.Err.Error()
+}
+
+// Unwrap returns the reason of the error
+func (e *AddressError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches ErrInvalidAddress or the sentinel of
+// its field
+func (e *AddressError) Is(target error) bool {
+	switch target {
+	case ErrInvalidAddress:
+		return true
+	case ErrInvalidSender:
+		return e.Field == "sender"
+	case ErrInvalidRecipient:
+		return e.Field == "recipient"
+	default:
+		return false
+	}
+}
+
+// Address is a normalized email address
+type Address struct {
+	// Local is the part before '@'. Unquoted local parts are case folded.
+	Local string
+	// Domain is the lower case ASCII form of the domain. Internationalized
+	// domains are converted to punycode.
+	Domain string
+}
+
+// String returns the address in addr-spec form
+func (a Address) String() string {
+	return a.Local + "@" + a.Domain
+}
+
+// ParseAddress parses an RFC 5322 addr-spec such as "John.Doe+shop@Example.COM"
+// and returns its normalized form. Display names, comments and domain
+// literals are not accepted since they cannot identify a PayPal account.
+func ParseAddress(s string) (Address, error) {
+	address, err := parseAddress(s)
+	if err != nil {
+		return Address{}, &AddressError{Address: s, Err: err}
+	}
+	return address, nil
+}
+
+func parseAddress(s string) (Address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Address{}, ErrEmptyAddress
+	}
+
+	if !utf8.ValidString(s) {
+		return Address{}, ErrInvalidLocalPart
+	}
+
+	at := strings.LastIndexByte(s, '@')
+	if at < 0 {
+		return Address{}, ErrMissingAt
+	}
+
+	local, err := parseLocalPart(s[:at])
+	if err != nil {
+		return Address{}, err
+	}
+
+	domain, err := parseDomain(s[at+1:])
+	if err != nil {
+		return Address{}, err
+	}
+
+	address := Address{Local: local, Domain: domain}
+	if len(address.String()) > maxAddressLength {
+		return Address{}, ErrAddressTooLong
+	}
+	return address, nil
+}
+
+func parseLocalPart(local string) (string, error) {
+	if local == "" || len(local) > maxLocalPartLength {
+		return "", ErrInvalidLocalPart
+	}
+
+	if local[0] == '"' {
+		if !isQuotedString(local) {
+			return "", ErrInvalidLocalPart
+		}
+		return local, nil
+	}
+
+	if !isDotAtom(local) {
+		return "", ErrInvalidLocalPart
+	}
+	return strings.ToLower(local), nil
+}
+
+// isDotAtom reports whether s is a dot-atom, allowing UTF-8 characters as
+// RFC 6531 does
+func isDotAtom(s string) bool {
+	if strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") || strings.Contains(s, "..") {
+		return false
+	}
+
+	for _, r := range s {
+		if r != '.' && !isAtext(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case strings.ContainsRune("!#$%&'*+/=?^_`{|}~-", r):
+		return true
+	default:
+		return r >= utf8.RuneSelf
+	}
+}
+
+// isQuotedString reports whether s is a quoted-string without folding white
+// space
+func isQuotedString(s string) bool {
+	if len(s) < 2 || s[len(s)-1] != '"' {
+		return false
+	}
+
+	escaped := false
+	for _, r := range s[1 : len(s)-1] {
+		switch {
+		case escaped:
+			if r < ' ' && r != '\t' || r == 0x7f {
+				return false
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"' || r < ' ' || r == 0x7f:
+			return false
+		}
+	}
+	return !escaped
+}
+
+func parseDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || strings.HasPrefix(domain, "[") {
+		return "", ErrInvalidDomain
+	}
+
+	labels := strings.Split(strings.ToLower(domain), ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+
+	for i, label := range labels {
+		ascii, err := toASCII(label)
+		if err != nil || !isLabel(ascii) {
+			return "", ErrInvalidDomain
+		}
+		labels[i] = ascii
+	}
+
+	if isNumeric(labels[len(labels)-1]) {
+		return "", ErrInvalidDomain
+	}
+
+	domain = strings.Join(labels, ".")
+	if len(domain) > maxDomainLength {
+		return "", ErrInvalidDomain
+	}
+	return domain, nil
+}
+
+func isLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLength {
+		return false
+	}
+
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(label string) bool {
+	for i := 0; i < len(label); i++ {
+		if label[i] < '0' || label[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// toASCII converts an internationalized domain label to its punycode form
+func toASCII(label string) (string, error) {
+	for i := 0; i < len(label); i++ {
+		if label[i] >= utf8.RuneSelf {
+			encoded, err := punycode(label)
+			if err != nil {
+				return "", err
+			}
+			return "xn--" + encoded, nil
+		}
+	}
+	return label, nil
+}
+
+// punycode encodes a label as described in RFC 3492
+func punycode(label string) (string, error) {
+	const (
+		base        = 36
+		tmin        = 1
+		tmax        = 26
+		initialBias = 72
+		initialN    = 128
+		maxDelta    = 1<<31 - 1
+	)
+
+	runes := []rune(label)
+	output := make([]byte, 0, len(label)+8)
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			output = append(output, byte(r))
+		}
+	}
+
+	basic := len(output)
+	handled := basic
+	if basic > 0 {
+		output = append(output, '-')
+	}
+
+	n, delta, bias := rune(initialN), 0, initialBias
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+
+		if int(m-n) > (maxDelta-delta)/(handled+1) {
+			return "", ErrInvalidDomain
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+
+			q := delta
+			for k := base; ; k += base {
+				t := k - bias
+				if t < tmin {
+					t = tmin
+				} else if t > tmax {
+					t = tmax
+				}
+				if q < t {
+					break
+				}
+				output = append(output, punycodeDigit(t+(q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			output = append(output, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+
+	return string(output), nil
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punycodeAdapt(delta, points int, first bool) int {
+	const (
+		base = 36
+		tmin = 1
+		tmax = 26
+		skew = 38
+		damp = 700
+	)
+
+	if first {
+		delta /= damp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+
+	k := 0
+	for delta > ((base-tmin)*tmax)/2 {
+		delta /= base - tmin
+		k += base
+	}
+	return k + (base-tmin+1)*delta/(delta+skew)
+}
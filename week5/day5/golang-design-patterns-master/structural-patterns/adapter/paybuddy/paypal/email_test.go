@@ -0,0 +1,54 @@
+package paypal_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+		err     error
+	}{
+		{address: "John.Doe+Shop@Example.COM", want: "john.doe+shop@example.com"},
+		{address: " mike@example.com ", want: "mike@example.com"},
+		{address: "a@b.photography", want: "a@b.photography"},
+		{address: "a@sub.domain.co.uk.", want: "a@sub.domain.co.uk"},
+		{address: `"a b"@example.com`, want: `"a b"@example.com`},
+		{address: "x@bücher.de", want: "x@xn--bcher-kva.de"},
+		{address: "x@münchen.de", want: "x@xn--mnchen-3ya.de"},
+		{address: "", err: paypal.ErrEmptyAddress},
+		{address: "mike", err: paypal.ErrMissingAt},
+		{address: "@example.com", err: paypal.ErrInvalidLocalPart},
+		{address: "a..b@example.com", err: paypal.ErrInvalidLocalPart},
+		{address: "a.@example.com", err: paypal.ErrInvalidLocalPart},
+		{address: "a@example", err: paypal.ErrInvalidDomain},
+		{address: "a@-example.com", err: paypal.ErrInvalidDomain},
+		{address: "a@1.2", err: paypal.ErrInvalidDomain},
+		{address: "a@[1.2.3.4]", err: paypal.ErrInvalidDomain},
+		{address: strings.Repeat("a", 65) + "@example.com", err: paypal.ErrInvalidLocalPart},
+	}
+
+	for _, test := range tests {
+		t.Run(test.address, func(t *testing.T) {
+			address, err := paypal.ParseAddress(test.address)
+			if test.err != nil {
+				if !errors.Is(err, test.err) || !errors.Is(err, paypal.ErrInvalidAddress) {
+					t.Errorf("error is %v, want %v", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address.String() != test.want {
+				t.Errorf("address is %q, want %q", address, test.want)
+			}
+		})
+	}
+}
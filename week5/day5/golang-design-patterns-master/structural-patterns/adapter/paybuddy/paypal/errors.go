@@ -0,0 +1,40 @@
+package paypal
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSameAccount is returned when the sender and the recipient are the same
+	ErrSameAccount = errors.New("The sender and the recipient must be different accounts")
+	// ErrMissingMoney is returned when no money is provided
+	ErrMissingMoney = errors.New("The money must be provided")
+	// ErrInvalidAmount is returned when the amount is not positive
+	ErrInvalidAmount = errors.New("The amount cannot be negative")
+	// ErrMissingCurrency is returned when the money has no currency
+	ErrMissingCurrency = errors.New("The currency must be provided")
+	// ErrMissingPaymentID is returned when a payment is looked up without id
+	ErrMissingPaymentID = errors.New("The payment id must be provided")
+	// ErrMissingAPIKey is returned when the client has no API key
+	ErrMissingAPIKey = errors.New("The API key must be provided")
+)
+
+// MoneyError is a payment rejected because of its amount or currency
+type MoneyError struct {
+	// Amount of the payment
+	Amount float64
+	// Currency of the amount
+	Currency string
+	// Err is the reason, ErrInvalidAmount or ErrMissingCurrency
+	Err error
+}
+
+func (e *MoneyError) Error() string {
+	return fmt.Sprintf("%v: %f %q", e.Err, e.Amount, e.Currency)
+}
+
+// Unwrap returns the reason of the error
+func (e *MoneyError) Unwrap() error {
+	return e.Err
+}
@@ -1,49 +1,87 @@
 package paypal
 
 import (
-	"errors"
-	"fmt"
-	"regexp"
+	"net/http"
+	"sync"
 )
 
-var mailRegexp = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
+// DefaultBaseURL is the address of the PayPal REST API
+const DefaultBaseURL = "https://api-m.sandbox.paypal.com"
 
 // Money of PayPal transactions
 type Money struct {
 	// Amount
-	Amount float64
+	Amount float64 `json:"amount"`
 	// Currency for that amount
-	Currency string
+	Currency string `json:"currency"`
 }
 
 // Payment in PayPal
 type Payment struct {
 	// APIKey is the PayPal API key
 	APIKey string
+	// Secret is the PayPal API secret issued with the APIKey
+	Secret string
+	// BaseURL of the PayPal REST API. DefaultBaseURL is used when empty.
+	BaseURL string
+	// Client used to reach the API. http.DefaultClient is used when nil.
+	Client *http.Client
+
+	mu    sync.Mutex
+	token *accessToken
+	fetch *tokenFetch
 }
 
 // Send money
-func (*Payment) Send(senderEmail, recipientEmail string, money *Money) error {
-	if !mailRegexp.MatchString(senderEmail) {
-		return errors.New("Invalid sender email address")
+func (p *Payment) Send(senderEmail, recipientEmail string, money *Money) error {
+	_, err := p.CreatePayment(senderEmail, recipientEmail, money)
+	return err
+}
+
+// validate checks the payment and returns the normalized sender and
+// recipient addresses
+func validate(senderEmail, recipientEmail string, money *Money) (Address, Address, error) {
+	sender, err := parseAccount("sender", senderEmail)
+	if err != nil {
+		return Address{}, Address{}, err
+	}
+
+	recipient, err := parseAccount("recipient", recipientEmail)
+	if err != nil {
+		return Address{}, Address{}, err
+	}
+
+	if sender == recipient {
+		return Address{}, Address{}, ErrSameAccount
+	}
+
+	if err := validateMoney(money); err != nil {
+		return Address{}, Address{}, err
 	}
 
-	if !mailRegexp.MatchString(recipientEmail) {
-		return errors.New("Invalid recipient email address")
+	return sender, recipient, nil
+}
+
+func parseAccount(field, email string) (Address, error) {
+	address, err := parseAddress(email)
+	if err != nil {
+		return Address{}, &AddressError{Field: field, Address: email, Err: err}
 	}
+	return address, nil
+}
 
+func validateMoney(money *Money) error {
 	if money == nil {
-		return errors.New("The money must be provided")
+		return ErrMissingMoney
 	}
 
 	if money.Amount <= 0 {
-		return errors.New("The amount cannot be negative")
+		return &MoneyError{Amount: money.Amount, Currency: money.Currency, Err: ErrInvalidAmount}
 	}
 
 	if money.Currency == "" {
-		return errors.New("The currency must be provided")
+		return &MoneyError{Amount: money.Amount, Currency: money.Currency, Err: ErrMissingCurrency}
 	}
 
-	fmt.Printf("Send %f %s from %s to %s", money.Amount, money.Currency, senderEmail, recipientEmail)
 	return nil
 }
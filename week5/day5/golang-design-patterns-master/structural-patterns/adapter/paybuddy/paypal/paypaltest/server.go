@@ -0,0 +1,310 @@
+// Package paypaltest provides an in-process fake of the PayPal REST API
+package paypaltest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+// TokenLifetime is the lifetime of access tokens issued by the Server
+const TokenLifetime = time.Hour
+
+// Server is a fake PayPal API listening on a local address
+type Server struct {
+	*httptest.Server
+	// APIKey accepted by the token endpoint
+	APIKey string
+	// Secret accepted by the token endpoint
+	Secret string
+
+	mu            sync.Mutex
+	payments      map[string]*paypal.PaymentRecord
+	tokens        map[string]time.Time
+	seq           int
+	webhookURL    string
+	webhookSecret string
+	declined      map[string]bool
+	unavailable   bool
+}
+
+// NewServer starts a fake PayPal API accepting the given credentials
+func NewServer(apiKey, secret string) *Server {
+	s := &Server{
+		APIKey:   apiKey,
+		Secret:   secret,
+		payments: make(map[string]*paypal.PaymentRecord),
+		tokens:   make(map[string]time.Time),
+		declined: make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Payment returns a PayPal client configured for this server
+func (s *Server) Payment() *paypal.Payment {
+	return &paypal.Payment{
+		APIKey:  s.APIKey,
+		Secret:  s.Secret,
+		BaseURL: s.URL,
+		Client:  s.Client(),
+	}
+}
+
+// SetWebhook registers the endpoint notified when a payment changes state
+func (s *Server) SetWebhook(url, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhookURL = url
+	s.webhookSecret = secret
+}
+
+// Payments returns a copy of all the payments ordered by creation
+func (s *Server) Payments() []paypal.PaymentRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]paypal.PaymentRecord, 0, len(s.payments))
+	for _, record := range s.payments {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+	return records
+}
+
+// Decline makes the server decline every payment sent by the sender
+func (s *Server) Decline(sender string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.declined[sender] = true
+}
+
+// SetUnavailable makes the server answer every request with 503 Service
+// Unavailable
+func (s *Server) SetUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unavailable = unavailable
+}
+
+// ExpireTokens invalidates every issued access token
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
+// SetState moves a payment to a new state and notifies the registered
+// webhook, if any
+func (s *Server) SetState(id string, state paypal.PaymentState) error {
+	s.mu.Lock()
+	record, ok := s.payments[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("The payment '%s' is not presented", id)
+	}
+	record.State = state
+	record.UpdateTime = time.Now().UTC()
+	event := &paypal.Event{
+		ID:         "WH-" + randomID(),
+		CreateTime: record.UpdateTime,
+		Resource:   *record,
+	}
+	url, secret := s.webhookURL, s.webhookSecret
+	s.mu.Unlock()
+
+	switch state {
+	case paypal.PaymentStateCompleted:
+		event.Type = paypal.EventPaymentCompleted
+	case paypal.PaymentStateFailed:
+		event.Type = paypal.EventPaymentFailed
+	default:
+		return nil
+	}
+
+	if url == "" {
+		return nil
+	}
+	return s.deliver(url, secret, event)
+}
+
+func (s *Server) deliver(url, secret string, event *paypal.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(paypal.WebhookTimestampHeader, fmt.Sprint(now.Unix()))
+	req.Header.Set(paypal.WebhookSignatureHeader, paypal.SignWebhook(secret, now, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("The webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// ServeHTTP serves the fake PayPal API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	unavailable := s.unavailable
+	s.mu.Unlock()
+
+	if unavailable {
+		writeError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service Unavailable")
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/oauth2/token" && r.Method == http.MethodPost:
+		s.issueToken(w, r)
+	case r.URL.Path == "/v1/payments" && r.Method == http.MethodPost:
+		if s.authorize(w, r) {
+			s.createPayment(w, r)
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/payments/") && r.Method == http.MethodGet:
+		if s.authorize(w, r) {
+			s.getPayment(w, strings.TrimPrefix(r.URL.Path, "/v1/payments/"))
+		}
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "The requested resource does not exist")
+	}
+}
+
+func (s *Server) issueToken(w http.ResponseWriter, r *http.Request) {
+	key, secret, ok := r.BasicAuth()
+	if !ok || key != s.APIKey || secret != s.Secret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "Client Authentication failed")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "Grant Type is not supported")
+		return
+	}
+
+	token := randomID()
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(TokenLifetime)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(TokenLifetime / time.Second),
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	expiresAt, ok := s.tokens[token]
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(expiresAt) {
+		writeError(w, http.StatusUnauthorized, "AUTHENTICATION_FAILURE", "Access token is invalid or expired")
+		return false
+	}
+	return true
+}
+
+func (s *Server) createPayment(w http.ResponseWriter, r *http.Request) {
+	request := struct {
+		Sender    string        `json:"sender"`
+		Recipient string        `json:"recipient"`
+		Amount    *paypal.Money `json:"amount"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "MALFORMED_REQUEST", err.Error())
+		return
+	}
+
+	if request.Sender == "" || request.Recipient == "" || request.Amount == nil ||
+		request.Amount.Amount <= 0 || request.Amount.Currency == "" {
+		writeError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Invalid request - see details")
+		return
+	}
+
+	now := time.Now().UTC()
+	s.mu.Lock()
+	if s.declined[request.Sender] {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnprocessableEntity, "INSTRUMENT_DECLINED", "The instrument presented was declined")
+		return
+	}
+	s.seq++
+	record := &paypal.PaymentRecord{
+		ID:         fmt.Sprintf("PAY-%08d", s.seq),
+		State:      paypal.PaymentStatePending,
+		Sender:     request.Sender,
+		Recipient:  request.Recipient,
+		Amount:     *request.Amount,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	s.payments[record.ID] = record
+	response := *record
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+func (s *Server) getPayment(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	record, ok := s.payments[id]
+	var response paypal.PaymentRecord
+	if ok {
+		response = *record
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "INVALID_RESOURCE_ID", "The requested resource ID was not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, map[string]string{
+		"name":    name,
+		"message": message,
+	})
+}
+
+func randomID() string {
+	data := make([]byte, 12)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
@@ -0,0 +1,90 @@
+package paypal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 signature
+	WebhookSignatureHeader = "Paypal-Transmission-Sig"
+	// WebhookTimestampHeader carries the unix time the webhook was sent at
+	WebhookTimestampHeader = "Paypal-Transmission-Time"
+)
+
+// EventType is the kind of a webhook event
+type EventType string
+
+const (
+	// EventPaymentCompleted is sent when a payment settles
+	EventPaymentCompleted EventType = "PAYMENT.COMPLETED"
+	// EventPaymentFailed is sent when a payment is rejected
+	EventPaymentFailed EventType = "PAYMENT.FAILED"
+)
+
+// Event is a webhook notification sent by PayPal
+type Event struct {
+	// ID of the event
+	ID string `json:"id"`
+	// Type of the event
+	Type EventType `json:"event_type"`
+	// CreateTime is when the event occurred
+	CreateTime time.Time `json:"create_time"`
+	// Resource is the payment the event is about
+	Resource PaymentRecord `json:"resource"`
+}
+
+var (
+	// ErrWebhookSignature is returned when a webhook signature does not match
+	ErrWebhookSignature = errors.New("Invalid webhook signature")
+	// ErrWebhookExpired is returned when a webhook timestamp is out of tolerance
+	ErrWebhookExpired = errors.New("The webhook timestamp is out of tolerance")
+)
+
+// SignWebhook computes the signature of a webhook body sent at timestamp
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	return sign(secret, strconv.FormatInt(timestamp.Unix(), 10), body)
+}
+
+// VerifyWebhook checks the signature headers of a webhook request. Requests
+// older or newer than tolerance are rejected; a zero tolerance disables the
+// check.
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	stamp := header.Get(WebhookTimestampHeader)
+	unix, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+
+	signature, err := hex.DecodeString(header.Get(WebhookSignatureHeader))
+	if err != nil {
+		return ErrWebhookSignature
+	}
+
+	expected, _ := hex.DecodeString(sign(secret, stamp, body))
+	if !hmac.Equal(signature, expected) {
+		return ErrWebhookSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrWebhookExpired
+		}
+	}
+
+	return nil
+}
+
+func sign(secret, stamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
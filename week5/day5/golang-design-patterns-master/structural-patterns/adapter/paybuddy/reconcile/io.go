@@ -0,0 +1,132 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+var csvHeader = []string{
+	"status", "reference", "from", "to", "currency",
+	"bank_amount", "bank_date", "paypal_amount", "paypal_date",
+}
+
+// WriteJSON writes the report as an indented JSON document
+func (report *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV writes the discrepancies as CSV with a header row
+func (report *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range report.Entries {
+		row := []string{
+			string(entry.Status),
+			entry.Reference,
+			entry.From,
+			entry.To,
+			entry.Currency,
+			formatOptionalAmount(entry.BankAmount),
+			formatOptionalDate(entry.BankDate),
+			formatOptionalAmount(entry.PayPalAmount),
+			formatOptionalDate(entry.PayPalDate),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ledgerEntry is the JSON form of a bank transaction
+type ledgerEntry struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"`
+	Reason    string    `json:"reason"`
+	Reference string    `json:"reference"`
+}
+
+// ReadBankTransactions reads a JSON array of bank ledger entries such as
+//
+//	[{"from": "mike@example.com", "to": "shop@example.com", "amount": 10,
+//	  "currency": "USD", "date": "2022-03-01T10:00:00Z", "reference": "PAY-1"}]
+//
+// Accounts are identified by email and currency; transactions referring to
+// the same email in the same currency share the same *bank.Account.
+func ReadBankTransactions(r io.Reader) ([]*bank.Transaction, error) {
+	var entries []ledgerEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	type accountKey struct {
+		email    string
+		currency string
+	}
+	accounts := make(map[accountKey]*bank.Account)
+	account := func(email, currency string) *bank.Account {
+		key := accountKey{email: email, currency: currency}
+		if a, ok := accounts[key]; ok {
+			return a
+		}
+		a := &bank.Account{Email: email, Currency: currency}
+		accounts[key] = a
+		return a
+	}
+
+	transactions := make([]*bank.Transaction, 0, len(entries))
+	for _, entry := range entries {
+		transactions = append(transactions, &bank.Transaction{
+			FromAccount: account(entry.From, entry.Currency),
+			ToAccount:   account(entry.To, entry.Currency),
+			Amount:      entry.Amount,
+			Date:        entry.Date,
+			Reason:      entry.Reason,
+			Reference:   entry.Reference,
+		})
+	}
+	return transactions, nil
+}
+
+// ReadPayPalPayments reads a JSON array of PayPal payment records
+func ReadPayPalPayments(r io.Reader) ([]paypal.PaymentRecord, error) {
+	var payments []paypal.PaymentRecord
+	if err := json.NewDecoder(r).Decode(&payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatOptionalAmount(amount *float64) string {
+	if amount == nil {
+		return ""
+	}
+	return formatAmount(*amount)
+}
+
+func formatOptionalDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,34 @@
+package reconcile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadBankTransactions(t *testing.T) {
+	ledger := `[
+		{"from": "mike@example.com", "to": "shop@example.com", "amount": 10, "currency": "USD", "date": "2022-03-01T10:00:00Z", "reference": "PAY-1"},
+		{"from": "mike@example.com", "to": "shop@example.com", "amount": 20, "currency": "EUR", "date": "2022-03-02T10:00:00Z", "reference": "PAY-2"},
+		{"from": "mike@example.com", "to": "shop@example.com", "amount": 30, "currency": "USD", "date": "2022-03-03T10:00:00Z", "reference": "PAY-3"}
+	]`
+
+	transactions, err := ReadBankTransactions(strings.NewReader(ledger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 3 {
+		t.Fatalf("read %d transactions, want 3", len(transactions))
+	}
+
+	for i, currency := range []string{"USD", "EUR", "USD"} {
+		if got := transactions[i].FromAccount.Currency; got != currency {
+			t.Errorf("the transaction %s is in %s, want %s", transactions[i].Reference, got, currency)
+		}
+	}
+	if transactions[0].FromAccount != transactions[2].FromAccount {
+		t.Errorf("the transactions in the same currency have different accounts")
+	}
+	if transactions[0].ToAccount == transactions[1].ToAccount {
+		t.Errorf("the transactions in different currencies share an account")
+	}
+}
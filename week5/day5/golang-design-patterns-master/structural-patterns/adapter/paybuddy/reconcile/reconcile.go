@@ -0,0 +1,284 @@
+// Package reconcile matches the bank ledger against the PayPal payments
+package reconcile
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+// Status of a reconciliation entry
+type Status string
+
+const (
+	// StatusUnmatchedBank is a bank transaction without PayPal payment
+	StatusUnmatchedBank Status = "unmatched_bank"
+	// StatusUnmatchedPayPal is a PayPal payment without bank transaction
+	StatusUnmatchedPayPal Status = "unmatched_paypal"
+	// StatusDuplicateBank is a bank transaction recorded more than once
+	StatusDuplicateBank Status = "duplicate_bank"
+	// StatusDuplicatePayPal is a PayPal payment recorded more than once
+	StatusDuplicatePayPal Status = "duplicate_paypal"
+	// StatusAmountMismatch is a matched pair whose amounts differ
+	StatusAmountMismatch Status = "amount_mismatch"
+)
+
+// Options tune the matching
+type Options struct {
+	// DateTolerance is the largest time difference between the records of a
+	// pair that are matched without reference
+	DateTolerance time.Duration
+	// AmountTolerance is the largest amount difference between the records of
+	// a pair that is not reported as mismatch
+	AmountTolerance float64
+}
+
+// DefaultOptions tolerate settlement delays of two days and rounding to the
+// cent
+var DefaultOptions = Options{
+	DateTolerance:   48 * time.Hour,
+	AmountTolerance: 0.005,
+}
+
+// Entry is a discrepancy found by the reconciliation
+type Entry struct {
+	// Status of the entry
+	Status Status `json:"status"`
+	// Reference of the records, the PayPal payment id when known
+	Reference string `json:"reference,omitempty"`
+	// From is the email of the paying account
+	From string `json:"from"`
+	// To is the email of the paid account
+	To string `json:"to"`
+	// Currency of the amounts
+	Currency string `json:"currency"`
+	// BankAmount is the amount recorded by the bank
+	BankAmount *float64 `json:"bank_amount,omitempty"`
+	// BankDate is the date recorded by the bank
+	BankDate *time.Time `json:"bank_date,omitempty"`
+	// PayPalAmount is the amount recorded by PayPal
+	PayPalAmount *float64 `json:"paypal_amount,omitempty"`
+	// PayPalDate is the date recorded by PayPal
+	PayPalDate *time.Time `json:"paypal_date,omitempty"`
+}
+
+// Report is the result of a reconciliation
+type Report struct {
+	// Matched is the number of pairs that agree
+	Matched int `json:"matched"`
+	// Entries are the discrepancies
+	Entries []Entry `json:"entries"`
+}
+
+type bankRecord struct {
+	transaction *bank.Transaction
+	from        string
+	to          string
+	matched     bool
+}
+
+type payPalRecord struct {
+	payment *paypal.PaymentRecord
+	from    string
+	to      string
+	matched bool
+}
+
+// Reconcile matches the bank transactions against the PayPal payments.
+// Records sharing a reference are paired first, the rest are paired by
+// accounts, currency, amount and date, so that only the former are reported
+// as amount mismatches. Failed PayPal payments are ignored since they
+// never move money.
+func Reconcile(transactions []*bank.Transaction, payments []paypal.PaymentRecord, opts Options) *Report {
+	report := &Report{Entries: []Entry{}}
+
+	banks := bankRecords(transactions, report)
+	payPals := payPalRecords(payments, report)
+
+	byReference := make(map[string]*payPalRecord)
+	for _, p := range payPals {
+		byReference[p.payment.ID] = p
+	}
+
+	for _, b := range banks {
+		if b.transaction.Reference == "" {
+			continue
+		}
+		if p, ok := byReference[b.transaction.Reference]; ok && !p.matched {
+			report.pair(b, p, opts)
+		}
+	}
+
+	// pairs without reference must agree on everything, the amount within
+	// the tolerance
+	for _, b := range banks {
+		if b.matched {
+			continue
+		}
+
+		var best *payPalRecord
+		var bestDelta time.Duration
+		for _, p := range payPals {
+			if p.matched || !sameParties(b, p) || !sameAmount(b, p, opts) {
+				continue
+			}
+			delta := absDuration(b.transaction.Date.Sub(p.payment.CreateTime))
+			if delta > opts.DateTolerance {
+				continue
+			}
+			if best == nil || delta < bestDelta {
+				best, bestDelta = p, delta
+			}
+		}
+
+		if best != nil {
+			report.pair(b, best, opts)
+		}
+	}
+
+	for _, b := range banks {
+		if !b.matched {
+			report.Entries = append(report.Entries, bankEntry(StatusUnmatchedBank, b))
+		}
+	}
+	for _, p := range payPals {
+		if !p.matched {
+			report.Entries = append(report.Entries, payPalEntry(StatusUnmatchedPayPal, p))
+		}
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return report.Entries[i].Status < report.Entries[j].Status
+	})
+	return report
+}
+
+// bankRecords drops the duplicated transactions, reporting them
+func bankRecords(transactions []*bank.Transaction, report *Report) []*bankRecord {
+	seen := make(map[string]bool)
+	var records []*bankRecord
+
+	for _, t := range transactions {
+		if t == nil || t.FromAccount == nil || t.ToAccount == nil {
+			continue
+		}
+
+		record := &bankRecord{
+			transaction: t,
+			from:        normalizeEmail(t.FromAccount.Email),
+			to:          normalizeEmail(t.ToAccount.Email),
+		}
+
+		key := t.Reference
+		if key == "" {
+			key = strings.Join([]string{record.from, record.to, t.FromAccount.Currency,
+				formatAmount(t.Amount), t.Date.UTC().Format(time.RFC3339Nano)}, "|")
+		}
+		if seen[key] {
+			report.Entries = append(report.Entries, bankEntry(StatusDuplicateBank, record))
+			continue
+		}
+		seen[key] = true
+		records = append(records, record)
+	}
+	return records
+}
+
+// payPalRecords drops the failed and duplicated payments, reporting the
+// latter
+func payPalRecords(payments []paypal.PaymentRecord, report *Report) []*payPalRecord {
+	seen := make(map[string]bool)
+	var records []*payPalRecord
+
+	for i := range payments {
+		payment := &payments[i]
+		if payment.State == paypal.PaymentStateFailed {
+			continue
+		}
+
+		record := &payPalRecord{
+			payment: payment,
+			from:    normalizeEmail(payment.Sender),
+			to:      normalizeEmail(payment.Recipient),
+		}
+
+		if seen[payment.ID] {
+			report.Entries = append(report.Entries, payPalEntry(StatusDuplicatePayPal, record))
+			continue
+		}
+		seen[payment.ID] = true
+		records = append(records, record)
+	}
+	return records
+}
+
+func (report *Report) pair(b *bankRecord, p *payPalRecord, opts Options) {
+	b.matched = true
+	p.matched = true
+
+	if sameAmount(b, p, opts) && b.transaction.FromAccount.Currency == p.payment.Amount.Currency {
+		report.Matched++
+		return
+	}
+
+	entry := bankEntry(StatusAmountMismatch, b)
+	entry.Reference = p.payment.ID
+	entry.PayPalAmount = &p.payment.Amount.Amount
+	entry.PayPalDate = &p.payment.CreateTime
+	if entry.Currency != p.payment.Amount.Currency {
+		entry.Currency += "/" + p.payment.Amount.Currency
+	}
+	report.Entries = append(report.Entries, entry)
+}
+
+func bankEntry(status Status, b *bankRecord) Entry {
+	t := b.transaction
+	return Entry{
+		Status:     status,
+		Reference:  t.Reference,
+		From:       b.from,
+		To:         b.to,
+		Currency:   t.FromAccount.Currency,
+		BankAmount: &t.Amount,
+		BankDate:   &t.Date,
+	}
+}
+
+func payPalEntry(status Status, p *payPalRecord) Entry {
+	return Entry{
+		Status:       status,
+		Reference:    p.payment.ID,
+		From:         p.from,
+		To:           p.to,
+		Currency:     p.payment.Amount.Currency,
+		PayPalAmount: &p.payment.Amount.Amount,
+		PayPalDate:   &p.payment.CreateTime,
+	}
+}
+
+func sameParties(b *bankRecord, p *payPalRecord) bool {
+	return b.from == p.from && b.to == p.to &&
+		b.transaction.FromAccount.Currency == p.payment.Amount.Currency
+}
+
+func sameAmount(b *bankRecord, p *payPalRecord, opts Options) bool {
+	return math.Abs(b.transaction.Amount-p.payment.Amount.Amount) <= opts.AmountTolerance
+}
+
+func normalizeEmail(email string) string {
+	if address, err := paypal.ParseAddress(email); err == nil {
+		return address.String()
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,148 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+var (
+	day  = time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC)
+	mike = &bank.Account{Email: "mike@example.com", Currency: "USD"}
+	shop = &bank.Account{Email: "shop@example.com", Currency: "USD"}
+)
+
+func transaction(reference string, amount float64, date time.Time) *bank.Transaction {
+	return &bank.Transaction{
+		FromAccount: mike,
+		ToAccount:   shop,
+		Amount:      amount,
+		Date:        date,
+		Reason:      "Payment to Online Store",
+		Reference:   reference,
+	}
+}
+
+func payment(id string, amount float64, date time.Time) paypal.PaymentRecord {
+	return paypal.PaymentRecord{
+		ID:         id,
+		State:      paypal.PaymentStateCompleted,
+		Sender:     "Mike@Example.com",
+		Recipient:  "shop@example.com",
+		Amount:     paypal.Money{Amount: amount, Currency: "USD"},
+		CreateTime: date,
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []*bank.Transaction
+		payments     []paypal.PaymentRecord
+		matched      int
+		statuses     []Status
+	}{
+		{
+			name:         "paired by reference",
+			transactions: []*bank.Transaction{transaction("PAY-1", 10, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 10, day.Add(time.Hour))},
+			matched:      1,
+		},
+		{
+			name:         "paired by reference with another amount",
+			transactions: []*bank.Transaction{transaction("PAY-1", 10, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 12, day)},
+			statuses:     []Status{StatusAmountMismatch},
+		},
+		{
+			name:         "paired without reference within the tolerances",
+			transactions: []*bank.Transaction{transaction("", 10.001, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 10, day.Add(24*time.Hour))},
+			matched:      1,
+		},
+		{
+			name:         "unrelated amounts without reference",
+			transactions: []*bank.Transaction{transaction("", 10, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 99, day)},
+			statuses:     []Status{StatusUnmatchedBank, StatusUnmatchedPayPal},
+		},
+		{
+			name:         "too far apart",
+			transactions: []*bank.Transaction{transaction("", 10, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 10, day.Add(72*time.Hour))},
+			statuses:     []Status{StatusUnmatchedBank, StatusUnmatchedPayPal},
+		},
+		{
+			name:         "closest date wins",
+			transactions: []*bank.Transaction{transaction("", 10, day)},
+			payments: []paypal.PaymentRecord{
+				payment("PAY-1", 10, day.Add(30*time.Hour)),
+				payment("PAY-2", 10, day.Add(time.Hour)),
+			},
+			matched:  1,
+			statuses: []Status{StatusUnmatchedPayPal},
+		},
+		{
+			name:         "duplicates",
+			transactions: []*bank.Transaction{transaction("PAY-1", 10, day), transaction("PAY-1", 10, day)},
+			payments:     []paypal.PaymentRecord{payment("PAY-1", 10, day), payment("PAY-1", 10, day)},
+			matched:      1,
+			statuses:     []Status{StatusDuplicateBank, StatusDuplicatePayPal},
+		},
+		{
+			name:         "failed payments",
+			transactions: nil,
+			payments: []paypal.PaymentRecord{func() paypal.PaymentRecord {
+				p := payment("PAY-1", 10, day)
+				p.State = paypal.PaymentStateFailed
+				return p
+			}()},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := Reconcile(test.transactions, test.payments, DefaultOptions)
+
+			if report.Matched != test.matched {
+				t.Errorf("matched %d pairs, want %d", report.Matched, test.matched)
+			}
+
+			var statuses []Status
+			for _, entry := range report.Entries {
+				statuses = append(statuses, entry.Status)
+			}
+			if len(statuses) != len(test.statuses) {
+				t.Fatalf("reported %v, want %v", statuses, test.statuses)
+			}
+			for i := range statuses {
+				if statuses[i] != test.statuses[i] {
+					t.Fatalf("reported %v, want %v", statuses, test.statuses)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileUnmatchedReference(t *testing.T) {
+	report := Reconcile(
+		[]*bank.Transaction{transaction("", 10, day)},
+		[]paypal.PaymentRecord{payment("PAY-1", 99, day)},
+		DefaultOptions,
+	)
+
+	for _, entry := range report.Entries {
+		switch entry.Status {
+		case StatusUnmatchedBank:
+			if entry.BankAmount == nil || *entry.BankAmount != 10 || entry.PayPalAmount != nil {
+				t.Errorf("bank entry %+v", entry)
+			}
+		case StatusUnmatchedPayPal:
+			if entry.Reference != "PAY-1" || entry.PayPalAmount == nil || entry.BankAmount != nil {
+				t.Errorf("PayPal entry %+v", entry)
+			}
+		}
+	}
+}
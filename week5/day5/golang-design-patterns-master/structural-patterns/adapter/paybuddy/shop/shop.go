@@ -0,0 +1,204 @@
+// Package shop implements a shopping card paid through adapted payment APIs
+package shop
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/payment"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/webhook"
+)
+
+// Payment checkouts order
+type Payment interface {
+	// Pay from email to email this amount
+	Pay(fromEmail, toEmail string, amount float64) error
+}
+
+// PaymentStatus is the settlement status of a payment
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending is a payment not confirmed yet
+	PaymentStatusPending PaymentStatus = "pending"
+	// PaymentStatusCompleted is a settled payment
+	PaymentStatusCompleted PaymentStatus = "completed"
+	// PaymentStatusFailed is a payment rejected after it was accepted
+	PaymentStatusFailed PaymentStatus = "failed"
+)
+
+// TrackedPayment is a Payment settled asynchronously and tracked by a
+// reference of the payment provider
+type TrackedPayment interface {
+	Payment
+	// PayTracked pays like Pay and returns the reference of the payment
+	PayTracked(fromEmail, toEmail string, amount float64) (string, error)
+	// Status returns the settlement status of the referenced payment
+	Status(reference string) (PaymentStatus, error)
+}
+
+// Item in the shopping card
+type Item struct {
+	// Name of the item
+	Name string
+	// Price of the item
+	Price float64
+}
+
+// ShoppingCard in online store
+type ShoppingCard struct {
+	// Items im the ShoppingCard
+	Items []*Item
+	// PaymentMethod selected
+	PaymentMethod Payment
+	// ShopEmailAddress address of the shop
+	ShopEmailAddress string
+}
+
+// Total returns the price of all the items
+func (c *ShoppingCard) Total() float64 {
+	var total float64
+
+	for _, item := range c.Items {
+		total += item.Price
+	}
+
+	return total
+}
+
+// Checkout checkouts a shopping card
+func (c *ShoppingCard) Checkout(payeeEmail string) error {
+	return c.PaymentMethod.Pay(payeeEmail, c.ShopEmailAddress, c.Total())
+}
+
+// BankAdapter adapts bank API
+type BankAdapter struct {
+	// Gateway of the bank
+	Gateway *bank.Gateway
+}
+
+// Pay from email to email this amount
+func (b *BankAdapter) Pay(fromEmail, toEmail string, amount float64) error {
+	fromAccount, err := b.Gateway.FindAccountByEmail(fromEmail)
+	if err != nil {
+		return bankError(err)
+	}
+
+	toAccount, err := b.Gateway.FindAccountByEmail(toEmail)
+	if err != nil {
+		return bankError(err)
+	}
+
+	t := &bank.Transaction{
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Amount:      amount,
+		Date:        time.Now(),
+		Reason:      "Payment to Online Store",
+	}
+
+	return bankError(b.Gateway.ProcessTransaction(t))
+}
+
+// bankError maps a bank error into the payment error taxonomy
+func bankError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, bank.ErrInsufficientFunds), errors.Is(err, bank.ErrWithdrawalLimit):
+		return payment.NewError(payment.ErrDeclined, "bank", err)
+	default:
+		return payment.NewError(payment.ErrInvalidInput, "bank", err)
+	}
+}
+
+// PayPalAdapter adapts PayPal API
+type PayPalAdapter struct {
+	Payment *paypal.Payment
+	// Orders awaiting the PayPal confirmation
+	Orders *webhook.Orders
+}
+
+// Pay from email to email this amount
+func (p *PayPalAdapter) Pay(fromEmail, toEmail string, amount float64) error {
+	_, err := p.PayTracked(fromEmail, toEmail, amount)
+	return err
+}
+
+// PayTracked pays and returns the PayPal payment id
+func (p *PayPalAdapter) PayTracked(fromEmail, toEmail string, amount float64) (string, error) {
+	record, err := p.Payment.CreatePayment(fromEmail, toEmail, &paypal.Money{Amount: amount, Currency: "USD"})
+	if err != nil {
+		return "", payPalError(err)
+	}
+
+	if p.Orders != nil {
+		p.Orders.Track(record)
+	}
+	return record.ID, nil
+}
+
+// Status returns the status of a PayPal payment. The orders updated by the
+// webhook are consulted first, PayPal is asked while the order awaits its
+// payment.
+func (p *PayPalAdapter) Status(reference string) (PaymentStatus, error) {
+	if p.Orders != nil {
+		if order, ok := p.Orders.Order(reference); ok {
+			switch order.Status {
+			case webhook.OrderStatusPaid:
+				return PaymentStatusCompleted, nil
+			case webhook.OrderStatusPaymentFailed:
+				return PaymentStatusFailed, nil
+			}
+		}
+	}
+
+	record, err := p.Payment.PaymentStatus(reference)
+	if err != nil {
+		return "", payPalError(err)
+	}
+
+	switch record.State {
+	case paypal.PaymentStateCompleted:
+		return PaymentStatusCompleted, nil
+	case paypal.PaymentStateFailed:
+		return PaymentStatusFailed, nil
+	default:
+		return PaymentStatusPending, nil
+	}
+}
+
+// payPalError maps a PayPal error into the payment error taxonomy
+func payPalError(err error) error {
+	var apiErr *paypal.APIError
+
+	switch {
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.StatusCode == http.StatusPaymentRequired || apiErr.Name == "INSTRUMENT_DECLINED":
+			return payment.NewError(payment.ErrDeclined, "paypal", err)
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return payment.NewError(payment.ErrProviderMisconfigured, "paypal", err)
+		case apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500:
+			return payment.NewError(payment.ErrProviderUnavailable, "paypal", err)
+		default:
+			// the other client errors, e.g. an unknown payment id, are about
+			// the payment details
+			return payment.NewError(payment.ErrInvalidInput, "paypal", err)
+		}
+	case errors.Is(err, paypal.ErrInvalidAddress),
+		errors.Is(err, paypal.ErrSameAccount),
+		errors.Is(err, paypal.ErrMissingMoney),
+		errors.Is(err, paypal.ErrInvalidAmount),
+		errors.Is(err, paypal.ErrMissingCurrency),
+		errors.Is(err, paypal.ErrMissingPaymentID):
+		return payment.NewError(payment.ErrInvalidInput, "paypal", err)
+	case errors.Is(err, paypal.ErrMissingAPIKey):
+		return payment.NewError(payment.ErrProviderMisconfigured, "paypal", err)
+	default:
+		return payment.NewError(payment.ErrProviderUnavailable, "paypal", err)
+	}
+}
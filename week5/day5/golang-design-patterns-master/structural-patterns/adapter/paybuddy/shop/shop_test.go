@@ -0,0 +1,91 @@
+package shop
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/bank"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/payment"
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+func TestBankError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{&bank.TransactionError{Err: bank.ErrInsufficientFunds}, payment.ErrDeclined},
+		{&bank.TransactionError{Err: bank.ErrWithdrawalLimit}, payment.ErrDeclined},
+		{&bank.AccountError{Email: "jane@example.com", Err: bank.ErrAccountNotFound}, payment.ErrInvalidInput},
+		{&bank.TransactionError{Err: bank.ErrInvalidAmount}, payment.ErrInvalidInput},
+	}
+
+	for _, test := range tests {
+		err := bankError(test.err)
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%v is classified as %v, want %v", test.err, err, test.kind)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v does not wrap %v", err, test.err)
+		}
+	}
+
+	if bankError(nil) != nil {
+		t.Errorf("a nil error is classified")
+	}
+}
+
+func TestPayPalError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{&paypal.APIError{StatusCode: http.StatusUnprocessableEntity, Name: "INSTRUMENT_DECLINED"}, payment.ErrDeclined},
+		{&paypal.APIError{StatusCode: http.StatusPaymentRequired}, payment.ErrDeclined},
+		{&paypal.APIError{StatusCode: http.StatusBadRequest}, payment.ErrInvalidInput},
+		{&paypal.APIError{StatusCode: http.StatusUnprocessableEntity, Name: "VALIDATION_ERROR"}, payment.ErrInvalidInput},
+		{&paypal.APIError{StatusCode: http.StatusNotFound, Name: "INVALID_RESOURCE_ID"}, payment.ErrInvalidInput},
+		{&paypal.APIError{StatusCode: http.StatusServiceUnavailable}, payment.ErrProviderUnavailable},
+		{&paypal.APIError{StatusCode: http.StatusInternalServerError}, payment.ErrProviderUnavailable},
+		{&paypal.APIError{StatusCode: http.StatusTooManyRequests}, payment.ErrProviderUnavailable},
+		{&paypal.APIError{StatusCode: http.StatusUnauthorized}, payment.ErrProviderMisconfigured},
+		{&paypal.APIError{StatusCode: http.StatusForbidden}, payment.ErrProviderMisconfigured},
+		{paypal.ErrMissingAPIKey, payment.ErrProviderMisconfigured},
+		{&paypal.AddressError{Field: "sender", Err: paypal.ErrMissingAt}, payment.ErrInvalidInput},
+		{paypal.ErrSameAccount, payment.ErrInvalidInput},
+		{&paypal.MoneyError{Err: paypal.ErrInvalidAmount}, payment.ErrInvalidInput},
+		{fmt.Errorf("dial tcp: connection refused"), payment.ErrProviderUnavailable},
+	}
+
+	for _, test := range tests {
+		err := payPalError(test.err)
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%v is classified as %v, want %v", test.err, err, test.kind)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%v does not wrap %v", err, test.err)
+		}
+	}
+}
+
+func TestBankAdapterPay(t *testing.T) {
+	mike := &bank.Account{Email: "mike@example.com", Balance: 100, Currency: "USD"}
+	card := &ShoppingCard{
+		Items:            []*Item{{Name: "Book", Price: 30}, {Name: "Pen", Price: 10}},
+		PaymentMethod:    &BankAdapter{Gateway: &bank.Gateway{Accounts: []*bank.Account{mike, {Email: "shop@example.com"}}}},
+		ShopEmailAddress: "shop@example.com",
+	}
+
+	if err := card.Checkout("mike@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if mike.Balance != 60 {
+		t.Errorf("the balance is %v, want 60", mike.Balance)
+	}
+
+	if err := card.Checkout("jane@example.com"); !errors.Is(err, payment.ErrInvalidInput) {
+		t.Errorf("paying from an unknown account returned %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+// OrderStatus is the payment status of an order
+type OrderStatus string
+
+const (
+	// OrderStatusAwaitingPayment is an order whose payment is not confirmed yet
+	OrderStatusAwaitingPayment OrderStatus = "awaiting_payment"
+	// OrderStatusPaid is an order whose payment was confirmed
+	OrderStatusPaid OrderStatus = "paid"
+	// OrderStatusPaymentFailed is an order whose payment was rejected
+	OrderStatusPaymentFailed OrderStatus = "payment_failed"
+)
+
+// Order paid with PayPal
+type Order struct {
+	// PaymentID is the PayPal payment of the order
+	PaymentID string
+	// Status of the order
+	Status OrderStatus
+	// UpdatedAt is the PayPal time of the last applied payment change
+	UpdatedAt time.Time
+}
+
+// PaymentUpdater applies the payment changes reported by PayPal
+type PaymentUpdater interface {
+	// UpdatePayment applies the current state of a payment
+	UpdatePayment(record paypal.PaymentRecord) error
+}
+
+// Orders tracks the orders awaiting PayPal confirmation
+type Orders struct {
+	mu     sync.RWMutex
+	orders map[string]*Order
+}
+
+// NewOrders creates an empty order book
+func NewOrders() *Orders {
+	return &Orders{
+		orders: make(map[string]*Order),
+	}
+}
+
+// Track starts tracking the order paid by the payment
+func (o *Orders) Track(record *paypal.PaymentRecord) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.orders[record.ID]; ok {
+		return
+	}
+	o.orders[record.ID] = &Order{
+		PaymentID: record.ID,
+		Status:    orderStatus(record.State),
+		UpdatedAt: record.UpdateTime,
+	}
+}
+
+// Order returns the order paid by the payment
+func (o *Orders) Order(paymentID string) (Order, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	order, ok := o.orders[paymentID]
+	if !ok {
+		return Order{}, false
+	}
+	return *order, true
+}
+
+// UpdatePayment applies the current state of a payment. Changes older than
+// the last applied one are ignored so that events may arrive out of order or
+// be replayed.
+func (o *Orders) UpdatePayment(record paypal.PaymentRecord) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	order, ok := o.orders[record.ID]
+	if !ok {
+		return fmt.Errorf("The payment '%s' does not belong to any order", record.ID)
+	}
+
+	if record.UpdateTime.Before(order.UpdatedAt) {
+		return nil
+	}
+
+	order.Status = orderStatus(record.State)
+	order.UpdatedAt = record.UpdateTime
+	return nil
+}
+
+func orderStatus(state paypal.PaymentState) OrderStatus {
+	switch state {
+	case paypal.PaymentStateCompleted:
+		return OrderStatusPaid
+	case paypal.PaymentStateFailed:
+		return OrderStatusPaymentFailed
+	default:
+		return OrderStatusAwaitingPayment
+	}
+}
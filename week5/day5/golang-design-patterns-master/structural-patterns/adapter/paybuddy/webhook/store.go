@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// StoredEvent is a verified webhook event kept for de-duplication and replay
+type StoredEvent struct {
+	// ID of the event
+	ID string `json:"id"`
+	// ReceivedAt is when the event was received
+	ReceivedAt time.Time `json:"received_at"`
+	// Payload is the raw event body as sent by PayPal
+	Payload json.RawMessage `json:"payload"`
+	// Error of the processing attempt, empty when it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Store keeps the received webhook events
+type Store interface {
+	// Processed reports whether the event was already processed successfully
+	Processed(id string) (bool, error)
+	// Save records a processing attempt of an event
+	Save(event StoredEvent) error
+	// Events returns the recorded attempts in the order they were saved
+	Events() ([]StoredEvent, error)
+}
+
+// MemoryStore keeps the events in memory
+type MemoryStore struct {
+	mu        sync.RWMutex
+	events    []StoredEvent
+	processed map[string]bool
+}
+
+// Processed reports whether the event was already processed successfully
+func (s *MemoryStore) Processed(id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.processed[id], nil
+}
+
+// Save records a processing attempt of an event
+func (s *MemoryStore) Save(event StoredEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(event)
+	return nil
+}
+
+// Events returns the recorded attempts in the order they were saved
+func (s *MemoryStore) Events() ([]StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]StoredEvent, len(s.events))
+	copy(events, s.events)
+	return events, nil
+}
+
+func (s *MemoryStore) add(event StoredEvent) {
+	if s.processed == nil {
+		s.processed = make(map[string]bool)
+	}
+	s.events = append(s.events, event)
+	if event.Error == "" {
+		s.processed[event.ID] = true
+	}
+}
+
+// FileStore keeps the events in a file, one JSON document per line
+type FileStore struct {
+	memory MemoryStore
+	mu     sync.Mutex
+	file   *os.File
+}
+
+// OpenFileStore opens or creates the event log at path
+func OpenFileStore(path string) (*FileStore, error) {
+	events, err := ReadEvents(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FileStore{file: file}
+	for _, event := range events {
+		store.memory.add(event)
+	}
+	return store, nil
+}
+
+// Processed reports whether the event was already processed successfully
+func (s *FileStore) Processed(id string) (bool, error) {
+	return s.memory.Processed(id)
+}
+
+// Save records a processing attempt of an event
+func (s *FileStore) Save(event StoredEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	return s.memory.Save(event)
+}
+
+// Events returns the recorded attempts in the order they were saved
+func (s *FileStore) Events() ([]StoredEvent, error) {
+	return s.memory.Events()
+}
+
+// Close closes the event log
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// ReadEvents reads an event log written by FileStore
+func ReadEvents(path string) ([]StoredEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []StoredEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		event := StoredEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,153 @@
+// Package webhook receives the asynchronous payment events sent by PayPal
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+// ReplayHeader marks a request re-sent by the replay tool. Such events are
+// processed again even when they were processed before. The mark is covered
+// by the signature of SignReplay, so that it cannot be added to a request
+// signed by PayPal.
+const ReplayHeader = "Paybuddy-Replay"
+
+// MaxBodySize is the largest webhook body accepted by the Handler
+const MaxBodySize = 1 << 20
+
+// Handler receives PayPal webhook events
+type Handler struct {
+	// Secret the webhook requests are signed with
+	Secret string
+	// Tolerance of the request timestamp. Zero disables the check.
+	Tolerance time.Duration
+	// Store keeps the received events
+	Store Store
+	// Payments applies the payment changes
+	Payments PaymentUpdater
+	// AllowReplay enables reprocessing of requests sent with ReplayHeader and
+	// signed with SignReplay. Other requests with the header are forbidden.
+	AllowReplay bool
+
+	mu sync.Mutex
+}
+
+// ServeHTTP verifies, de-duplicates and processes a webhook request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	replay := r.Header.Get(ReplayHeader) != ""
+	if replay && !h.AllowReplay {
+		http.Error(w, "Replays are not allowed", http.StatusForbidden)
+		return
+	}
+
+	signed := body
+	if replay {
+		signed = replayPayload(body)
+	}
+	if err := paypal.VerifyWebhook(h.Secret, r.Header, signed, h.Tolerance); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	event := &paypal.Event{}
+	if err := json.Unmarshal(body, event); err != nil || event.ID == "" {
+		http.Error(w, "Malformed event", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.receive(event, body, replay); err != nil {
+		// PayPal redelivers the events that were not acknowledged
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SignReplay computes the signature of a webhook body re-sent at timestamp
+// with ReplayHeader
+func SignReplay(secret string, timestamp time.Time, body []byte) string {
+	return paypal.SignWebhook(secret, timestamp, replayPayload(body))
+}
+
+// replayPayload returns what the signature of a replayed request covers:
+// the replay mark and the body
+func replayPayload(body []byte) []byte {
+	return append([]byte(ReplayHeader+"."), body...)
+}
+
+// Replay processes the stored events again in their original order
+func (h *Handler) Replay(events []StoredEvent) error {
+	for _, stored := range events {
+		event := &paypal.Event{}
+		if err := json.Unmarshal(stored.Payload, event); err != nil {
+			return err
+		}
+		if err := h.receive(event, stored.Payload, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) receive(event *paypal.Event, body []byte, force bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !force {
+		processed, err := h.Store.Processed(event.ID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			return nil
+		}
+	}
+
+	stored := StoredEvent{
+		ID:         event.ID,
+		ReceivedAt: time.Now().UTC(),
+		Payload:    json.RawMessage(body),
+	}
+
+	processErr := h.process(event)
+	if processErr != nil {
+		stored.Error = processErr.Error()
+	}
+
+	if err := h.Store.Save(stored); err != nil {
+		return err
+	}
+	return processErr
+}
+
+func (h *Handler) process(event *paypal.Event) error {
+	switch event.Type {
+	case paypal.EventPaymentCompleted, paypal.EventPaymentFailed:
+		if h.Payments == nil {
+			return errors.New("The payment updater is not configured")
+		}
+		return h.Payments.UpdatePayment(event.Resource)
+	default:
+		// events we are not interested in are acknowledged and dropped
+		return nil
+	}
+}
@@ -0,0 +1,243 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/adapter/paybuddy/paypal"
+)
+
+const secret = "webhook-secret"
+
+var created = time.Date(2022, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+func event(id string, eventType paypal.EventType, state paypal.PaymentState, updated time.Time) []byte {
+	body, err := json.Marshal(&paypal.Event{
+		ID:         id,
+		Type:       eventType,
+		CreateTime: updated,
+		Resource: paypal.PaymentRecord{
+			ID:         "PAY-1",
+			State:      state,
+			CreateTime: created,
+			UpdateTime: updated,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
+
+func request(body []byte, key string, at time.Time) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/paypal", bytes.NewReader(body))
+	r.Header.Set(paypal.WebhookTimestampHeader, fmt.Sprint(at.Unix()))
+	r.Header.Set(paypal.WebhookSignatureHeader, paypal.SignWebhook(key, at, body))
+	return r
+}
+
+func newHandler() (*Handler, *Orders) {
+	orders := NewOrders()
+	orders.Track(&paypal.PaymentRecord{ID: "PAY-1", State: paypal.PaymentStatePending, UpdateTime: created})
+	return &Handler{Secret: secret, Tolerance: time.Minute, Store: &MemoryStore{}, Payments: orders}, orders
+}
+
+func TestHandler(t *testing.T) {
+	completed := event("WH-1", paypal.EventPaymentCompleted, paypal.PaymentStateCompleted, created.Add(time.Minute))
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		code    int
+		status  OrderStatus
+		stored  int
+	}{
+		{
+			name:    "completed payment",
+			request: func() *http.Request { return request(completed, secret, time.Now()) },
+			code:    http.StatusNoContent,
+			status:  OrderStatusPaid,
+			stored:  1,
+		},
+		{
+			name: "failed payment",
+			request: func() *http.Request {
+				return request(event("WH-2", paypal.EventPaymentFailed, paypal.PaymentStateFailed, created.Add(time.Minute)), secret, time.Now())
+			},
+			code:   http.StatusNoContent,
+			status: OrderStatusPaymentFailed,
+			stored: 1,
+		},
+		{
+			name: "other event",
+			request: func() *http.Request {
+				return request(event("WH-3", "PAYMENT.CREATED", "", created), secret, time.Now())
+			},
+			code:   http.StatusNoContent,
+			status: OrderStatusAwaitingPayment,
+			stored: 1,
+		},
+		{
+			name:    "wrong signature",
+			request: func() *http.Request { return request(completed, "other-secret", time.Now()) },
+			code:    http.StatusUnauthorized,
+			status:  OrderStatusAwaitingPayment,
+		},
+		{
+			name:    "expired timestamp",
+			request: func() *http.Request { return request(completed, secret, time.Now().Add(-time.Hour)) },
+			code:    http.StatusUnauthorized,
+			status:  OrderStatusAwaitingPayment,
+		},
+		{
+			name:    "malformed event",
+			request: func() *http.Request { return request([]byte(`{"event_type":1}`), secret, time.Now()) },
+			code:    http.StatusBadRequest,
+			status:  OrderStatusAwaitingPayment,
+		},
+		{
+			name:    "method not allowed",
+			request: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/webhooks/paypal", nil) },
+			code:    http.StatusMethodNotAllowed,
+			status:  OrderStatusAwaitingPayment,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, orders := newHandler()
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, test.request())
+
+			if w.Code != test.code {
+				t.Errorf("responded %d, want %d", w.Code, test.code)
+			}
+			if order, _ := orders.Order("PAY-1"); order.Status != test.status {
+				t.Errorf("the order is %s, want %s", order.Status, test.status)
+			}
+			if events, _ := handler.Store.Events(); len(events) != test.stored {
+				t.Errorf("stored %d events, want %d", len(events), test.stored)
+			}
+		})
+	}
+}
+
+func TestHandlerDeduplicates(t *testing.T) {
+	handler, orders := newHandler()
+	completed := event("WH-1", paypal.EventPaymentCompleted, paypal.PaymentStateCompleted, created.Add(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request(completed, secret, time.Now()))
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("responded %d", w.Code)
+		}
+	}
+
+	if events, _ := handler.Store.Events(); len(events) != 1 {
+		t.Errorf("stored %d events, want 1", len(events))
+	}
+
+	// an event older than the applied one is ignored
+	failed := event("WH-2", paypal.EventPaymentFailed, paypal.PaymentStateFailed, created)
+	handler.ServeHTTP(httptest.NewRecorder(), request(failed, secret, time.Now()))
+	if order, _ := orders.Order("PAY-1"); order.Status != OrderStatusPaid {
+		t.Errorf("the order is %s after an older event", order.Status)
+	}
+}
+
+func TestHandlerReplayHeader(t *testing.T) {
+	completed := event("WH-1", paypal.EventPaymentCompleted, paypal.PaymentStateCompleted, created.Add(time.Minute))
+
+	// replayed returns the completed event re-sent with the replay mark and
+	// signed by sign
+	replayed := func(sign func(secret string, timestamp time.Time, body []byte) string) *http.Request {
+		r := request(completed, secret, time.Now())
+		r.Header.Set(paypal.WebhookSignatureHeader, sign(secret, time.Now(), completed))
+		r.Header.Set(ReplayHeader, "1")
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		allowReplay bool
+		request     *http.Request
+		code        int
+		stored      int
+	}{
+		{"replay", true, replayed(SignReplay), http.StatusNoContent, 2},
+		{"mark added to a PayPal request", true, replayed(paypal.SignWebhook), http.StatusUnauthorized, 1},
+		{"replays not allowed", false, replayed(SignReplay), http.StatusForbidden, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, _ := newHandler()
+			handler.AllowReplay = test.allowReplay
+			handler.ServeHTTP(httptest.NewRecorder(), request(completed, secret, time.Now()))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, test.request)
+
+			if w.Code != test.code {
+				t.Errorf("responded %d, want %d", w.Code, test.code)
+			}
+			if events, _ := handler.Store.Events(); len(events) != test.stored {
+				t.Errorf("stored %d events, want %d", len(events), test.stored)
+			}
+		})
+	}
+}
+
+func TestHandlerReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &Handler{Secret: secret, Store: store, Payments: NewOrders()}
+	completed := event("WH-1", paypal.EventPaymentCompleted, paypal.PaymentStateCompleted, created.Add(time.Minute))
+
+	// the payment is not tracked yet, so the event fails and is redelivered
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request(completed, secret, time.Now()))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("responded %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := ReadEvents(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Error == "" {
+		t.Fatalf("stored %+v", events)
+	}
+
+	_, orders := newHandler()
+	if store, err = OpenFileStore(path); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	handler = &Handler{Secret: secret, Store: store, Payments: orders}
+	if err := handler.Replay(events); err != nil {
+		t.Fatal(err)
+	}
+	if order, _ := orders.Order("PAY-1"); order.Status != OrderStatusPaid {
+		t.Errorf("the order is %s after the replay", order.Status)
+	}
+	if processed, _ := store.Processed("WH-1"); !processed {
+		t.Errorf("the replayed event is not processed")
+	}
+}
@@ -0,0 +1,125 @@
+package uikit
+
+import "math"
+
+// Bounded tells the rectangle it draws in
+type Bounded interface {
+	// Bounds returns the smallest rectangle containing everything drawn,
+	// strokes included
+	Bounds() Rect
+}
+
+// Bounds returns the rectangle of the painted circle
+func (circle *Circle) Bounds() Rect {
+	return strokeBounds(Rect{
+		Location: Point{X: circle.Center.X - circle.Radius, Y: circle.Center.Y - circle.Radius},
+		Size:     Size{Width: 2 * circle.Radius, Height: 2 * circle.Radius},
+	}, circle.Style)
+}
+
+// Bounds returns the rectangle of the painted ellipse
+func (ellipse *Ellipse) Bounds() Rect {
+	sin, cos := math.Sincos(ellipse.Rotation)
+	rx, ry := ellipse.RadiusX, ellipse.RadiusY
+	w := math.Sqrt(rx*rx*cos*cos + ry*ry*sin*sin)
+	h := math.Sqrt(rx*rx*sin*sin + ry*ry*cos*cos)
+
+	return strokeBounds(Rect{
+		Location: Point{X: ellipse.Center.X - w, Y: ellipse.Center.Y - h},
+		Size:     Size{Width: 2 * w, Height: 2 * h},
+	}, ellipse.Style)
+}
+
+// Bounds returns the rectangle of the stroked line
+func (line *Line) Bounds() Rect {
+	return strokeBounds(pointBounds([]Point{line.From, line.To}), line.Style)
+}
+
+// Bounds returns the rectangle of the stroked polyline
+func (polyline *Polyline) Bounds() Rect {
+	return strokeBounds(pointBounds(polyline.Points), polyline.Style)
+}
+
+// Bounds returns the rectangle of the painted rectangle
+func (rectangle *Rectangle) Bounds() Rect {
+	return strokeBounds(pointBounds(roundedRectPolygon(rectangle.Rect, 0, 1)), rectangle.Style)
+}
+
+// Bounds returns the rectangle of the stroked arc
+func (arc *Arc) Bounds() Rect {
+	points := arcPolygon(arc.Center, arc.Radius, arc.Radius, arc.StartAngle, arc.EndAngle, false, 1)
+	return strokeBounds(grow(pointBounds(points), flatness), arc.Style)
+}
+
+// Bounds returns the rectangle of the control points of the painted path,
+// which contains its curves
+func (shape *PathShape) Bounds() Rect {
+	var points []Point
+	for _, segment := range shape.Path.Segments {
+		points = append(points, segment.Points...)
+	}
+	return strokeBounds(pointBounds(points), shape.Style)
+}
+
+// Bounds returns the box of the text
+func (text *Text) Bounds() Rect {
+	return Rect{Location: text.Origin, Size: MeasureText(text.Text, text.Font)}
+}
+
+// Bounds returns the rectangle of the image
+func (img *Image) Bounds() Rect {
+	return pointBounds(roundedRectPolygon(img.Rect, 0, 1))
+}
+
+// Bounds returns the rectangle of the transformed shapes of the group.
+// The shapes which aren't Bounded are ignored.
+func (group *Group) Bounds() Rect {
+	t := group.Transform
+	if t == (Transform{}) {
+		t = Identity
+	}
+
+	var bounds Rect
+	for _, shape := range group.Shapes {
+		if bounded, ok := shape.(Bounded); ok {
+			bounds = bounds.Union(t.applyRect(bounded.Bounds()))
+		}
+	}
+	return bounds
+}
+
+// applyRect returns the bounds of the transformed rectangle
+func (t Transform) applyRect(r Rect) Rect {
+	return pointBounds(t.applyAll(roundedRectPolygon(r, 0, 1)))
+}
+
+// strokeBounds grows the bounds of an outline by half the width of the
+// stroke of the style
+func strokeBounds(r Rect, style *Style) Rect {
+	if s := styleOrDefault(style); s.stroked() {
+		return grow(r, s.StrokeWidth/2)
+	}
+	return r
+}
+
+// pointBounds returns the smallest rectangle containing the points
+func pointBounds(points []Point) Rect {
+	if len(points) == 0 {
+		return Rect{}
+	}
+
+	x0, y0, x1, y1 := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points[1:] {
+		x0, y0 = math.Min(x0, p.X), math.Min(y0, p.Y)
+		x1, y1 = math.Max(x1, p.X), math.Max(y1, p.Y)
+	}
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
+// grow returns the rectangle grown by the distance on every side
+func grow(r Rect, d float64) Rect {
+	return Rect{
+		Location: Point{X: r.Location.X - d, Y: r.Location.Y - d},
+		Size:     Size{Width: r.Size.Width + 2*d, Height: r.Size.Height + 2*d},
+	}
+}
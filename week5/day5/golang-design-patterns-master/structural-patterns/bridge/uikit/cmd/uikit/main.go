@@ -1,12 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
 
 	"github.com/svett/golang-design-patterns/structural-patterns/bridge/uikit"
 )
 
 func main() {
+	pngPath := flag.String("png", "uikit.png", "file the raster drawer writes")
+	svgPath := flag.String("svg", "uikit.svg", "file the SVG drawer writes")
+	formPath := flag.String("form", "form.png", "file the raster drawer writes the widget tree to")
+	ansi := flag.Bool("ansi", false, "color the terminal drawing with ANSI escape sequences")
+	flag.Parse()
+
 	openGL := &uikit.OpenGL{}
 	direct2D := &uikit.Direct2D{}
 
@@ -24,4 +37,278 @@ func main() {
 
 	circle.DrawingContext = direct2D
 	circle.Draw()
+
+	fmt.Println()
+
+	terminal := uikit.NewTerminal(40, 20)
+	terminal.Color = *ansi
+	terminal.Concat(uikit.Scale(0.5, 0.5))
+
+	circle.DrawingContext = terminal
+	circle.Draw()
+	fmt.Print(terminal)
+
+	raster := uikit.NewRaster(320, 320)
+	raster.Clear(color.White)
+
+	if err := draw(scene(raster)); err != nil {
+		exit(err)
+	}
+	if err := writeFile(*pngPath, raster.WritePNG); err != nil {
+		exit(err)
+	}
+	fmt.Printf("Raster drew the scene into %s\n", *pngPath)
+
+	svg := uikit.NewSVG(320, 320)
+
+	if err := draw(scene(svg)); err != nil {
+		exit(err)
+	}
+	if err := os.WriteFile(*svgPath, []byte(svg.String()), 0644); err != nil {
+		exit(err)
+	}
+	fmt.Printf("SVG drew the scene into %s\n", *svgPath)
+
+	recorder := uikit.NewRecorder()
+	if err := draw(scene(recorder)); err != nil {
+		exit(err)
+	}
+
+	var encoded bytes.Buffer
+	if err := recorder.List.WriteBinary(&encoded); err != nil {
+		exit(err)
+	}
+	list, err := uikit.ReadBinary(&encoded)
+	if err != nil {
+		exit(err)
+	}
+
+	replayed := uikit.NewRaster(320, 320)
+	replayed.Clear(color.White)
+	player := &uikit.Player{DrawingContext: replayed, List: list}
+	if err := player.Draw(); err != nil {
+		exit(err)
+	}
+	fmt.Printf("Recorder captured %d commands, replayed the same pixels: %t\n",
+		len(list.Commands), bytes.Equal(replayed.Image.Pix, raster.Image.Pix))
+
+	animated := uikit.NewRaster(320, 320)
+	shapes := scene(animated)
+	frames := uikit.NewScene(animated, uikit.Size{Width: 320, Height: 320}, shapes...)
+	ball := shapes[0].(*uikit.Circle)
+	for i := 0; i < 3; i++ {
+		regions, err := frames.Frame()
+		if err != nil {
+			exit(err)
+		}
+		fmt.Printf("Frame %d redrew %v\n", i, regions)
+
+		ball.Center.X += 10
+		frames.Invalidate(ball)
+	}
+
+	root, ok := form()
+	uikit.Layout(root, uikit.Size{Width: 320, Height: 200})
+
+	raster = uikit.NewRaster(320, 200)
+	raster.Clear(color.White)
+	if err := uikit.Render(root, raster); err != nil {
+		exit(err)
+	}
+	if err := writeFile(*formPath, raster.WritePNG); err != nil {
+		exit(err)
+	}
+	fmt.Printf("Raster drew the widget tree into %s\n", *formPath)
+
+	dispatcher := uikit.NewDispatcher(root)
+	click := uikit.Point{
+		X: ok.Bounds.Location.X + ok.Bounds.Size.Width/2,
+		Y: ok.Bounds.Location.Y + ok.Bounds.Size.Height/2,
+	}
+	dispatcher.Dispatch(&uikit.Event{Type: uikit.PointerDown, Position: click})
+	dispatcher.Dispatch(&uikit.Event{Type: uikit.PointerUp, Position: click})
+	dispatcher.Dispatch(&uikit.Event{Type: uikit.KeyDown, Key: "Enter"})
+}
+
+// form returns a sign in form widget tree and its OK button
+func form() (uikit.Widget, *uikit.Button) {
+	field := func(text string) uikit.Widget {
+		value := uikit.NewLabel(text)
+		value.VerticalAlignment = uikit.AlignCenter
+		value.Margin = uikit.Insets{Left: 6}
+
+		box := &uikit.View{
+			Children: []uikit.Widget{value},
+			Height:   26,
+			Background: &uikit.Style{
+				Fill:        uikit.Solid{Color: color.White},
+				Stroke:      uikit.Solid{Color: color.Gray{Y: 0x99}},
+				StrokeWidth: 1,
+				Opacity:     1,
+			},
+			CornerRadius: 3,
+		}
+		return box
+	}
+	caption := func(text string) uikit.Widget {
+		label := uikit.NewLabel(text)
+		label.VerticalAlignment = uikit.AlignCenter
+		return label
+	}
+
+	title := uikit.NewLabel("Sign in")
+	title.Font.Size = 24
+
+	fields := uikit.NewGrid([]uikit.Track{{}, {Flex: 1}},
+		caption("Email"), field("mike@shop.com"),
+		caption("Password"), field("********"),
+	)
+	fields.ColumnSpacing = 12
+	fields.RowSpacing = 8
+
+	spacer := &uikit.View{Flex: 1}
+	ok := uikit.NewButton("OK")
+	ok.On(uikit.PointerUp, func(e *uikit.Event) {
+		fmt.Println("OK clicked")
+	})
+	ok.On(uikit.KeyDown, func(e *uikit.Event) {
+		fmt.Printf("OK got key %s\n", e.Key)
+	})
+	buttons := uikit.NewStack(uikit.Horizontal, spacer, uikit.NewButton("Cancel"), ok)
+	buttons.Spacing = 8
+
+	filler := &uikit.View{Flex: 1}
+	panel := uikit.NewStack(uikit.Vertical, title, fields, filler, buttons)
+	panel.Spacing = 12
+	panel.Padding = uikit.UniformInsets(16)
+	panel.Margin = uikit.UniformInsets(8)
+	panel.CornerRadius = 8
+	panel.Background = &uikit.Style{
+		Fill:        uikit.Solid{Color: color.RGBA{R: 0xf4, G: 0xf6, B: 0xfa, A: 0xff}},
+		Stroke:      uikit.Solid{Color: color.Gray{Y: 0xbb}},
+		StrokeWidth: 1,
+		Opacity:     1,
+	}
+	panel.On(uikit.PointerDown, func(e *uikit.Event) {
+		fmt.Printf("Panel saw a pointer down at %.0f,%.0f\n", e.Position.X, e.Position.Y)
+	})
+	return panel, ok
+}
+
+// scene returns a shape of every kind drawn by the drawer
+func scene(drawer uikit.Drawer) []uikit.Shape {
+	checkers := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				checkers.Set(x, y, color.RGBA{R: 0xff, G: 0x66, B: 0x00, A: 0xff})
+			}
+		}
+	}
+
+	blue := color.RGBA{R: 0x33, G: 0x99, B: 0xff, A: 0xff}
+	black := uikit.Solid{Color: color.Black}
+
+	outlined := &uikit.Style{Fill: uikit.Solid{Color: blue}, Stroke: black, StrokeWidth: 3, Opacity: 1}
+	dashed := &uikit.Style{Stroke: black, StrokeWidth: 3, Dash: []float64{8, 6}, Opacity: 1}
+	glowing := &uikit.Style{
+		Fill: &uikit.RadialGradient{
+			Center: uikit.Point{X: 60, Y: 60},
+			Radius: 40,
+			Stops: []uikit.GradientStop{
+				{Offset: 0, Color: color.White},
+				{Offset: 1, Color: blue},
+			},
+		},
+		Stroke:      black,
+		StrokeWidth: 3,
+		Opacity:     1,
+	}
+	faded := &uikit.Style{
+		Fill: &uikit.LinearGradient{
+			Start: uikit.Point{X: 120, Y: 20},
+			End:   uikit.Point{X: 200, Y: 100},
+			Stops: []uikit.GradientStop{
+				{Offset: 0, Color: color.RGBA{R: 0xff, G: 0x66, A: 0xff}},
+				{Offset: 1, Color: blue},
+			},
+		},
+		Stroke:      black,
+		StrokeWidth: 3,
+		Opacity:     0.6,
+	}
+	multiplied := &uikit.Style{
+		Fill:      uikit.Solid{Color: color.RGBA{R: 0xff, G: 0xcc, A: 0xff}},
+		Opacity:   1,
+		BlendMode: uikit.BlendMultiply,
+	}
+
+	path := &uikit.Path{}
+	path.MoveTo(uikit.Point{X: 190, Y: 140}).
+		CubicTo(uikit.Point{X: 220, Y: 90}, uikit.Point{X: 270, Y: 190}, uikit.Point{X: 300, Y: 140}).
+		QuadTo(uikit.Point{X: 245, Y: 230}, uikit.Point{X: 190, Y: 140}).
+		Close()
+
+	return []uikit.Shape{
+		&uikit.Circle{DrawingContext: drawer, Center: uikit.Point{X: 60, Y: 60}, Radius: 40, Style: glowing},
+		&uikit.Rectangle{
+			DrawingContext: drawer,
+			Rect:           uikit.Rect{Location: uikit.Point{X: 120, Y: 20}, Size: uikit.Size{Width: 80, Height: 80}},
+			CornerRadius:   12,
+			Style:          faded,
+		},
+		&uikit.Line{DrawingContext: drawer, From: uikit.Point{X: 220, Y: 20}, To: uikit.Point{X: 300, Y: 100}, Style: dashed},
+		&uikit.Polyline{DrawingContext: drawer, Points: []uikit.Point{{X: 20, Y: 200}, {X: 50, Y: 130}, {X: 80, Y: 200}, {X: 110, Y: 130}}, Style: outlined},
+		&uikit.Arc{DrawingContext: drawer, Center: uikit.Point{X: 150, Y: 170}, Radius: 30, StartAngle: 0, EndAngle: 1.5 * math.Pi, Style: dashed},
+		&uikit.PathShape{DrawingContext: drawer, Path: path, Style: outlined},
+		&uikit.Circle{DrawingContext: drawer, Center: uikit.Point{X: 245, Y: 150}, Radius: 30, Style: multiplied},
+		&uikit.Text{DrawingContext: drawer, Origin: uikit.Point{X: 20, Y: 212}, Text: "Hello, uikit!", Font: uikit.DefaultFont, Style: outlined},
+		&uikit.Image{
+			DrawingContext: drawer,
+			Source:         checkers,
+			Rect:           uikit.Rect{Location: uikit.Point{X: 250, Y: 190}, Size: uikit.Size{Width: 40, Height: 40}},
+		},
+		&uikit.Ellipse{DrawingContext: drawer, Center: uikit.Point{X: 60, Y: 275}, RadiusX: 40, RadiusY: 15, Rotation: math.Pi / 6, Style: outlined},
+		&uikit.Ellipse{DrawingContext: drawer, Center: uikit.Point{X: 60, Y: 275}, RadiusX: 40, RadiusY: 15, Rotation: -math.Pi / 6, Style: dashed},
+		&uikit.Group{
+			DrawingContext: drawer,
+			Transform:      uikit.Skew(-math.Pi/8, 0).Then(uikit.Rotate(-math.Pi / 12)).Then(uikit.Translate(130, 270)),
+			Shapes: []uikit.Shape{
+				&uikit.Rectangle{
+					DrawingContext: drawer,
+					Rect:           uikit.Rect{Size: uikit.Size{Width: 150, Height: 30}},
+					CornerRadius:   6,
+					Style:          faded,
+				},
+				&uikit.Text{DrawingContext: drawer, Origin: uikit.Point{X: 8, Y: 7}, Text: "transformed", Font: uikit.DefaultFont},
+			},
+		},
+	}
+}
+
+func draw(shapes []uikit.Shape) error {
+	for _, shape := range shapes {
+		if err := shape.Draw(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/bridge/uikit"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares the output with the golden file or rewrites it with -update
+func golden(t *testing.T, name string, output []byte, equal func(want []byte) bool) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, output, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(want) {
+		failed := filepath.Join(t.TempDir(), name)
+		os.WriteFile(failed, output, 0644)
+		t.Errorf("the drawing differs from %s, see %s", path, failed)
+	}
+}
+
+// tolerance is the largest difference of a channel, in 8-bit units, between
+// a drawing and its golden image. Where the compiler fuses multiply-adds,
+// e.g. on arm64, the coverage of the edges rounds differently.
+const tolerance = 3
+
+// samePixels reports whether the images have the same bounds and colors
+// within the tolerance
+func samePixels(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if !near(r1, r2) || !near(g1, g2) || !near(b1, b2) || !near(a1, a2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// near reports whether the 16-bit channels are within the tolerance
+func near(c1, c2 uint32) bool {
+	if c1 > c2 {
+		c1, c2 = c2, c1
+	}
+	return c2-c1 <= tolerance*0x101
+}
+
+func TestSamePixels(t *testing.T) {
+	gray := func(y uint8, size int) image.Image {
+		img := image.NewGray(image.Rect(0, 0, size, size))
+		img.SetGray(1, 1, color.Gray{Y: y})
+		return img
+	}
+
+	tests := []struct {
+		name string
+		b    image.Image
+		same bool
+	}{
+		{"equal", gray(100, 2), true},
+		{"within the tolerance", gray(100+tolerance, 2), true},
+		{"beyond the tolerance", gray(100+tolerance+1, 2), false},
+		{"other bounds", gray(100, 3), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if same := samePixels(gray(100, 2), test.b); same != test.same {
+				t.Errorf("the pixels are the same: %t, want %t", same, test.same)
+			}
+		})
+	}
+}
+
+func TestRasterScene(t *testing.T) {
+	raster := uikit.NewRaster(320, 320)
+	raster.Clear(color.White)
+	if err := draw(scene(raster)); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	if err := raster.WritePNG(&output); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pixels are compared, so a different PNG encoder does not fail
+	golden(t, "scene.png", output.Bytes(), func(want []byte) bool {
+		decoded, err := png.Decode(bytes.NewReader(want))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return samePixels(decoded, raster.Image)
+	})
+}
+
+func TestSVGScene(t *testing.T) {
+	svg := uikit.NewSVG(320, 320)
+	if err := draw(scene(svg)); err != nil {
+		t.Fatal(err)
+	}
+
+	output := []byte(svg.String())
+	golden(t, "scene.svg", output, func(want []byte) bool {
+		return bytes.Equal(want, output)
+	})
+}
+
+func TestRecordedScene(t *testing.T) {
+	raster := uikit.NewRaster(320, 320)
+	raster.Clear(color.White)
+	if err := draw(scene(raster)); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := uikit.NewRecorder()
+	if err := draw(scene(recorder)); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := uikit.NewRaster(320, 320)
+	replayed.Clear(color.White)
+	player := &uikit.Player{DrawingContext: replayed, List: &recorder.List}
+	if err := player.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Image.Pix, raster.Image.Pix) {
+		t.Errorf("the replayed scene differs from the drawn one")
+	}
+}
@@ -0,0 +1,649 @@
+package uikit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/internal/binenc"
+)
+
+// displayListMagic starts the binary encoding of a display list
+const displayListMagic = "UIDL"
+
+// displayListVersion is the version of the binary encoding
+const displayListVersion = 1
+
+// maxDisplayListLength bounds the lengths read from a binary display list
+const maxDisplayListLength = 1 << 26
+
+const (
+	paintNone byte = iota
+	paintSolid
+	paintLinear
+	paintRadial
+)
+
+// jsonCommand is the JSON encoding of a command
+type jsonCommand struct {
+	Op         string     `json:"op"`
+	Style      *jsonStyle `json:"style,omitempty"`
+	Transform  *Transform `json:"transform,omitempty"`
+	Rect       *Rect      `json:"rect,omitempty"`
+	Points     []Point    `json:"points,omitempty"`
+	Radius     float64    `json:"radius,omitempty"`
+	StartAngle float64    `json:"start_angle,omitempty"`
+	EndAngle   float64    `json:"end_angle,omitempty"`
+	Path       *Path      `json:"path,omitempty"`
+	Text       string     `json:"text,omitempty"`
+	Font       *Font      `json:"font,omitempty"`
+	Image      []byte     `json:"image,omitempty"`
+}
+
+// jsonStyle is the JSON encoding of a style
+type jsonStyle struct {
+	Fill        *jsonPaint `json:"fill,omitempty"`
+	Stroke      *jsonPaint `json:"stroke,omitempty"`
+	StrokeWidth float64    `json:"stroke_width,omitempty"`
+	Dash        []float64  `json:"dash,omitempty"`
+	DashOffset  float64    `json:"dash_offset,omitempty"`
+	Opacity     float64    `json:"opacity"`
+	BlendMode   string     `json:"blend_mode,omitempty"`
+}
+
+// jsonPaint is the JSON encoding of a solid color or a gradient
+type jsonPaint struct {
+	Type   string        `json:"type"`
+	Color  *color.RGBA64 `json:"color,omitempty"`
+	Start  *Point        `json:"start,omitempty"`
+	End    *Point        `json:"end,omitempty"`
+	Center *Point        `json:"center,omitempty"`
+	Radius float64       `json:"radius,omitempty"`
+	Stops  []jsonStop    `json:"stops,omitempty"`
+}
+
+// jsonStop is the JSON encoding of a gradient stop
+type jsonStop struct {
+	Offset float64      `json:"offset"`
+	Color  color.RGBA64 `json:"color"`
+}
+
+// MarshalJSON encodes the command and the fields of its op. Images are
+// encoded as PNG.
+func (c Command) MarshalJSON() ([]byte, error) {
+	out := jsonCommand{Op: c.Op.String()}
+	if out.Op == "" {
+		return nil, fmt.Errorf("Unknown display list op %d", c.Op)
+	}
+
+	switch c.Op {
+	case OpSetStyle:
+		style, err := encodeJSONStyle(c.Style)
+		if err != nil {
+			return nil, err
+		}
+		out.Style = style
+	case OpConcat:
+		out.Transform = &c.Transform
+	case OpEllipse, OpClipRect:
+		out.Rect = &c.Rect
+	case OpLine, OpPolyline:
+		out.Points = c.Points
+	case OpRoundedRect:
+		out.Rect = &c.Rect
+		out.Radius = c.Radius
+	case OpArc:
+		out.Points = c.Points
+		out.Radius = c.Radius
+		out.StartAngle = c.StartAngle
+		out.EndAngle = c.EndAngle
+	case OpPath:
+		out.Path = c.Path
+	case OpText:
+		out.Points = c.Points
+		out.Text = c.Text
+		out.Font = &c.Font
+	case OpImage:
+		data, err := encodePNG(c.Image)
+		if err != nil {
+			return nil, err
+		}
+		out.Rect = &c.Rect
+		out.Image = data
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a command encoded by MarshalJSON
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var in jsonCommand
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	op, err := parseOp(in.Op)
+	if err != nil {
+		return err
+	}
+
+	*c = Command{
+		Op:         op,
+		Points:     in.Points,
+		Radius:     in.Radius,
+		StartAngle: in.StartAngle,
+		EndAngle:   in.EndAngle,
+		Path:       in.Path,
+		Text:       in.Text,
+	}
+	if in.Style != nil {
+		if c.Style, err = decodeJSONStyle(in.Style); err != nil {
+			return err
+		}
+	}
+	if in.Transform != nil {
+		c.Transform = *in.Transform
+	}
+	if in.Rect != nil {
+		c.Rect = *in.Rect
+	}
+	if in.Font != nil {
+		c.Font = *in.Font
+	}
+	if op == OpImage {
+		if c.Image, err = png.Decode(bytes.NewReader(in.Image)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseOp(name string) (Op, error) {
+	for op, opName := range opNames {
+		if op > 0 && opName == name {
+			return Op(op), nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown display list op %q", name)
+}
+
+func encodeJSONStyle(style Style) (*jsonStyle, error) {
+	fill, err := encodeJSONPaint(style.Fill)
+	if err != nil {
+		return nil, err
+	}
+	stroke, err := encodeJSONPaint(style.Stroke)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonStyle{
+		Fill:        fill,
+		Stroke:      stroke,
+		StrokeWidth: style.StrokeWidth,
+		Dash:        style.Dash,
+		DashOffset:  style.DashOffset,
+		Opacity:     style.Opacity,
+		BlendMode:   style.BlendMode.String(),
+	}, nil
+}
+
+func decodeJSONStyle(in *jsonStyle) (Style, error) {
+	fill, err := decodeJSONPaint(in.Fill)
+	if err != nil {
+		return Style{}, err
+	}
+	stroke, err := decodeJSONPaint(in.Stroke)
+	if err != nil {
+		return Style{}, err
+	}
+
+	mode := BlendNormal
+	if in.BlendMode != "" {
+		if mode, err = parseBlendMode(in.BlendMode); err != nil {
+			return Style{}, err
+		}
+	}
+
+	return Style{
+		Fill:        fill,
+		Stroke:      stroke,
+		StrokeWidth: in.StrokeWidth,
+		Dash:        in.Dash,
+		DashOffset:  in.DashOffset,
+		Opacity:     in.Opacity,
+		BlendMode:   mode,
+	}, nil
+}
+
+func parseBlendMode(name string) (BlendMode, error) {
+	for mode, modeName := range blendModeNames {
+		if modeName == name {
+			return BlendMode(mode), nil
+		}
+	}
+	return BlendNormal, fmt.Errorf("Unknown blend mode %q", name)
+}
+
+func encodeJSONPaint(paint Paint) (*jsonPaint, error) {
+	switch p := paint.(type) {
+	case nil:
+		return nil, nil
+	case Solid:
+		out := &jsonPaint{Type: "solid"}
+		if p.Color != nil {
+			c := color.RGBA64Model.Convert(p.Color).(color.RGBA64)
+			out.Color = &c
+		}
+		return out, nil
+	case *LinearGradient:
+		return &jsonPaint{Type: "linear", Start: &p.Start, End: &p.End, Stops: encodeJSONStops(p.Stops)}, nil
+	case *RadialGradient:
+		return &jsonPaint{Type: "radial", Center: &p.Center, Radius: p.Radius, Stops: encodeJSONStops(p.Stops)}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported paint %T", paint)
+	}
+}
+
+func decodeJSONPaint(in *jsonPaint) (Paint, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	switch in.Type {
+	case "solid":
+		if in.Color == nil {
+			return Solid{}, nil
+		}
+		return Solid{Color: *in.Color}, nil
+	case "linear":
+		g := &LinearGradient{Stops: decodeJSONStops(in.Stops)}
+		if in.Start != nil {
+			g.Start = *in.Start
+		}
+		if in.End != nil {
+			g.End = *in.End
+		}
+		return g, nil
+	case "radial":
+		g := &RadialGradient{Radius: in.Radius, Stops: decodeJSONStops(in.Stops)}
+		if in.Center != nil {
+			g.Center = *in.Center
+		}
+		return g, nil
+	default:
+		return nil, fmt.Errorf("Unknown paint type %q", in.Type)
+	}
+}
+
+func encodeJSONStops(stops []GradientStop) []jsonStop {
+	out := make([]jsonStop, len(stops))
+	for i, stop := range stops {
+		out[i] = jsonStop{Offset: stop.Offset}
+		if stop.Color != nil {
+			out[i].Color = color.RGBA64Model.Convert(stop.Color).(color.RGBA64)
+		}
+	}
+	return out
+}
+
+func decodeJSONStops(in []jsonStop) []GradientStop {
+	stops := make([]GradientStop, len(in))
+	for i, stop := range in {
+		stops[i] = GradientStop{Offset: stop.Offset, Color: stop.Color}
+	}
+	return stops
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	if img == nil {
+		return nil, errors.New("Display list image is nil")
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// WriteBinary writes the compact binary encoding of the display list
+func (l *DisplayList) WriteBinary(w io.Writer) error {
+	bw := binaryWriter{binenc.NewWriter(w)}
+
+	bw.Raw([]byte(displayListMagic))
+	bw.Uvarint(displayListVersion)
+	bw.Uvarint(uint64(len(l.Commands)))
+	for i := range l.Commands {
+		if err := bw.command(&l.Commands[i]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadBinary reads a display list written by WriteBinary
+func ReadBinary(r io.Reader) (*DisplayList, error) {
+	br := binaryReader{binenc.NewReader(r, maxDisplayListLength, "Display list")}
+
+	if magic := br.Raw(len(displayListMagic)); br.Err() != nil || string(magic) != displayListMagic {
+		return nil, errors.New("Invalid display list header")
+	}
+	if version := br.Uvarint(); br.Err() == nil && version != displayListVersion {
+		return nil, fmt.Errorf("Unsupported display list version %d", version)
+	}
+
+	list := &DisplayList{}
+	count := br.Length()
+	for i := 0; i < count && br.Err() == nil; i++ {
+		list.Commands = append(list.Commands, br.command())
+	}
+
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// binaryWriter writes the binary encoding, keeping the first error
+type binaryWriter struct {
+	*binenc.Writer
+}
+
+func (bw binaryWriter) string(s string) {
+	bw.Bytes([]byte(s))
+}
+
+func (bw binaryWriter) point(p Point) {
+	bw.Float(p.X)
+	bw.Float(p.Y)
+}
+
+func (bw binaryWriter) points(points []Point) {
+	bw.Uvarint(uint64(len(points)))
+	for _, p := range points {
+		bw.point(p)
+	}
+}
+
+func (bw binaryWriter) rect(r Rect) {
+	bw.point(r.Location)
+	bw.Float(r.Size.Width)
+	bw.Float(r.Size.Height)
+}
+
+func (bw binaryWriter) color(c color.Color) {
+	if c == nil {
+		bw.Byte(0)
+		return
+	}
+
+	r, g, b, a := c.RGBA()
+	bw.Byte(1)
+	for _, channel := range []uint32{r, g, b, a} {
+		bw.Uint16(uint16(channel))
+	}
+}
+
+func (bw binaryWriter) stops(stops []GradientStop) {
+	bw.Uvarint(uint64(len(stops)))
+	for _, stop := range stops {
+		bw.Float(stop.Offset)
+		bw.color(stop.Color)
+	}
+}
+
+func (bw binaryWriter) paint(paint Paint) error {
+	switch p := paint.(type) {
+	case nil:
+		bw.Byte(paintNone)
+	case Solid:
+		bw.Byte(paintSolid)
+		bw.color(p.Color)
+	case *LinearGradient:
+		bw.Byte(paintLinear)
+		bw.point(p.Start)
+		bw.point(p.End)
+		bw.stops(p.Stops)
+	case *RadialGradient:
+		bw.Byte(paintRadial)
+		bw.point(p.Center)
+		bw.Float(p.Radius)
+		bw.stops(p.Stops)
+	default:
+		return fmt.Errorf("Unsupported paint %T", paint)
+	}
+	return nil
+}
+
+func (bw binaryWriter) style(style Style) error {
+	if err := bw.paint(style.Fill); err != nil {
+		return err
+	}
+	if err := bw.paint(style.Stroke); err != nil {
+		return err
+	}
+
+	bw.Float(style.StrokeWidth)
+	bw.Uvarint(uint64(len(style.Dash)))
+	for _, dash := range style.Dash {
+		bw.Float(dash)
+	}
+	bw.Float(style.DashOffset)
+	bw.Float(style.Opacity)
+	bw.Byte(byte(style.BlendMode))
+	return nil
+}
+
+func (bw binaryWriter) path(path *Path) {
+	if path == nil {
+		bw.Uvarint(0)
+		return
+	}
+
+	bw.Uvarint(uint64(len(path.Segments)))
+	for _, segment := range path.Segments {
+		bw.Byte(byte(segment.Op))
+		bw.points(segment.Points)
+	}
+}
+
+func (bw binaryWriter) command(c *Command) error {
+	if c.Op.String() == "" {
+		return fmt.Errorf("Unknown display list op %d", c.Op)
+	}
+	bw.Byte(byte(c.Op))
+
+	switch c.Op {
+	case OpSetStyle:
+		if err := bw.style(c.Style); err != nil {
+			return err
+		}
+	case OpConcat:
+		for _, v := range []float64{c.Transform.A, c.Transform.B, c.Transform.C, c.Transform.D, c.Transform.E, c.Transform.F} {
+			bw.Float(v)
+		}
+	case OpEllipse, OpClipRect:
+		bw.rect(c.Rect)
+	case OpLine, OpPolyline:
+		bw.points(c.Points)
+	case OpRoundedRect:
+		bw.rect(c.Rect)
+		bw.Float(c.Radius)
+	case OpArc:
+		bw.points(c.Points)
+		bw.Float(c.Radius)
+		bw.Float(c.StartAngle)
+		bw.Float(c.EndAngle)
+	case OpPath:
+		bw.path(c.Path)
+	case OpText:
+		bw.points(c.Points)
+		bw.string(c.Text)
+		bw.string(c.Font.Family)
+		bw.Float(c.Font.Size)
+	case OpImage:
+		data, err := encodePNG(c.Image)
+		if err != nil {
+			return err
+		}
+		bw.rect(c.Rect)
+		bw.Bytes(data)
+	}
+	return bw.Err()
+}
+
+// commandBytes returns the binary encoding of the command
+func commandBytes(c *Command) ([]byte, error) {
+	var buffer bytes.Buffer
+	bw := binaryWriter{binenc.NewWriter(&buffer)}
+	if err := bw.command(c); err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// binaryReader reads the binary encoding, keeping the first error
+type binaryReader struct {
+	*binenc.Reader
+}
+
+func (br binaryReader) string() string {
+	return string(br.Bytes())
+}
+
+func (br binaryReader) point() Point {
+	x := br.Float()
+	return Point{X: x, Y: br.Float()}
+}
+
+func (br binaryReader) points() []Point {
+	var points []Point
+	for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+		points = append(points, br.point())
+	}
+	return points
+}
+
+func (br binaryReader) rect() Rect {
+	location := br.point()
+	width := br.Float()
+	return Rect{Location: location, Size: Size{Width: width, Height: br.Float()}}
+}
+
+func (br binaryReader) color() color.Color {
+	if br.Byte() == 0 || br.Err() != nil {
+		return nil
+	}
+
+	r := br.Uint16()
+	g := br.Uint16()
+	b := br.Uint16()
+	return color.RGBA64{R: r, G: g, B: b, A: br.Uint16()}
+}
+
+func (br binaryReader) stops() []GradientStop {
+	var stops []GradientStop
+	for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+		offset := br.Float()
+		stops = append(stops, GradientStop{Offset: offset, Color: br.color()})
+	}
+	return stops
+}
+
+func (br binaryReader) paint() Paint {
+	switch kind := br.Byte(); kind {
+	case paintNone:
+		return nil
+	case paintSolid:
+		return Solid{Color: br.color()}
+	case paintLinear:
+		start := br.point()
+		end := br.point()
+		return &LinearGradient{Start: start, End: end, Stops: br.stops()}
+	case paintRadial:
+		center := br.point()
+		radius := br.Float()
+		return &RadialGradient{Center: center, Radius: radius, Stops: br.stops()}
+	default:
+		br.Fail(fmt.Errorf("Unknown paint type %d", kind))
+		return nil
+	}
+}
+
+func (br binaryReader) style() Style {
+	var style Style
+	style.Fill = br.paint()
+	style.Stroke = br.paint()
+	style.StrokeWidth = br.Float()
+	for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+		style.Dash = append(style.Dash, br.Float())
+	}
+	style.DashOffset = br.Float()
+	style.Opacity = br.Float()
+	style.BlendMode = BlendMode(br.Byte())
+	return style
+}
+
+func (br binaryReader) path() *Path {
+	path := &Path{}
+	for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+		op := PathOp(br.Byte())
+		path.Segments = append(path.Segments, PathSegment{Op: op, Points: br.points()})
+	}
+	return path
+}
+
+func (br binaryReader) command() Command {
+	c := Command{Op: Op(br.Byte())}
+	if br.Err() != nil {
+		return c
+	}
+
+	switch c.Op {
+	case OpSetStyle:
+		c.Style = br.style()
+	case OpSave, OpRestore:
+	case OpConcat:
+		for _, v := range []*float64{&c.Transform.A, &c.Transform.B, &c.Transform.C, &c.Transform.D, &c.Transform.E, &c.Transform.F} {
+			*v = br.Float()
+		}
+	case OpEllipse, OpClipRect:
+		c.Rect = br.rect()
+	case OpLine, OpPolyline:
+		c.Points = br.points()
+	case OpRoundedRect:
+		c.Rect = br.rect()
+		c.Radius = br.Float()
+	case OpArc:
+		c.Points = br.points()
+		c.Radius = br.Float()
+		c.StartAngle = br.Float()
+		c.EndAngle = br.Float()
+	case OpPath:
+		c.Path = br.path()
+	case OpText:
+		c.Points = br.points()
+		c.Text = br.string()
+		c.Font.Family = br.string()
+		c.Font.Size = br.Float()
+	case OpImage:
+		c.Rect = br.rect()
+		data := br.Bytes()
+		if br.Err() == nil {
+			img, err := png.Decode(bytes.NewReader(data))
+			br.Fail(err)
+			c.Image = img
+		}
+	default:
+		br.Fail(fmt.Errorf("Unknown display list op %d", c.Op))
+	}
+	return c
+}
@@ -0,0 +1,251 @@
+package uikit
+
+// EventType is the kind of an input event
+type EventType uint8
+
+const (
+	// PointerDown is sent when a pointer button is pressed
+	PointerDown EventType = iota
+	// PointerUp is sent when a pointer button is released
+	PointerUp
+	// PointerMove is sent when the pointer moves
+	PointerMove
+	// KeyDown is sent when a key is pressed
+	KeyDown
+	// KeyUp is sent when a key is released
+	KeyUp
+)
+
+// IsPointer reports whether the event is a pointer event
+func (t EventType) IsPointer() bool {
+	return t <= PointerMove
+}
+
+// Phase is the stage of the propagation of an event
+type Phase uint8
+
+const (
+	// PhaseCapture goes from the root down to the parent of the target
+	PhaseCapture Phase = iota + 1
+	// PhaseTarget is at the target itself
+	PhaseTarget
+	// PhaseBubble goes from the parent of the target up to the root
+	PhaseBubble
+)
+
+// Modifiers are the modifier keys held during an event
+type Modifiers uint8
+
+const (
+	// ModShift is the shift key
+	ModShift Modifiers = 1 << iota
+	// ModControl is the control key
+	ModControl
+	// ModAlt is the alt or option key
+	ModAlt
+	// ModMeta is the command or windows key
+	ModMeta
+)
+
+// Event is an input event propagated through the widget tree
+type Event struct {
+	// Type of the event
+	Type EventType
+	// Position of the pointer in the coordinates of the root widget
+	Position Point
+	// Button of the pointer pressed or released, 0 is the primary one
+	Button int
+	// Key pressed or released, such as "a", "Enter" or "ArrowLeft"
+	Key string
+	// Modifiers held during the event
+	Modifiers Modifiers
+
+	// Target is the widget the event is sent to
+	Target Widget
+	// CurrentTarget is the widget whose handler is running
+	CurrentTarget Widget
+	// Phase of the propagation
+	Phase Phase
+
+	stopped bool
+}
+
+// StopPropagation stops the event from reaching the widgets after the
+// current one
+func (e *Event) StopPropagation() {
+	e.stopped = true
+}
+
+// Stopped reports whether the propagation was stopped
+func (e *Event) Stopped() bool {
+	return e.stopped
+}
+
+// Handler handles an event
+type Handler func(e *Event)
+
+// listener is a handler registered on a view
+type listener struct {
+	eventType EventType
+	capture   bool
+	handler   Handler
+}
+
+// On registers a handler of the events of the type reaching the view when
+// they are at their target or bubble up
+func (v *View) On(t EventType, handler Handler) {
+	v.listeners = append(v.listeners, listener{eventType: t, handler: handler})
+}
+
+// OnCapture registers a handler of the events of the type reaching the view
+// on their way down to their target, or at the target before the handlers
+// registered with On
+func (v *View) OnCapture(t EventType, handler Handler) {
+	v.listeners = append(v.listeners, listener{eventType: t, capture: true, handler: handler})
+}
+
+// notify calls the handlers of the event for the phase
+func (v *View) notify(e *Event, capture bool) {
+	for _, l := range v.listeners {
+		if e.stopped {
+			return
+		}
+		if l.eventType == e.Type && l.capture == capture {
+			l.handler(e)
+		}
+	}
+}
+
+// HitTest reports whether the point is in the bounds of the view
+func (v *View) HitTest(p Point) bool {
+	return v.Bounds.Contains(p)
+}
+
+// WidgetAt returns the path from the root to the topmost widget hit by the
+// point, or nil when the root is not hit
+func WidgetAt(root Widget, p Point) []Widget {
+	if !hitWidget(root, p) {
+		return nil
+	}
+
+	path := []Widget{root}
+	for {
+		children := path[len(path)-1].Base().Children
+		next := Widget(nil)
+		for i := len(children) - 1; i >= 0; i-- {
+			if hitWidget(children[i], p) {
+				next = children[i]
+				break
+			}
+		}
+		if next == nil {
+			return path
+		}
+		path = append(path, next)
+	}
+}
+
+// hitWidget hit tests the widget, or its bounds unless it is a HitTester
+func hitWidget(w Widget, p Point) bool {
+	if tester, ok := w.(HitTester); ok {
+		return tester.HitTest(p)
+	}
+	return w.Base().HitTest(p)
+}
+
+// Dispatcher sends the input events to the widgets of a tree. The pointer
+// events go to the topmost widget under the pointer, the key events to the
+// focused widget.
+type Dispatcher struct {
+	// Root of the widget tree
+	Root Widget
+	// Focus receives the key events, the root when nil. A pointer down
+	// focuses its target.
+	Focus Widget
+
+	pointerCapture Widget
+}
+
+// NewDispatcher creates a dispatcher of the events of the widget tree
+func NewDispatcher(root Widget) *Dispatcher {
+	return &Dispatcher{Root: root}
+}
+
+// CapturePointer sends the pointer events to the widget wherever the
+// pointer is, until ReleasePointer, such as while dragging
+func (d *Dispatcher) CapturePointer(w Widget) {
+	d.pointerCapture = w
+}
+
+// ReleasePointer sends the pointer events to the widget under the pointer
+func (d *Dispatcher) ReleasePointer() {
+	d.pointerCapture = nil
+}
+
+// Dispatch propagates the event from the root to its target and back and
+// returns the target, or nil when the event has none
+func (d *Dispatcher) Dispatch(e *Event) Widget {
+	path := d.route(e)
+	if len(path) == 0 {
+		return nil
+	}
+
+	target := path[len(path)-1]
+	e.Target = target
+	e.stopped = false
+	if e.Type == PointerDown {
+		d.Focus = target
+	}
+
+	e.Phase = PhaseCapture
+	for _, w := range path[:len(path)-1] {
+		if e.stopped {
+			return target
+		}
+		e.CurrentTarget = w
+		w.Base().notify(e, true)
+	}
+
+	e.Phase = PhaseTarget
+	e.CurrentTarget = target
+	target.Base().notify(e, true)
+	target.Base().notify(e, false)
+
+	e.Phase = PhaseBubble
+	for i := len(path) - 2; i >= 0; i-- {
+		if e.stopped {
+			return target
+		}
+		e.CurrentTarget = path[i]
+		path[i].Base().notify(e, false)
+	}
+	return target
+}
+
+// route returns the path from the root to the target of the event
+func (d *Dispatcher) route(e *Event) []Widget {
+	switch {
+	case e.Type.IsPointer() && d.pointerCapture != nil:
+		return pathTo(d.Root, d.pointerCapture)
+	case e.Type.IsPointer():
+		return WidgetAt(d.Root, e.Position)
+	case d.Focus != nil:
+		return pathTo(d.Root, d.Focus)
+	default:
+		return []Widget{d.Root}
+	}
+}
+
+// pathTo returns the path from the root to the widget, or nil when the
+// widget is not in the tree
+func pathTo(root, w Widget) []Widget {
+	if root == w {
+		return []Widget{root}
+	}
+	for _, child := range root.Base().Children {
+		if path := pathTo(child, w); path != nil {
+			return append([]Widget{root}, path...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package uikit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// eventTree lays out a root with a row of two buttons and logs the handlers
+// of the pointer down events with their phase, stopping the propagation in
+// the handler named stop
+func eventTree(stop string) (*Dispatcher, *Button, *[]string) {
+	a := NewButton("A")
+	b := NewButton("B")
+	row := NewStack(Horizontal, a, b)
+	root := &View{Children: []Widget{row}}
+	Layout(root, Size{Width: 200, Height: 100})
+
+	var log []string
+	record := func(name string) Handler {
+		return func(e *Event) {
+			log = append(log, fmt.Sprintf("%s:%d", name, e.Phase))
+			if name == stop {
+				e.StopPropagation()
+			}
+		}
+	}
+
+	root.OnCapture(PointerDown, record("root-capture"))
+	root.On(PointerDown, record("root"))
+	row.OnCapture(PointerDown, record("row-capture"))
+	row.On(PointerDown, record("row"))
+	b.On(PointerDown, record("b"))
+	b.OnCapture(PointerDown, record("b-capture"))
+	b.On(PointerDown, record("b-second"))
+	a.On(PointerDown, record("a"))
+	return NewDispatcher(root), b, &log
+}
+
+// center returns the center of the rectangle
+func center(r Rect) Point {
+	return Point{X: r.Location.X + r.Size.Width/2, Y: r.Location.Y + r.Size.Height/2}
+}
+
+func TestDispatchOrder(t *testing.T) {
+	tests := []struct {
+		stop string
+		want string
+	}{
+		{"none", "root-capture:1,row-capture:1,b-capture:2,b:2,b-second:2,row:3,root:3"},
+		{"root-capture", "root-capture:1"},
+		{"row-capture", "root-capture:1,row-capture:1"},
+		{"b-capture", "root-capture:1,row-capture:1,b-capture:2"},
+		{"b", "root-capture:1,row-capture:1,b-capture:2,b:2"},
+		{"row", "root-capture:1,row-capture:1,b-capture:2,b:2,b-second:2,row:3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.stop, func(t *testing.T) {
+			dispatcher, b, log := eventTree(test.stop)
+
+			e := &Event{Type: PointerDown, Position: center(b.Bounds)}
+			if target := dispatcher.Dispatch(e); target != b {
+				t.Fatalf("the target is %v, want the button B", target)
+			}
+			if got := strings.Join(*log, ","); got != test.want {
+				t.Errorf("called %s, want %s", got, test.want)
+			}
+			if e.Stopped() != (test.stop != "none") {
+				t.Errorf("the propagation is stopped: %t", e.Stopped())
+			}
+		})
+	}
+}
+
+func TestDispatchTargets(t *testing.T) {
+	dispatcher, b, log := eventTree("none")
+	root := dispatcher.Root
+
+	if target := dispatcher.Dispatch(&Event{Type: PointerMove, Position: Point{X: 500, Y: 500}}); target != nil {
+		t.Errorf("a pointer outside the root targets %v", target)
+	}
+
+	// key events go to the root until a pointer down focuses its target
+	var keys []Widget
+	root.Base().On(KeyDown, func(e *Event) { keys = append(keys, e.Target) })
+	dispatcher.Dispatch(&Event{Type: KeyDown, Key: "Enter"})
+	dispatcher.Dispatch(&Event{Type: PointerDown, Position: center(b.Bounds)})
+	dispatcher.Dispatch(&Event{Type: KeyDown, Key: "Enter"})
+	if len(keys) != 2 || keys[0] != root || keys[1] != b {
+		t.Errorf("the key events target %v", keys)
+	}
+
+	// a captured pointer goes to its widget wherever it is
+	*log = nil
+	dispatcher.CapturePointer(b)
+	if target := dispatcher.Dispatch(&Event{Type: PointerDown, Position: Point{X: 500, Y: 500}}); target != b {
+		t.Errorf("the captured pointer targets %v", target)
+	}
+	dispatcher.ReleasePointer()
+	if target := dispatcher.Dispatch(&Event{Type: PointerDown, Position: Point{X: 500, Y: 500}}); target != nil {
+		t.Errorf("the released pointer targets %v", target)
+	}
+	if len(*log) != 7 {
+		t.Errorf("called %v", *log)
+	}
+}
@@ -0,0 +1,31 @@
+package uikit
+
+import "github.com/svett/golang-design-patterns/structural-patterns/internal/geometry"
+
+// Font of a text run
+type Font struct {
+	// Family of the font, used by the vector backends
+	Family string
+	// Size of the font, the height of a line in pixels
+	Size float64
+}
+
+// DefaultFont is a 16 pixels monospace font
+var DefaultFont = Font{Family: "monospace", Size: 16}
+
+// MeasureText returns the size of a text run in the font. Every glyph has the
+// same advance in the built-in font.
+func MeasureText(text string, font Font) Size {
+	return Size{Width: geometry.TextWidth(text, font.Size), Height: font.Size}
+}
+
+// textPolygons returns the squares of the glyph pixels of a text run whose
+// top-left corner is at the origin
+func textPolygons(text string, origin Point, font Font) [][]Point {
+	squares := geometry.TextPolygons(text, geometry.Point(origin), font.Size)
+	polygons := make([][]Point, len(squares))
+	for i, square := range squares {
+		polygons[i] = fromGeometry(square)
+	}
+	return polygons
+}
@@ -0,0 +1,194 @@
+package uikit
+
+import "math"
+
+// HitTester tells whether a point is on it
+type HitTester interface {
+	// HitTest reports whether the point hits it
+	HitTest(p Point) bool
+}
+
+// ShapeAt returns the topmost shape hit by the point, the last one drawn,
+// or nil when none is hit. Groups return the shape hit within them.
+func ShapeAt(shapes []Shape, p Point) Shape {
+	for i := len(shapes) - 1; i >= 0; i-- {
+		if group, ok := shapes[i].(*Group); ok {
+			if shape := group.ShapeAt(p); shape != nil {
+				return shape
+			}
+			continue
+		}
+		if tester, ok := shapes[i].(HitTester); ok && tester.HitTest(p) {
+			return shapes[i]
+		}
+	}
+	return nil
+}
+
+// HitTest reports whether the point is on the painted circle
+func (circle *Circle) HitTest(p Point) bool {
+	rect := Rect{
+		Location: Point{X: circle.Center.X - circle.Radius, Y: circle.Center.Y - circle.Radius},
+		Size:     Size{Width: 2 * circle.Radius, Height: 2 * circle.Radius},
+	}
+	return hitPainted(p, []subpath{{points: ellipsePolygon(rect, 1), closed: true}}, true, styleOrDefault(circle.Style))
+}
+
+// HitTest reports whether the point is on the painted ellipse
+func (ellipse *Ellipse) HitTest(p Point) bool {
+	inverse, ok := RotateAround(ellipse.Center, ellipse.Rotation).Invert()
+	if !ok {
+		return false
+	}
+
+	rect := Rect{
+		Location: Point{X: ellipse.Center.X - ellipse.RadiusX, Y: ellipse.Center.Y - ellipse.RadiusY},
+		Size:     Size{Width: 2 * ellipse.RadiusX, Height: 2 * ellipse.RadiusY},
+	}
+	return hitPainted(inverse.Apply(p), []subpath{{points: ellipsePolygon(rect, 1), closed: true}}, true, styleOrDefault(ellipse.Style))
+}
+
+// HitTest reports whether the point is on the stroked line
+func (line *Line) HitTest(p Point) bool {
+	return hitPainted(p, []subpath{{points: []Point{line.From, line.To}}}, false, styleOrDefault(line.Style))
+}
+
+// HitTest reports whether the point is on the stroked polyline
+func (polyline *Polyline) HitTest(p Point) bool {
+	return hitPainted(p, []subpath{{points: polyline.Points}}, false, styleOrDefault(polyline.Style))
+}
+
+// HitTest reports whether the point is on the painted rectangle
+func (rectangle *Rectangle) HitTest(p Point) bool {
+	outline := roundedRectPolygon(rectangle.Rect, rectangle.CornerRadius, 1)
+	return hitPainted(p, []subpath{{points: outline, closed: true}}, true, styleOrDefault(rectangle.Style))
+}
+
+// HitTest reports whether the point is on the stroked arc
+func (arc *Arc) HitTest(p Point) bool {
+	outline := arcPolygon(arc.Center, arc.Radius, arc.Radius, arc.StartAngle, arc.EndAngle, false, 1)
+	return hitPainted(p, []subpath{{points: outline}}, false, styleOrDefault(arc.Style))
+}
+
+// HitTest reports whether the point is on the painted path
+func (shape *PathShape) HitTest(p Point) bool {
+	return hitPainted(p, shape.Path.flatten(1), true, styleOrDefault(shape.Style))
+}
+
+// HitTest reports whether the point is in the box of the text
+func (text *Text) HitTest(p Point) bool {
+	return Rect{Location: text.Origin, Size: MeasureText(text.Text, text.Font)}.Contains(p)
+}
+
+// HitTest reports whether the point is on the image
+func (img *Image) HitTest(p Point) bool {
+	return img.Rect.Contains(p)
+}
+
+// HitTest reports whether the point is on a shape of the group
+func (group *Group) HitTest(p Point) bool {
+	return group.ShapeAt(p) != nil
+}
+
+// ShapeAt returns the topmost shape of the group hit by the point, or nil
+func (group *Group) ShapeAt(p Point) Shape {
+	t := group.Transform
+	if t == (Transform{}) {
+		t = Identity
+	}
+
+	inverse, ok := t.Invert()
+	if !ok {
+		return nil
+	}
+	return ShapeAt(group.Shapes, inverse.Apply(p))
+}
+
+// Contains reports whether the point is in the rectangle, the left and top
+// edges included
+func (r Rect) Contains(p Point) bool {
+	x0, x1 := r.Location.X, r.Location.X+r.Size.Width
+	y0, y1 := r.Location.Y, r.Location.Y+r.Size.Height
+	return p.X >= math.Min(x0, x1) && p.X < math.Max(x0, x1) &&
+		p.Y >= math.Min(y0, y1) && p.Y < math.Max(y0, y1)
+}
+
+// hitPainted reports whether the point is on the area the style paints:
+// the inside of the filled subpaths and the band of their strokes
+func hitPainted(p Point, subpaths []subpath, filled bool, style Style) bool {
+	if filled && style.Fill != nil {
+		winding := 0
+		for _, sub := range subpaths {
+			winding += windingNumber(p, sub.points)
+		}
+		if winding != 0 {
+			return true
+		}
+	}
+
+	if !style.stroked() {
+		return false
+	}
+
+	hw := style.StrokeWidth / 2
+	for _, sub := range subpaths {
+		lines := [][]Point{sub.points}
+		closed := sub.closed
+		if style.dashed() {
+			lines = dashPolyline(sub.points, sub.closed, style.Dash, style.DashOffset, 1)
+			closed = false
+		}
+
+		for _, line := range lines {
+			if distanceToPolyline(p, line, closed) <= hw {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// windingNumber returns how many times the polygon winds around the point
+func windingNumber(p Point, polygon []Point) int {
+	winding := 0
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		side := (b.X-a.X)*(p.Y-a.Y) - (p.X-a.X)*(b.Y-a.Y)
+		if a.Y <= p.Y {
+			if b.Y > p.Y && side > 0 {
+				winding++
+			}
+		} else if b.Y <= p.Y && side < 0 {
+			winding--
+		}
+	}
+	return winding
+}
+
+// distanceToPolyline returns the distance between the point and the nearest
+// segment of the polyline
+func distanceToPolyline(p Point, points []Point, closed bool) float64 {
+	if len(points) == 1 {
+		return math.Hypot(p.X-points[0].X, p.Y-points[0].Y)
+	}
+
+	distance := math.Inf(1)
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+	for i := 0; i < segments; i++ {
+		distance = math.Min(distance, distanceToSegment(p, points[i], points[(i+1)%len(points)]))
+	}
+	return distance
+}
+
+// distanceToSegment returns the distance between the point and the segment
+func distanceToSegment(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = clamp(((p.X-a.X)*dx+(p.Y-a.Y)*dy)/length, 0, 1)
+	}
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
@@ -0,0 +1,67 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestHitTest(t *testing.T) {
+	fill := &Style{Fill: Solid{Color: color.Black}}
+	dashed := &Style{Stroke: Solid{Color: color.Black}, StrokeWidth: 4, Dash: []float64{10, 10}}
+	ellipse := &Ellipse{Center: Point{X: 100, Y: 100}, RadiusX: 50, RadiusY: 10, Rotation: math.Pi / 2, Style: fill}
+	outline := &Circle{Radius: 10}
+	line := &Line{To: Point{X: 100}, Style: dashed}
+	rectangle := &Rectangle{Rect: rect(10, 10, 20, 20), CornerRadius: 5, Style: fill}
+
+	tests := []struct {
+		name  string
+		shape HitTester
+		point Point
+		hit   bool
+	}{
+		{"rotated ellipse along", ellipse, Point{X: 100, Y: 140}, true},
+		{"rotated ellipse across", ellipse, Point{X: 140, Y: 100}, false},
+		{"unfilled circle inside", outline, Point{}, false},
+		{"unfilled circle outline", outline, Point{X: 10}, true},
+		{"dash", line, Point{X: 5, Y: 1}, true},
+		{"gap", line, Point{X: 15, Y: 1}, false},
+		{"beside the dash", line, Point{X: 5, Y: 3}, false},
+		{"rectangle inside", rectangle, Point{X: 20, Y: 20}, true},
+		{"rounded corner", rectangle, Point{X: 10.5, Y: 10.5}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if hit := test.shape.HitTest(test.point); hit != test.hit {
+				t.Errorf("the hit is %t, want %t", hit, test.hit)
+			}
+		})
+	}
+}
+
+func TestShapeAt(t *testing.T) {
+	fill := &Style{Fill: Solid{Color: color.Black}}
+	background := &Rectangle{Rect: rect(0, 0, 100, 100), Style: fill}
+	button := &Rectangle{Rect: rect(0, 0, 10, 10), Style: fill}
+	group := &Group{Transform: Translate(50, 50), Shapes: []Shape{button}}
+	shapes := []Shape{background, group}
+
+	tests := []struct {
+		name  string
+		point Point
+		want  Shape
+	}{
+		{"in the group", Point{X: 55, Y: 55}, button},
+		{"under the group", Point{X: 20, Y: 20}, background},
+		{"outside", Point{X: 200, Y: 200}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if shape := ShapeAt(shapes, test.point); shape != test.want {
+				t.Errorf("the shape is %v, want %v", shape, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package uikit
+
+import "github.com/svett/golang-design-patterns/structural-patterns/internal/geometry"
+
+// PathOp is the operation of a path segment
+type PathOp uint8
+
+const (
+	// PathMoveTo starts a new subpath at the point
+	PathMoveTo PathOp = iota
+	// PathLineTo draws a straight line to the point
+	PathLineTo
+	// PathQuadTo draws a quadratic bezier curve through a control point to
+	// the point
+	PathQuadTo
+	// PathCubicTo draws a cubic bezier curve through two control points to
+	// the point
+	PathCubicTo
+	// PathClose closes the subpath with a line to its start
+	PathClose
+)
+
+// PathSegment is a single operation of a path. Points holds the control
+// points followed by the end point.
+type PathSegment struct {
+	// Op of the segment
+	Op PathOp
+	// Points of the segment
+	Points []Point
+}
+
+// Path is a sequence of lines and bezier curves
+type Path struct {
+	// Segments of the path
+	Segments []PathSegment
+}
+
+// MoveTo starts a new subpath at the point
+func (p *Path) MoveTo(to Point) *Path {
+	return p.add(PathMoveTo, to)
+}
+
+// LineTo draws a line to the point
+func (p *Path) LineTo(to Point) *Path {
+	return p.add(PathLineTo, to)
+}
+
+// QuadTo draws a quadratic bezier curve to the point
+func (p *Path) QuadTo(control, to Point) *Path {
+	return p.add(PathQuadTo, control, to)
+}
+
+// CubicTo draws a cubic bezier curve to the point
+func (p *Path) CubicTo(control1, control2, to Point) *Path {
+	return p.add(PathCubicTo, control1, control2, to)
+}
+
+// Close closes the current subpath
+func (p *Path) Close() *Path {
+	return p.add(PathClose)
+}
+
+func (p *Path) add(op PathOp, points ...Point) *Path {
+	p.Segments = append(p.Segments, PathSegment{Op: op, Points: points})
+	return p
+}
+
+// subpath is a flattened subpath
+type subpath struct {
+	points []Point
+	closed bool
+}
+
+// flatten approximates the path with polylines, finer on larger scales
+func (p *Path) flatten(scale float64) []subpath {
+	flattener := &geometry.Flattener{Flatness: flatness / scale}
+	var points []geometry.Point
+	for _, segment := range p.Segments {
+		points = points[:0]
+		for _, point := range segment.Points {
+			points = append(points, geometry.Point(point))
+		}
+		flattener.Segment(geometry.Op(segment.Op), points)
+	}
+
+	flattened := flattener.Subpaths()
+	subpaths := make([]subpath, len(flattened))
+	for i, sub := range flattened {
+		subpaths[i] = subpath{points: fromGeometry(sub.Points), closed: sub.Closed}
+	}
+	return subpaths
+}
+
+// fromGeometry converts the points of the shared geometry
+func fromGeometry(points []geometry.Point) []Point {
+	converted := make([]Point, len(points))
+	for i, point := range points {
+		converted[i] = Point(point)
+	}
+	return converted
+}
@@ -0,0 +1,136 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestPathFlatten(t *testing.T) {
+	quad := (&Path{}).MoveTo(Point{}).QuadTo(Point{X: 50, Y: 100}, Point{X: 100})
+	cubic := (&Path{}).MoveTo(Point{}).CubicTo(Point{Y: 100}, Point{X: 100, Y: 100}, Point{X: 100})
+
+	tests := []struct {
+		name  string
+		path  *Path
+		scale float64
+		curve func(t float64) Point
+	}{
+		{"quad", quad, 1, func(t float64) Point {
+			return Point{X: 2*(1-t)*t*50 + t*t*100, Y: 2 * (1 - t) * t * 100}
+		}},
+		{"zoomed quad", quad, 8, func(t float64) Point {
+			return Point{X: 2*(1-t)*t*50 + t*t*100, Y: 2 * (1 - t) * t * 100}
+		}},
+		{"cubic", cubic, 1, func(t float64) Point {
+			u := 1 - t
+			return Point{X: 3*u*t*t*100 + t*t*t*100, Y: 3*u*u*t*100 + 3*u*t*t*100}
+		}},
+		{"zoomed cubic", cubic, 8, func(t float64) Point {
+			u := 1 - t
+			return Point{X: 3*u*t*t*100 + t*t*t*100, Y: 3*u*u*t*100 + 3*u*t*t*100}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			subpaths := test.path.flatten(test.scale)
+			if len(subpaths) != 1 || subpaths[0].closed {
+				t.Fatalf("the subpaths are %v, want one open subpath", subpaths)
+			}
+
+			// the flatness is in device pixels, finer in local units on
+			// larger scales
+			tolerance := flatness / test.scale
+			for i := 0; i <= 1000; i++ {
+				p := test.curve(float64(i) / 1000)
+				if d := polylineDistance(p, subpaths[0].points); d > tolerance {
+					t.Fatalf("the curve at %v is %g from the polyline, want at most %g", p, d, tolerance)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawPathMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    *Path
+		data    string
+		filled  []Point
+		missing []Point
+	}{
+		{
+			name: "curves with the wrong number of points",
+			path: &Path{Segments: []PathSegment{
+				{Op: PathMoveTo, Points: []Point{{X: 2, Y: 2}}},
+				{Op: PathQuadTo, Points: []Point{{X: 18, Y: 2}}},
+				{Op: PathCubicTo, Points: []Point{{X: 30, Y: 30}, {X: 18, Y: 18}}},
+				{Op: PathLineTo, Points: []Point{{X: 2, Y: 18}}},
+				{Op: PathClose},
+			}},
+			data:    "M2 2 L18 2 L18 18 L2 18 Z",
+			filled:  []Point{{X: 10, Y: 10}},
+			missing: []Point{{X: 25, Y: 25}},
+		},
+		{
+			name: "segments without points",
+			path: &Path{Segments: []PathSegment{
+				{Op: PathClose},
+				{Op: PathMoveTo},
+				{Op: PathLineTo, Points: []Point{{X: 18}}},
+				{Op: PathCubicTo},
+				{Op: PathLineTo, Points: []Point{{X: 18, Y: 18}}},
+				{Op: PathOp(42), Points: []Point{{Y: 18}}},
+			}},
+			data:    "M0 0 L18 0 L18 18 L0 18",
+			filled:  []Point{{X: 10, Y: 10}},
+			missing: []Point{{X: 25, Y: 10}},
+		},
+		{
+			name:    "only a move",
+			path:    (&Path{}).MoveTo(Point{X: 10, Y: 10}),
+			data:    "M10 10",
+			missing: []Point{{X: 10, Y: 10}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raster := NewRaster(30, 30)
+			raster.SetStyle(Style{Fill: Solid{Color: color.Black}, Opacity: 1})
+			if err := raster.DrawPath(test.path); err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range test.filled {
+				if alpha := raster.Image.RGBAAt(int(p.X), int(p.Y)).A; alpha != 0xff {
+					t.Errorf("the alpha at %v is %d, want 255", p, alpha)
+				}
+			}
+			for _, p := range test.missing {
+				if alpha := raster.Image.RGBAAt(int(p.X), int(p.Y)).A; alpha != 0 {
+					t.Errorf("the alpha at %v is %d, want 0", p, alpha)
+				}
+			}
+
+			if data := svgPathData(test.path); data != test.data {
+				t.Errorf("the SVG path data is %q, want %q", data, test.data)
+			}
+		})
+	}
+}
+
+// polylineDistance returns the distance from the point to the polyline
+func polylineDistance(p Point, polyline []Point) float64 {
+	distance := math.Inf(1)
+	for i := 1; i < len(polyline); i++ {
+		a, b := polyline[i-1], polyline[i]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = clamp(((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l, 0, 1)
+		}
+		distance = math.Min(distance, math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy))
+	}
+	return distance
+}
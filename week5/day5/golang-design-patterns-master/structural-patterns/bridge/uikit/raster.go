@@ -0,0 +1,259 @@
+package uikit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Raster drawer renders anti-aliased shapes into an RGBA image
+type Raster struct {
+	graphicsState
+
+	// Image drawn on
+	Image *image.RGBA
+
+	rasterizer *rasterizer
+	// painted is called with the alpha every pixel is painted over with
+	painted func(x, y int, alpha float64)
+}
+
+// NewRaster creates a raster drawer of a transparent image of this size
+// drawing in the default style
+func NewRaster(width, height int) *Raster {
+	return &Raster{
+		graphicsState: newGraphicsState(),
+		Image:         image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+}
+
+// Clear fills the whole image with the color
+func (r *Raster) Clear(c color.Color) {
+	draw.Draw(r.Image, r.Image.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+
+	if r.painted != nil {
+		bounds := r.Image.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r.painted(x, y, 1)
+			}
+		}
+	}
+}
+
+// WritePNG encodes the image as PNG
+func (r *Raster) WritePNG(w io.Writer) error {
+	return png.Encode(w, r.Image)
+}
+
+// DrawEllipseInRect draws an ellipse in rectangle
+func (r *Raster) DrawEllipseInRect(rect Rect) error {
+	r.draw([]subpath{{points: ellipsePolygon(rect, r.scale()), closed: true}}, true)
+	return nil
+}
+
+// DrawLine draws a straight line
+func (r *Raster) DrawLine(from, to Point) error {
+	r.draw([]subpath{{points: []Point{from, to}}}, false)
+	return nil
+}
+
+// DrawPolyline draws connected straight lines
+func (r *Raster) DrawPolyline(points []Point) error {
+	r.draw([]subpath{{points: points}}, false)
+	return nil
+}
+
+// DrawRoundedRect draws a rectangle with rounded corners
+func (r *Raster) DrawRoundedRect(rect Rect, radius float64) error {
+	r.draw([]subpath{{points: roundedRectPolygon(rect, radius, r.scale()), closed: true}}, true)
+	return nil
+}
+
+// DrawArc draws a circular arc
+func (r *Raster) DrawArc(center Point, radius, startAngle, endAngle float64) error {
+	r.draw([]subpath{{points: arcPolygon(center, radius, radius, startAngle, endAngle, false, r.scale())}}, false)
+	return nil
+}
+
+// DrawPath draws lines and bezier curves. Every subpath is filled as if it
+// was closed.
+func (r *Raster) DrawPath(path *Path) error {
+	r.draw(path.flatten(r.scale()), true)
+	return nil
+}
+
+// DrawText draws a text run with the fill paint, or with the stroke paint
+// when there is no fill
+func (r *Raster) DrawText(text string, origin Point, font Font) error {
+	if paint := r.current.style.textPaint(); paint != nil {
+		r.fill(textPolygons(text, origin, font), paint)
+	}
+	return nil
+}
+
+// DrawImage draws an image scaled into the rectangle
+func (r *Raster) DrawImage(img image.Image, rect Rect) error {
+	src := img.Bounds()
+	inverse, ok := r.current.transform.Invert()
+	if src.Empty() || rect.Size.Width == 0 || rect.Size.Height == 0 || !ok {
+		return nil
+	}
+
+	r.cover([][]Point{roundedRectPolygon(rect, 0, 1)}, func(x, y int, coverage float64) {
+		p := inverse.Apply(Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+		u := (p.X - rect.Location.X) / rect.Size.Width
+		v := (p.Y - rect.Location.Y) / rect.Size.Height
+		sx := src.Min.X + int(clamp(math.Floor(u*float64(src.Dx())), 0, float64(src.Dx()-1)))
+		sy := src.Min.Y + int(clamp(math.Floor(v*float64(src.Dy())), 0, float64(src.Dy()-1)))
+		r.blend(x, y, img.At(sx, sy), coverage)
+	})
+	return nil
+}
+
+// scale returns the size of a local unit on the image
+func (r *Raster) scale() float64 {
+	return r.current.transform.scaleFactor()
+}
+
+// draw fills the subpaths when filled is set and strokes their outlines.
+// The subpaths are in local coordinates.
+func (r *Raster) draw(subpaths []subpath, filled bool) {
+	style := r.current.style
+
+	if filled && style.Fill != nil {
+		polygons := make([][]Point, len(subpaths))
+		for i, sub := range subpaths {
+			polygons[i] = sub.points
+		}
+		r.fill(polygons, style.Fill)
+	}
+
+	if !style.stroked() {
+		return
+	}
+
+	scale := r.scale()
+	var polygons [][]Point
+	for _, sub := range subpaths {
+		if !style.dashed() {
+			polygons = append(polygons, strokePolygons(sub.points, sub.closed, style.StrokeWidth, scale)...)
+			continue
+		}
+		for _, dash := range dashPolyline(sub.points, sub.closed, style.Dash, style.DashOffset, scale) {
+			polygons = append(polygons, strokePolygons(dash, false, style.StrokeWidth, scale)...)
+		}
+	}
+	r.fill(polygons, style.Stroke)
+}
+
+// fill paints the area covered by the polygons in local coordinates with
+// the paint
+func (r *Raster) fill(polygons [][]Point, paint Paint) {
+	inverse, ok := r.current.transform.Invert()
+	if !ok {
+		return
+	}
+
+	r.cover(polygons, func(x, y int, coverage float64) {
+		r.blend(x, y, paint.ColorAt(inverse.Apply(Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})), coverage)
+	})
+}
+
+// cover calls fn for every pixel of the image covered by the polygons in
+// local coordinates, within the clip
+func (r *Raster) cover(polygons [][]Point, fn func(x, y int, coverage float64)) {
+	mask := r.clipMask(r.current.clip)
+	bounds := r.Image.Bounds()
+
+	r.rasterize(r.current.transform, polygons, func(x, y int, coverage float64) {
+		if mask != nil {
+			coverage *= mask[(y-bounds.Min.Y)*bounds.Dx()+x-bounds.Min.X]
+			if coverage == 0 {
+				return
+			}
+		}
+		fn(x, y, coverage)
+	})
+}
+
+// rasterize calls fn for every pixel of the image covered by the polygons
+// in the coordinates of the transform
+func (r *Raster) rasterize(t Transform, polygons [][]Point, fn func(x, y int, coverage float64)) {
+	bounds := r.Image.Bounds()
+	if r.rasterizer == nil || r.rasterizer.width != bounds.Dx() || r.rasterizer.height != bounds.Dy() {
+		r.rasterizer = newRasterizer(bounds.Dx(), bounds.Dy())
+	}
+
+	device := t.Then(Translate(-float64(bounds.Min.X), -float64(bounds.Min.Y)))
+	ras := r.rasterizer
+	ras.reset()
+	for _, polygon := range polygons {
+		ras.addPolygon(device.applyAll(polygon))
+	}
+
+	ras.cover(false, func(x, y int, coverage float64) {
+		fn(x+bounds.Min.X, y+bounds.Min.Y, coverage)
+	})
+}
+
+// clipMask returns the coverage of the pixels of the image by the clip, nil
+// when there is no clip
+func (r *Raster) clipMask(c *clip) []float64 {
+	if c == nil {
+		return nil
+	}
+
+	bounds := r.Image.Bounds()
+	if len(c.mask) == bounds.Dx()*bounds.Dy() {
+		return c.mask
+	}
+
+	parent := r.clipMask(c.parent)
+	mask := make([]float64, bounds.Dx()*bounds.Dy())
+	r.rasterize(c.transform, [][]Point{roundedRectPolygon(c.rect, 0, 1)}, func(x, y int, coverage float64) {
+		i := (y-bounds.Min.Y)*bounds.Dx() + x - bounds.Min.X
+		if parent != nil {
+			coverage *= parent[i]
+		}
+		mask[i] = coverage
+	})
+
+	c.mask = mask
+	return mask
+}
+
+// blend composes the color scaled by the coverage and the opacity over the
+// pixel in the blend mode of the style
+func (r *Raster) blend(x, y int, c color.Color, coverage float64) {
+	sr, sg, sb, sa := c.RGBA()
+	if sa == 0 {
+		return
+	}
+
+	i := r.Image.PixOffset(x, y)
+	pix := r.Image.Pix[i : i+4 : i+4]
+
+	k := coverage * clamp(r.current.style.Opacity, 0, 1) / 0xffff
+	src := [4]float64{float64(sr) * k, float64(sg) * k, float64(sb) * k, float64(sa) * k}
+	dst := [4]float64{float64(pix[0]) / 0xff, float64(pix[1]) / 0xff, float64(pix[2]) / 0xff, float64(pix[3]) / 0xff}
+	if r.painted != nil {
+		r.painted(x, y, src[3])
+	}
+
+	if mode := r.current.style.BlendMode; mode != BlendNormal && dst[3] > 0 {
+		channels := [3]uint32{sr, sg, sb}
+		for ch, c := range channels {
+			source := float64(c) / float64(sa)
+			mixed := (1-dst[3])*source + dst[3]*mode.blend(dst[ch]/dst[3], source)
+			src[ch] = mixed * src[3]
+		}
+	}
+
+	for ch := range pix {
+		pix[ch] = uint8(clamp(src[ch]+dst[ch]*(1-src[3]), 0, 1)*0xff + 0.5)
+	}
+}
@@ -0,0 +1,333 @@
+package uikit
+
+import "math"
+
+// flatness is the largest distance in pixels between a curve and the
+// polygon approximating it
+const flatness = 0.2
+
+// rasterizer computes the anti-aliased coverage of polygons by accumulating
+// their signed area per pixel
+type rasterizer struct {
+	width  int
+	height int
+	stride int
+	area   []float64
+
+	minX, maxX int
+	minY, maxY int
+}
+
+func newRasterizer(width, height int) *rasterizer {
+	r := &rasterizer{
+		width:  width,
+		height: height,
+		stride: width + 2,
+		area:   make([]float64, (width+2)*height),
+	}
+	r.reset()
+	return r
+}
+
+// reset clears the accumulated area
+func (r *rasterizer) reset() {
+	for y := r.minY; y < r.maxY; y++ {
+		row := r.area[y*r.stride : (y+1)*r.stride]
+		for x := range row {
+			row[x] = 0
+		}
+	}
+	r.minX, r.maxX = r.width, 0
+	r.minY, r.maxY = r.height, 0
+}
+
+// addPolygon adds a closed polygon
+func (r *rasterizer) addPolygon(points []Point) {
+	for i := range points {
+		r.addLine(points[i], points[(i+1)%len(points)])
+	}
+}
+
+func (r *rasterizer) addLine(p0, p1 Point) {
+	if p0.Y == p1.Y || math.IsNaN(p0.X+p0.Y+p1.X+p1.Y) {
+		return
+	}
+
+	dir := 1.0
+	if p0.Y > p1.Y {
+		dir = -1
+		p0, p1 = p1, p0
+	}
+
+	dxdy := (p1.X - p0.X) / (p1.Y - p0.Y)
+	x, top := p0.X, p0.Y
+	if top < 0 {
+		x -= top * dxdy
+		top = 0
+	}
+	bottom := math.Min(p1.Y, float64(r.height))
+	if top >= bottom {
+		return
+	}
+
+	first := int(top)
+	for y := first; float64(y) < bottom; y++ {
+		dy := math.Min(float64(y+1), p1.Y) - math.Max(float64(y), p0.Y)
+		next := x + dxdy*dy
+		r.accumulate(y, x, next, dy*dir)
+		x = next
+	}
+
+	if first < r.minY {
+		r.minY = first
+	}
+	if last := int(math.Ceil(bottom)); last > r.maxY {
+		r.maxY = last
+	}
+}
+
+// accumulate adds the area covered by a line crossing the row y from xa to
+// xb; d is the signed height of the crossing
+func (r *rasterizer) accumulate(y int, xa, xb, d float64) {
+	row := r.area[y*r.stride : (y+1)*r.stride]
+	xa = clamp(xa, 0, float64(r.width))
+	xb = clamp(xb, 0, float64(r.width))
+
+	x0, x1 := math.Min(xa, xb), math.Max(xa, xb)
+	x0floor := math.Floor(x0)
+	x0i := int(x0floor)
+	x1ceil := math.Ceil(x1)
+	x1i := int(x1ceil)
+
+	if x0i < r.minX {
+		r.minX = x0i
+	}
+	if x1i+1 > r.maxX {
+		r.maxX = x1i + 1
+	}
+
+	if x1i <= x0i+1 {
+		xmf := 0.5*(xa+xb) - x0floor
+		row[x0i] += d - d*xmf
+		row[x0i+1] += d * xmf
+		return
+	}
+
+	s := 1 / (x1 - x0)
+	x0f := x0 - x0floor
+	a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+	x1f := x1 - x1ceil + 1
+	am := 0.5 * s * x1f * x1f
+
+	row[x0i] += d * a0
+	if x1i == x0i+2 {
+		row[x0i+1] += d * (1 - a0 - am)
+	} else {
+		a1 := s * (1.5 - x0f)
+		row[x0i+1] += d * (a1 - a0)
+		for xi := x0i + 2; xi < x1i-1; xi++ {
+			row[xi] += d * s
+		}
+		a2 := a1 + float64(x1i-x0i-3)*s
+		row[x1i-1] += d * (1 - a2 - am)
+	}
+	row[x1i] += d * am
+}
+
+// cover calls fn for every pixel covered by the accumulated polygons with
+// its coverage in (0, 1]
+func (r *rasterizer) cover(evenOdd bool, fn func(x, y int, coverage float64)) {
+	maxX := r.maxX
+	if maxX > r.width {
+		maxX = r.width
+	}
+
+	for y := r.minY; y < r.maxY; y++ {
+		row := r.area[y*r.stride : (y+1)*r.stride]
+		acc := 0.0
+		for x := 0; x < r.minX; x++ {
+			acc += row[x]
+		}
+
+		for x := r.minX; x < maxX; x++ {
+			acc += row[x]
+			c := math.Abs(acc)
+			if evenOdd {
+				c -= 2 * math.Floor(c/2)
+				if c > 1 {
+					c = 2 - c
+				}
+			} else if c > 1 {
+				c = 1
+			}
+			if c > 1.0/512 {
+				fn(x, y, c)
+			}
+		}
+	}
+}
+
+// ellipsePolygon approximates the ellipse inscribed in the rectangle. The
+// scale is the size of a unit on the device, the approximation is finer on
+// larger scales.
+func ellipsePolygon(r Rect, scale float64) []Point {
+	rx, ry := r.Size.Width/2, r.Size.Height/2
+	center := Point{X: r.Location.X + rx, Y: r.Location.Y + ry}
+	return arcPolygon(center, rx, ry, 0, 2*math.Pi, true, scale)
+}
+
+// roundedRectPolygon approximates a rectangle with rounded corners
+func roundedRectPolygon(r Rect, radius, scale float64) []Point {
+	x0, y0 := r.Location.X, r.Location.Y
+	x1, y1 := x0+r.Size.Width, y0+r.Size.Height
+	radius = math.Min(radius, math.Min(math.Abs(r.Size.Width), math.Abs(r.Size.Height))/2)
+	if radius <= 0 {
+		return []Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+	}
+
+	var points []Point
+	corners := []struct {
+		center Point
+		start  float64
+	}{
+		{Point{X: x1 - radius, Y: y0 + radius}, -math.Pi / 2},
+		{Point{X: x1 - radius, Y: y1 - radius}, 0},
+		{Point{X: x0 + radius, Y: y1 - radius}, math.Pi / 2},
+		{Point{X: x0 + radius, Y: y0 + radius}, math.Pi},
+	}
+	for _, corner := range corners {
+		points = append(points, arcPolygon(corner.center, radius, radius, corner.start, corner.start+math.Pi/2, false, scale)...)
+	}
+	return points
+}
+
+// arcPolygon approximates the elliptical arc from start to end, angles in
+// radians measured clockwise from the x-axis on screen
+func arcPolygon(center Point, rx, ry, start, end float64, closed bool, scale float64) []Point {
+	sweep := end - start
+	n := curveSegments(math.Max(math.Abs(rx), math.Abs(ry))*scale, math.Abs(sweep))
+	if closed {
+		n--
+	}
+
+	points := make([]Point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		angle := start + sweep*float64(i)/float64(n+boolToInt(closed))
+		points = append(points, Point{
+			X: center.X + rx*math.Cos(angle),
+			Y: center.Y + ry*math.Sin(angle),
+		})
+	}
+	return points
+}
+
+// curveSegments returns how many segments approximate an arc of the radius
+// and sweep within the flatness
+func curveSegments(radius, sweep float64) int {
+	if radius <= flatness {
+		return 4
+	}
+	step := 2 * math.Acos(1-flatness/radius)
+	n := int(math.Ceil(sweep / step))
+	if n < 4 {
+		n = 4
+	}
+	if n > 4096 {
+		n = 4096
+	}
+	return n
+}
+
+// strokePolygons returns the polygons covering a polyline stroked with the
+// width. Joins and caps are round.
+func strokePolygons(points []Point, closed bool, width, scale float64) [][]Point {
+	hw := width / 2
+	if hw <= 0 || len(points) == 0 {
+		return nil
+	}
+
+	var polygons [][]Point
+	disc := func(p Point) {
+		polygons = append(polygons, arcPolygon(p, hw, hw, 0, 2*math.Pi, true, scale))
+	}
+
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+
+	for i := 0; i < segments; i++ {
+		p, q := points[i], points[(i+1)%len(points)]
+		dx, dy := q.X-p.X, q.Y-p.Y
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -dy/length*hw, dx/length*hw
+		polygons = append(polygons, orient([]Point{
+			{X: p.X + nx, Y: p.Y + ny},
+			{X: q.X + nx, Y: q.Y + ny},
+			{X: q.X - nx, Y: q.Y - ny},
+			{X: p.X - nx, Y: p.Y - ny},
+		}))
+	}
+
+	for i := range points {
+		if closed || (i > 0 && i < len(points)-1) {
+			if turns(points, i, closed) {
+				disc(points[i])
+			}
+			continue
+		}
+		disc(points[i])
+	}
+	return polygons
+}
+
+// turns reports whether the polyline turns noticeably at the vertex i, which
+// leaves a gap between the segments that a join must fill
+func turns(points []Point, i int, closed bool) bool {
+	n := len(points)
+	prev, next := i-1, i+1
+	if closed {
+		prev, next = (i+n-1)%n, (i+1)%n
+	}
+	a, p, b := points[prev], points[i], points[next]
+	cross := (p.X-a.X)*(b.Y-p.Y) - (p.Y-a.Y)*(b.X-p.X)
+	la := math.Hypot(p.X-a.X, p.Y-a.Y)
+	lb := math.Hypot(b.X-p.X, b.Y-p.Y)
+	return la == 0 || lb == 0 || math.Abs(cross)/(la*lb) > 1e-3
+}
+
+// orient returns the polygon with a positive signed area so that
+// overlapping polygons add up instead of cancelling out
+func orient(points []Point) []Point {
+	area := 0.0
+	for i := range points {
+		p, q := points[i], points[(i+1)%len(points)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	if area < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	return points
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
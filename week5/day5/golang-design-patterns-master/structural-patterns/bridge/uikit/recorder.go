@@ -0,0 +1,313 @@
+package uikit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Op is the Drawer method a command records
+type Op uint8
+
+const (
+	// OpSetStyle records SetStyle
+	OpSetStyle Op = iota + 1
+	// OpSave records Save
+	OpSave
+	// OpRestore records Restore
+	OpRestore
+	// OpConcat records Concat
+	OpConcat
+	// OpEllipse records DrawEllipseInRect
+	OpEllipse
+	// OpLine records DrawLine
+	OpLine
+	// OpPolyline records DrawPolyline
+	OpPolyline
+	// OpRoundedRect records DrawRoundedRect
+	OpRoundedRect
+	// OpArc records DrawArc
+	OpArc
+	// OpPath records DrawPath
+	OpPath
+	// OpText records DrawText
+	OpText
+	// OpImage records DrawImage
+	OpImage
+	// OpClipRect records ClipRect
+	OpClipRect
+)
+
+var opNames = [...]string{"", "set_style", "save", "restore", "concat", "ellipse", "line", "polyline", "rounded_rect", "arc", "path", "text", "image", "clip_rect"}
+
+// String returns the name of the op
+func (op Op) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return ""
+}
+
+// Command is a recorded call of a Drawer method. Only the fields of its op
+// are set.
+type Command struct {
+	// Op is the method called
+	Op Op
+	// Style of OpSetStyle
+	Style Style
+	// Transform of OpConcat
+	Transform Transform
+	// Rect of OpEllipse, OpRoundedRect, OpImage and OpClipRect
+	Rect Rect
+	// Points of OpLine, OpPolyline, and the center of OpArc
+	Points []Point
+	// Radius of OpRoundedRect and OpArc
+	Radius float64
+	// StartAngle of OpArc
+	StartAngle float64
+	// EndAngle of OpArc
+	EndAngle float64
+	// Path of OpPath
+	Path *Path
+	// Text of OpText, drawn at the first point
+	Text string
+	// Font of OpText
+	Font Font
+	// Image of OpImage
+	Image image.Image
+}
+
+// Play calls the recorded method on the drawer
+func (c *Command) Play(d Drawer) error {
+	switch c.Op {
+	case OpSetStyle:
+		d.SetStyle(c.Style)
+	case OpSave:
+		d.Save()
+	case OpRestore:
+		d.Restore()
+	case OpConcat:
+		d.Concat(c.Transform)
+	case OpClipRect:
+		d.ClipRect(c.Rect)
+	case OpEllipse:
+		return d.DrawEllipseInRect(c.Rect)
+	case OpLine:
+		return d.DrawLine(c.point(0), c.point(1))
+	case OpPolyline:
+		return d.DrawPolyline(c.Points)
+	case OpRoundedRect:
+		return d.DrawRoundedRect(c.Rect, c.Radius)
+	case OpArc:
+		return d.DrawArc(c.point(0), c.Radius, c.StartAngle, c.EndAngle)
+	case OpPath:
+		if c.Path == nil {
+			return d.DrawPath(&Path{})
+		}
+		return d.DrawPath(c.Path)
+	case OpText:
+		return d.DrawText(c.Text, c.point(0), c.Font)
+	case OpImage:
+		return d.DrawImage(c.Image, c.Rect)
+	}
+	return nil
+}
+
+func (c *Command) point(i int) Point {
+	if i < len(c.Points) {
+		return c.Points[i]
+	}
+	return Point{}
+}
+
+// DisplayList is a sequence of recorded commands
+type DisplayList struct {
+	// Commands in the order they were called
+	Commands []Command `json:"commands"`
+}
+
+// Play calls the recorded methods on the drawer, in order
+func (l *DisplayList) Play(d Drawer) error {
+	for i := range l.Commands {
+		if err := l.Commands[i].Play(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Diff returns the index of the first command differing between the display
+// lists, or -1 when they are the same
+func (l *DisplayList) Diff(other *DisplayList) int {
+	for i := range l.Commands {
+		if i >= len(other.Commands) || !sameCommand(&l.Commands[i], &other.Commands[i]) {
+			return i
+		}
+	}
+	if len(other.Commands) > len(l.Commands) {
+		return len(l.Commands)
+	}
+	return -1
+}
+
+// sameCommand compares the commands by their binary encoding
+func sameCommand(a, b *Command) bool {
+	left, errLeft := commandBytes(a)
+	right, errRight := commandBytes(b)
+	return errLeft == nil && errRight == nil && bytes.Equal(left, right)
+}
+
+// Recorder is a Drawer recording the calls into a display list. The values
+// passed are copied, so the display list is not changed by the caller
+// mutating them afterwards.
+type Recorder struct {
+	// List of the commands recorded
+	List DisplayList
+}
+
+// NewRecorder creates a recorder of an empty display list
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Reset empties the display list
+func (r *Recorder) Reset() {
+	r.List.Commands = nil
+}
+
+// SetStyle records a style change
+func (r *Recorder) SetStyle(style Style) {
+	r.record(Command{Op: OpSetStyle, Style: copyStyle(style)})
+}
+
+// Save records a save of the graphics state
+func (r *Recorder) Save() {
+	r.record(Command{Op: OpSave})
+}
+
+// Restore records a restore of the graphics state
+func (r *Recorder) Restore() {
+	r.record(Command{Op: OpRestore})
+}
+
+// Concat records a transform change
+func (r *Recorder) Concat(t Transform) {
+	r.record(Command{Op: OpConcat, Transform: t})
+}
+
+// ClipRect records a clip change
+func (r *Recorder) ClipRect(rect Rect) {
+	r.record(Command{Op: OpClipRect, Rect: rect})
+}
+
+// DrawEllipseInRect records an ellipse
+func (r *Recorder) DrawEllipseInRect(rect Rect) error {
+	r.record(Command{Op: OpEllipse, Rect: rect})
+	return nil
+}
+
+// DrawLine records a line
+func (r *Recorder) DrawLine(from, to Point) error {
+	r.record(Command{Op: OpLine, Points: []Point{from, to}})
+	return nil
+}
+
+// DrawPolyline records a polyline
+func (r *Recorder) DrawPolyline(points []Point) error {
+	r.record(Command{Op: OpPolyline, Points: append([]Point(nil), points...)})
+	return nil
+}
+
+// DrawRoundedRect records a rectangle
+func (r *Recorder) DrawRoundedRect(rect Rect, radius float64) error {
+	r.record(Command{Op: OpRoundedRect, Rect: rect, Radius: radius})
+	return nil
+}
+
+// DrawArc records an arc
+func (r *Recorder) DrawArc(center Point, radius, startAngle, endAngle float64) error {
+	r.record(Command{Op: OpArc, Points: []Point{center}, Radius: radius, StartAngle: startAngle, EndAngle: endAngle})
+	return nil
+}
+
+// DrawPath records a path
+func (r *Recorder) DrawPath(path *Path) error {
+	copied := &Path{Segments: make([]PathSegment, len(path.Segments))}
+	for i, segment := range path.Segments {
+		copied.Segments[i] = PathSegment{Op: segment.Op, Points: append([]Point(nil), segment.Points...)}
+	}
+	r.record(Command{Op: OpPath, Path: copied})
+	return nil
+}
+
+// DrawText records a text run
+func (r *Recorder) DrawText(text string, origin Point, font Font) error {
+	r.record(Command{Op: OpText, Text: text, Points: []Point{origin}, Font: font})
+	return nil
+}
+
+// DrawImage records a snapshot of the image
+func (r *Recorder) DrawImage(img image.Image, rect Rect) error {
+	bounds := img.Bounds()
+	snapshot := image.NewRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(snapshot, snapshot.Bounds(), img, bounds.Min, draw.Src)
+	r.record(Command{Op: OpImage, Image: snapshot, Rect: rect})
+	return nil
+}
+
+func (r *Recorder) record(c Command) {
+	r.List.Commands = append(r.List.Commands, c)
+}
+
+// Player is a shape replaying a display list through its drawing context
+type Player struct {
+	// DrawingContext the display list is replayed on
+	DrawingContext Drawer
+	// List replayed
+	List *DisplayList
+}
+
+// Draw replays the display list
+func (p *Player) Draw() error {
+	return p.List.Play(p.DrawingContext)
+}
+
+// copyStyle copies the dashes and the gradients of the style, turning its
+// colors into color.RGBA64 which every encoding keeps exactly
+func copyStyle(style Style) Style {
+	style.Fill = copyPaint(style.Fill)
+	style.Stroke = copyPaint(style.Stroke)
+	if style.Dash != nil {
+		style.Dash = append([]float64(nil), style.Dash...)
+	}
+	return style
+}
+
+func copyPaint(paint Paint) Paint {
+	switch p := paint.(type) {
+	case Solid:
+		return Solid{Color: rgba64(p.Color)}
+	case *LinearGradient:
+		return &LinearGradient{Start: p.Start, End: p.End, Stops: copyStops(p.Stops)}
+	case *RadialGradient:
+		return &RadialGradient{Center: p.Center, Radius: p.Radius, Stops: copyStops(p.Stops)}
+	default:
+		return paint
+	}
+}
+
+func copyStops(stops []GradientStop) []GradientStop {
+	copied := make([]GradientStop, len(stops))
+	for i, stop := range stops {
+		copied[i] = GradientStop{Offset: stop.Offset, Color: rgba64(stop.Color)}
+	}
+	return copied
+}
+
+func rgba64(c color.Color) color.Color {
+	if c == nil {
+		return nil
+	}
+	return color.RGBA64Model.Convert(c)
+}
@@ -0,0 +1,159 @@
+package uikit
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// drawCommands draws a command of every op, with every kind of paint
+func drawCommands(d Drawer) {
+	img := image.NewRGBA(image.Rect(3, 3, 7, 7))
+	for i := 0; i < 16; i++ {
+		a := uint8(i*17 + 3)
+		img.Set(3+i%4, 3+i/4, color.RGBA{R: a / 2, G: a / 3, B: a, A: a})
+	}
+
+	path := &Path{}
+	path.MoveTo(Point{X: 10, Y: 10}).
+		QuadTo(Point{X: 50, Y: 0}, Point{X: 90, Y: 40}).
+		CubicTo(Point{X: 60, Y: 60}, Point{X: 30, Y: 60}, Point{X: 10, Y: 10}).
+		Close()
+
+	d.SetStyle(Style{
+		Fill: &LinearGradient{
+			End:   Point{X: 100},
+			Stops: []GradientStop{{Offset: 0, Color: color.White}, {Offset: 1, Color: color.NRGBA{B: 255, A: 100}}},
+		},
+		Stroke:      Solid{Color: color.Black},
+		StrokeWidth: 2,
+		Dash:        []float64{4, 2},
+		Opacity:     0.8,
+		BlendMode:   BlendMultiply,
+	})
+	d.Save()
+	d.Concat(Rotate(0.3))
+	d.ClipRect(Rect{Location: Point{X: 5, Y: 5}, Size: Size{Width: 60, Height: 70}})
+	d.DrawEllipseInRect(Rect{Location: Point{X: 10, Y: 10}, Size: Size{Width: 40, Height: 20}})
+	d.DrawLine(Point{X: 1, Y: 2}, Point{X: 80, Y: 90})
+	d.DrawPolyline([]Point{{X: 0, Y: 0}, {X: 20, Y: 40}, {X: 60, Y: 10}})
+	d.Restore()
+	d.SetStyle(Style{
+		Fill: &RadialGradient{
+			Center: Point{X: 50, Y: 50},
+			Radius: 30,
+			Stops:  []GradientStop{{Offset: 0, Color: color.White}, {Offset: 1, Color: color.Black}},
+		},
+		Opacity: 1,
+	})
+	d.DrawRoundedRect(Rect{Location: Point{X: 30, Y: 30}, Size: Size{Width: 40, Height: 30}}, 5)
+	d.DrawArc(Point{X: 50, Y: 50}, 20, 0, math.Pi)
+	d.DrawPath(path)
+	d.DrawText("hi", Point{X: 5, Y: 80}, DefaultFont)
+	d.DrawImage(img, Rect{Location: Point{X: 70, Y: 70}, Size: Size{Width: 20, Height: 20}})
+}
+
+func TestDisplayListRoundTrip(t *testing.T) {
+	recorder := NewRecorder()
+	drawCommands(recorder)
+
+	direct := NewRaster(100, 100)
+	drawCommands(direct)
+
+	tests := []struct {
+		name      string
+		roundTrip func(list *DisplayList) (*DisplayList, error)
+	}{
+		{
+			name: "json",
+			roundTrip: func(list *DisplayList) (*DisplayList, error) {
+				data, err := json.Marshal(list)
+				if err != nil {
+					return nil, err
+				}
+				decoded := &DisplayList{}
+				return decoded, json.Unmarshal(data, decoded)
+			},
+		},
+		{
+			name: "binary",
+			roundTrip: func(list *DisplayList) (*DisplayList, error) {
+				var data bytes.Buffer
+				if err := list.WriteBinary(&data); err != nil {
+					return nil, err
+				}
+				return ReadBinary(&data)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := test.roundTrip(&recorder.List)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i := recorder.List.Diff(list); i != -1 {
+				t.Fatalf("the command %d differs after the round trip", i)
+			}
+
+			replayed := NewRaster(100, 100)
+			player := &Player{DrawingContext: replayed, List: list}
+			if err := player.Draw(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(replayed.Image.Pix, direct.Image.Pix) {
+				t.Errorf("the replayed list differs from the direct drawing")
+			}
+		})
+	}
+}
+
+func TestDisplayListDiff(t *testing.T) {
+	recorder := NewRecorder()
+	drawCommands(recorder)
+	count := len(recorder.List.Commands)
+
+	tests := []struct {
+		name   string
+		change func(r *Recorder)
+		want   int
+	}{
+		{"same", func(r *Recorder) {}, -1},
+		{"longer", func(r *Recorder) { r.DrawLine(Point{}, Point{X: 1}) }, count},
+		{"shorter", func(r *Recorder) { r.List.Commands = r.List.Commands[:count-2] }, count - 2},
+		{"rectangle", func(r *Recorder) { r.List.Commands[4].Rect.Size.Width++ }, 4},
+		{"style", func(r *Recorder) { r.List.Commands[0].Style.Opacity = 1 }, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			other := NewRecorder()
+			drawCommands(other)
+			test.change(other)
+
+			if i := recorder.List.Diff(&other.List); i != test.want {
+				t.Errorf("the lists differ at %d, want %d", i, test.want)
+			}
+		})
+	}
+}
+
+func TestReadBinaryTruncated(t *testing.T) {
+	recorder := NewRecorder()
+	drawCommands(recorder)
+
+	var data bytes.Buffer
+	if err := recorder.List.WriteBinary(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, 1, data.Len() / 2, data.Len() - 1} {
+		if _, err := ReadBinary(bytes.NewReader(data.Bytes()[:n])); err == nil {
+			t.Errorf("read %d of %d bytes without an error", n, data.Len())
+		}
+	}
+}
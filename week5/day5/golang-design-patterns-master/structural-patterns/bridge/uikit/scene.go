@@ -0,0 +1,177 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+)
+
+// Scene keeps shapes drawn on a canvas and redraws them incrementally. The
+// shapes changed since the last frame mark the regions they were and are
+// in as dirty, and a frame redraws every shape overlapping the dirty
+// regions, clipped to them.
+type Scene struct {
+	// DrawingContext of the shapes, the regions are cleared and clipped on it
+	DrawingContext Drawer
+	// Size of the canvas
+	Size Size
+	// Background paints the dirty regions before the shapes are redrawn,
+	// white when nil
+	Background Paint
+
+	shapes []Shape
+	drawn  map[Shape]Rect
+	dirty  []Rect
+	all    bool
+}
+
+// NewScene creates a scene of the shapes on a canvas of the size, which
+// the first frame draws entirely
+func NewScene(d Drawer, size Size, shapes ...Shape) *Scene {
+	return &Scene{
+		DrawingContext: d,
+		Size:           size,
+		shapes:         shapes,
+		drawn:          map[Shape]Rect{},
+		all:            true,
+	}
+}
+
+// Shapes returns the shapes of the scene, from the bottom to the top
+func (s *Scene) Shapes() []Shape {
+	return s.shapes
+}
+
+// Add puts the shapes on top of the scene
+func (s *Scene) Add(shapes ...Shape) {
+	s.shapes = append(s.shapes, shapes...)
+	for _, shape := range shapes {
+		s.Invalidate(shape)
+	}
+}
+
+// Remove takes the shape off the scene
+func (s *Scene) Remove(shape Shape) {
+	for i, other := range s.shapes {
+		if other == shape {
+			s.Invalidate(shape)
+			s.shapes = append(s.shapes[:i:i], s.shapes[i+1:]...)
+			delete(s.drawn, shape)
+			return
+		}
+	}
+}
+
+// Invalidate marks the region the shape was drawn in and the region it is
+// in now as dirty. It is called after the shape is changed. The whole
+// canvas is dirty when the shape isn't Bounded.
+func (s *Scene) Invalidate(shape Shape) {
+	bounded, ok := shape.(Bounded)
+	if !ok {
+		s.InvalidateAll()
+		return
+	}
+
+	if drawn, ok := s.drawn[shape]; ok {
+		s.InvalidateRect(drawn)
+	}
+	s.InvalidateRect(bounded.Bounds())
+}
+
+// InvalidateRect marks the rectangle as dirty
+func (s *Scene) InvalidateRect(r Rect) {
+	if r = pixelBounds(r).Intersect(s.canvas()); !r.Empty() {
+		s.dirty = append(s.dirty, r)
+	}
+}
+
+// InvalidateAll marks the whole canvas as dirty
+func (s *Scene) InvalidateAll() {
+	s.all = true
+}
+
+// DirtyRegions returns the regions the next frame redraws, the dirty
+// rectangles merged as long as they overlap
+func (s *Scene) DirtyRegions() []Rect {
+	if s.all {
+		return []Rect{s.canvas()}
+	}
+
+	regions := append([]Rect(nil), s.dirty...)
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(regions) && !merged; i++ {
+			for j := i + 1; j < len(regions); j++ {
+				if !regions[i].Intersect(regions[j]).Empty() {
+					regions[i] = regions[i].Union(regions[j])
+					regions = append(regions[:j], regions[j+1:]...)
+					merged = true
+					break
+				}
+			}
+		}
+	}
+	return regions
+}
+
+// Frame redraws the dirty regions and returns them. Every region is
+// cleared with the background, then the shapes overlapping it are drawn
+// from the bottom to the top, clipped to it.
+func (s *Scene) Frame() ([]Rect, error) {
+	regions := s.DirtyRegions()
+	for _, region := range regions {
+		if err := s.redraw(region); err != nil {
+			return nil, err
+		}
+	}
+
+	s.dirty = nil
+	s.all = false
+	for _, shape := range s.shapes {
+		if bounded, ok := shape.(Bounded); ok {
+			s.drawn[shape] = bounded.Bounds()
+		}
+	}
+	return regions, nil
+}
+
+// redraw draws the region, clipped to it
+func (s *Scene) redraw(region Rect) error {
+	d := s.DrawingContext
+	d.Save()
+	defer d.Restore()
+
+	background := s.Background
+	if background == nil {
+		background = Solid{Color: color.White}
+	}
+
+	d.ClipRect(region)
+	d.SetStyle(Style{Fill: background, Opacity: 1})
+	if err := d.DrawRoundedRect(region, 0); err != nil {
+		return err
+	}
+
+	for _, shape := range s.shapes {
+		if bounded, ok := shape.(Bounded); ok && bounded.Bounds().Intersect(region).Empty() {
+			continue
+		}
+		if err := shape.Draw(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canvas returns the rectangle of the canvas
+func (s *Scene) canvas() Rect {
+	return Rect{Size: s.Size}
+}
+
+// pixelBounds returns the smallest rectangle of whole pixels containing the
+// rectangle
+func pixelBounds(r Rect) Rect {
+	x0, y0 := math.Floor(r.Location.X), math.Floor(r.Location.Y)
+	x1 := math.Ceil(r.Location.X + r.Size.Width)
+	y1 := math.Ceil(r.Location.Y + r.Size.Height)
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
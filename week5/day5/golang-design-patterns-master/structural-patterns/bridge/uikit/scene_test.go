@@ -0,0 +1,111 @@
+package uikit
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestSceneDirtyRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		invalid []Rect
+		want    []Rect
+	}{
+		{"clean", nil, nil},
+		{"whole pixels", []Rect{rect(10.5, 10.5, 5, 5)}, []Rect{rect(10, 10, 6, 6)}},
+		{"overlapping", []Rect{rect(0, 0, 10, 10), rect(5, 5, 10, 10)}, []Rect{rect(0, 0, 15, 15)}},
+		{"disjoint", []Rect{rect(0, 0, 10, 10), rect(50, 50, 10, 10)}, []Rect{rect(0, 0, 10, 10), rect(50, 50, 10, 10)}},
+		{"chained", []Rect{rect(0, 0, 10, 10), rect(20, 0, 10, 10), rect(8, 0, 14, 10)}, []Rect{rect(0, 0, 30, 10)}},
+		{"outside", []Rect{rect(150, 150, 10, 10), rect(90, 90, 20, 20)}, []Rect{rect(90, 90, 10, 10)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scene := NewScene(NewRecorder(), Size{Width: 100, Height: 100})
+			if regions := scene.DirtyRegions(); !reflect.DeepEqual(regions, []Rect{rect(0, 0, 100, 100)}) {
+				t.Fatalf("the first frame redraws %v, want the whole canvas", regions)
+			}
+			if _, err := scene.Frame(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, r := range test.invalid {
+				scene.InvalidateRect(r)
+			}
+			if regions := scene.DirtyRegions(); !reflect.DeepEqual(regions, test.want) {
+				t.Errorf("redraws %v, want %v", regions, test.want)
+			}
+		})
+	}
+}
+
+// sceneShapes returns a circle and an ellipse moved between the frames,
+// with shapes staying in place
+func sceneShapes(d Drawer) ([]Shape, *Circle, *Ellipse) {
+	blue := &Style{
+		Fill:        Solid{Color: color.RGBA{B: 0xff, A: 0xff}},
+		Stroke:      Solid{Color: color.Black},
+		StrokeWidth: 3,
+		Opacity:     0.7,
+	}
+
+	circle := &Circle{DrawingContext: d, Center: Point{X: 40.3, Y: 40.7}, Radius: 20, Style: blue}
+	ellipse := &Ellipse{DrawingContext: d, Center: Point{X: 70, Y: 50}, RadiusX: 30, RadiusY: 8, Rotation: 0.7, Style: blue}
+	group := &Group{
+		DrawingContext: d,
+		Transform:      Rotate(0.3).Then(Translate(20, 90)),
+		Shapes: []Shape{
+			&Rectangle{DrawingContext: d, Rect: rect(0, 0, 50, 20), CornerRadius: 4, Style: blue},
+			&Text{DrawingContext: d, Origin: Point{X: 3, Y: 3}, Text: "ab", Font: DefaultFont},
+		},
+	}
+	return []Shape{circle, ellipse, group}, circle, ellipse
+}
+
+func TestSceneFrame(t *testing.T) {
+	raster := NewRaster(160, 160)
+	shapes, circle, ellipse := sceneShapes(raster)
+	scene := NewScene(raster, Size{Width: 160, Height: 160}, shapes...)
+
+	// redraw returns the pixels of the shapes drawn from scratch
+	redraw := func() []byte {
+		fresh := NewRaster(160, 160)
+		fresh.Clear(color.White)
+		shapes, freshCircle, freshEllipse := sceneShapes(fresh)
+		freshCircle.Center, freshEllipse.Rotation = circle.Center, ellipse.Rotation
+		for _, shape := range shapes {
+			shape.Draw()
+		}
+		return fresh.Image.Pix
+	}
+
+	if _, err := scene.Frame(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raster.Image.Pix, redraw()) {
+		t.Fatal("the first frame differs from the shapes drawn from scratch")
+	}
+
+	circle.Center.X += 17.4
+	circle.Center.Y += 3.2
+	scene.Invalidate(circle)
+	ellipse.Rotation = 1.9
+	scene.Invalidate(ellipse)
+
+	regions, err := scene.Frame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 1 || regions[0] == rect(0, 0, 160, 160) {
+		t.Errorf("redrew %v, want the region of the circle and the ellipse", regions)
+	}
+	if !bytes.Equal(raster.Image.Pix, redraw()) {
+		t.Error("the incremental frame differs from the shapes drawn from scratch")
+	}
+
+	if regions, _ := scene.Frame(); len(regions) != 0 {
+		t.Errorf("an unchanged scene redrew %v", regions)
+	}
+}
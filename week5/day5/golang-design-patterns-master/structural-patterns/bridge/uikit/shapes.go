@@ -0,0 +1,195 @@
+package uikit
+
+import "image"
+
+// Shape is drawn through its drawing context
+type Shape interface {
+	// Draw draws the shape
+	Draw() error
+}
+
+// Line represents a straight line shape
+type Line struct {
+	// DrawingContext for this line
+	DrawingContext Drawer
+	// From is the start of the line
+	From Point
+	// To is the end of the line
+	To Point
+	// Style of the line, the default style when nil
+	Style *Style
+}
+
+// Draw draws a line
+func (line *Line) Draw() error {
+	line.DrawingContext.SetStyle(styleOrDefault(line.Style))
+	return line.DrawingContext.DrawLine(line.From, line.To)
+}
+
+// Polyline represents connected straight lines
+type Polyline struct {
+	// DrawingContext for this polyline
+	DrawingContext Drawer
+	// Points connected by the lines
+	Points []Point
+	// Style of the polyline, the default style when nil
+	Style *Style
+}
+
+// Draw draws a polyline
+func (polyline *Polyline) Draw() error {
+	polyline.DrawingContext.SetStyle(styleOrDefault(polyline.Style))
+	return polyline.DrawingContext.DrawPolyline(polyline.Points)
+}
+
+// Rectangle represents a rectangle shape with optionally rounded corners
+type Rectangle struct {
+	// DrawingContext for this rectangle
+	DrawingContext Drawer
+	// Rect of the rectangle
+	Rect Rect
+	// CornerRadius of the rounded corners
+	CornerRadius float64
+	// Style of the rectangle, the default style when nil
+	Style *Style
+}
+
+// Draw draws a rectangle
+func (rectangle *Rectangle) Draw() error {
+	rectangle.DrawingContext.SetStyle(styleOrDefault(rectangle.Style))
+	return rectangle.DrawingContext.DrawRoundedRect(rectangle.Rect, rectangle.CornerRadius)
+}
+
+// Arc represents a circular arc shape
+type Arc struct {
+	// DrawingContext for this arc
+	DrawingContext Drawer
+	// Center of the arc circle
+	Center Point
+	// Radius of the arc circle
+	Radius float64
+	// StartAngle in radians clockwise from the x-axis
+	StartAngle float64
+	// EndAngle in radians clockwise from the x-axis
+	EndAngle float64
+	// Style of the arc, the default style when nil
+	Style *Style
+}
+
+// Draw draws an arc
+func (arc *Arc) Draw() error {
+	arc.DrawingContext.SetStyle(styleOrDefault(arc.Style))
+	return arc.DrawingContext.DrawArc(arc.Center, arc.Radius, arc.StartAngle, arc.EndAngle)
+}
+
+// PathShape represents a shape of lines and bezier curves
+type PathShape struct {
+	// DrawingContext for this path
+	DrawingContext Drawer
+	// Path of the shape
+	Path *Path
+	// Style of the path, the default style when nil
+	Style *Style
+}
+
+// Draw draws a path
+func (shape *PathShape) Draw() error {
+	shape.DrawingContext.SetStyle(styleOrDefault(shape.Style))
+	return shape.DrawingContext.DrawPath(shape.Path)
+}
+
+// Text represents a text run
+type Text struct {
+	// DrawingContext for this text
+	DrawingContext Drawer
+	// Origin is the top-left corner of the text
+	Origin Point
+	// Text drawn
+	Text string
+	// Font of the text
+	Font Font
+	// Style of the text, the default style when nil
+	Style *Style
+}
+
+// Draw draws a text
+func (text *Text) Draw() error {
+	text.DrawingContext.SetStyle(styleOrDefault(text.Style))
+	return text.DrawingContext.DrawText(text.Text, text.Origin, text.Font)
+}
+
+// Image represents a picture scaled into a rectangle
+type Image struct {
+	// DrawingContext for this image
+	DrawingContext Drawer
+	// Source image
+	Source image.Image
+	// Rect the image is scaled into
+	Rect Rect
+	// Style of the image, the default style when nil
+	Style *Style
+}
+
+// Draw draws an image
+func (img *Image) Draw() error {
+	img.DrawingContext.SetStyle(styleOrDefault(img.Style))
+	return img.DrawingContext.DrawImage(img.Source, img.Rect)
+}
+
+// Ellipse represents an ellipse shape rotated around its center
+type Ellipse struct {
+	// DrawingContext for this ellipse
+	DrawingContext Drawer
+	// Center of the ellipse
+	Center Point
+	// RadiusX is the radius along the x-axis before the rotation
+	RadiusX float64
+	// RadiusY is the radius along the y-axis before the rotation
+	RadiusY float64
+	// Rotation in radians, clockwise
+	Rotation float64
+	// Style of the ellipse, the default style when nil
+	Style *Style
+}
+
+// Draw draws an ellipse
+func (ellipse *Ellipse) Draw() error {
+	ctx := ellipse.DrawingContext
+	ctx.Save()
+	defer ctx.Restore()
+
+	ctx.SetStyle(styleOrDefault(ellipse.Style))
+	ctx.Concat(RotateAround(ellipse.Center, ellipse.Rotation))
+	return ctx.DrawEllipseInRect(Rect{
+		Location: Point{X: ellipse.Center.X - ellipse.RadiusX, Y: ellipse.Center.Y - ellipse.RadiusY},
+		Size:     Size{Width: 2 * ellipse.RadiusX, Height: 2 * ellipse.RadiusY},
+	})
+}
+
+// Group represents shapes drawn in local coordinates placed by a transform
+type Group struct {
+	// DrawingContext for this group
+	DrawingContext Drawer
+	// Transform from the local coordinates of the shapes, the zero
+	// transform is taken as the identity
+	Transform Transform
+	// Shapes of the group drawn in order
+	Shapes []Shape
+}
+
+// Draw draws the shapes of the group
+func (group *Group) Draw() error {
+	ctx := group.DrawingContext
+	ctx.Save()
+	defer ctx.Restore()
+
+	if group.Transform != (Transform{}) {
+		ctx.Concat(group.Transform)
+	}
+	for _, shape := range group.Shapes {
+		if err := shape.Draw(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
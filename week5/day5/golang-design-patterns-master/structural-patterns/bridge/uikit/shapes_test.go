@@ -0,0 +1,117 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestArcPolygon(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end float64
+		from, to   Point
+		// side is a point on the arc between its ends
+		side Point
+	}{
+		{"clockwise", 0, math.Pi / 2, Point{X: 10}, Point{Y: 10}, Point{X: 7.07, Y: 7.07}},
+		{"counterclockwise", 0, -math.Pi / 2, Point{X: 10}, Point{Y: -10}, Point{X: 7.07, Y: -7.07}},
+		{"across zero", -math.Pi / 4, math.Pi / 4, Point{X: 7.07, Y: -7.07}, Point{X: 7.07, Y: 7.07}, Point{X: 10}},
+		{"beyond a full turn", 3 * math.Pi / 2, 5 * math.Pi / 2, Point{Y: -10}, Point{Y: 10}, Point{X: 10}},
+		{"back across zero", math.Pi / 4, -math.Pi / 4, Point{X: 7.07, Y: 7.07}, Point{X: 7.07, Y: -7.07}, Point{X: 10}},
+	}
+
+	near := func(p, q Point) bool { return math.Hypot(p.X-q.X, p.Y-q.Y) < 0.01 }
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			points := arcPolygon(Point{}, 10, 10, test.start, test.end, false, 1)
+			if first, last := points[0], points[len(points)-1]; !near(first, test.from) || !near(last, test.to) {
+				t.Fatalf("the arc runs from %v to %v, want from %v to %v", first, last, test.from, test.to)
+			}
+
+			// the arc passes by the side and keeps turning the same way
+			passes := false
+			for i, p := range points {
+				if math.Abs(math.Hypot(p.X, p.Y)-10) > 1e-9 {
+					t.Fatalf("the point %v is off the circle", p)
+				}
+				passes = passes || math.Hypot(p.X-test.side.X, p.Y-test.side.Y) < 1
+				if i > 1 {
+					a, b := points[i-2], points[i-1]
+					cross := (b.X-a.X)*(p.Y-b.Y) - (b.Y-a.Y)*(p.X-b.X)
+					if (cross > 0) != (test.end > test.start) {
+						t.Fatalf("the arc turns back at %v", p)
+					}
+				}
+			}
+			if !passes {
+				t.Errorf("the arc does not pass by %v", test.side)
+			}
+		})
+	}
+}
+
+func TestRoundedRectPolygon(t *testing.T) {
+	tests := []struct {
+		name   string
+		rect   Rect
+		radius float64
+		// corner is the point of the outline on the diagonal of the
+		// top-left corner
+		corner Point
+	}{
+		{"square corners", rect(0, 0, 20, 10), 0, Point{}},
+		{"negative radius", rect(0, 0, 20, 10), -5, Point{}},
+		{"rounded", rect(0, 0, 20, 10), 2, Point{X: 2 - math.Sqrt2, Y: 2 - math.Sqrt2}},
+		{"radius clamped to the height", rect(0, 0, 20, 10), 100, Point{X: 5 - 5/math.Sqrt2, Y: 5 - 5/math.Sqrt2}},
+		{"radius clamped to the width", rect(0, 0, 4, 10), 100, Point{X: 2 - math.Sqrt2, Y: 2 - math.Sqrt2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			points := roundedRectPolygon(test.rect, test.radius, 1)
+			if bounds := pointBounds(points); bounds != test.rect {
+				t.Fatalf("the bounds are %+v, want %+v", bounds, test.rect)
+			}
+
+			closest := math.Inf(1)
+			for _, p := range points {
+				closest = math.Min(closest, math.Hypot(p.X-test.corner.X, p.Y-test.corner.Y))
+			}
+			if closest > flatness {
+				t.Errorf("the outline is %g from %v, want it through the point", closest, test.corner)
+			}
+		})
+	}
+}
+
+func TestRectangleDraw(t *testing.T) {
+	raster := NewRaster(20, 10)
+	rectangle := &Rectangle{
+		DrawingContext: raster,
+		Rect:           rect(0, 0, 20, 10),
+		CornerRadius:   100,
+		Style:          &Style{Fill: Solid{Color: color.Black}, Opacity: 1},
+	}
+	if err := rectangle.Draw(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		x, y  int
+		alpha uint8
+	}{
+		{0, 0, 0},
+		{19, 9, 0},
+		{10, 0, 0xff},
+		{1, 5, 0xff},
+		{10, 5, 0xff},
+	}
+
+	for _, test := range tests {
+		if alpha := raster.Image.RGBAAt(test.x, test.y).A; (alpha > 0x80) != (test.alpha > 0x80) {
+			t.Errorf("the alpha at %d,%d is %d, want %d", test.x, test.y, alpha, test.alpha)
+		}
+	}
+}
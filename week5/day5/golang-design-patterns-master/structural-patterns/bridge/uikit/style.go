@@ -0,0 +1,301 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+)
+
+// Paint determines the color of every point of a filled or stroked area
+type Paint interface {
+	// ColorAt returns the color at the point
+	ColorAt(p Point) color.Color
+}
+
+// Solid paints with a single color
+type Solid struct {
+	// Color of the paint
+	Color color.Color
+}
+
+// ColorAt returns the color of the paint
+func (s Solid) ColorAt(p Point) color.Color {
+	return s.Color
+}
+
+// GradientStop is a color of a gradient at an offset between 0 and 1
+type GradientStop struct {
+	// Offset of the stop along the gradient
+	Offset float64
+	// Color at the offset
+	Color color.Color
+}
+
+// LinearGradient paints colors varying along a line. Points beyond the line
+// take the color of its nearest end.
+type LinearGradient struct {
+	// Start of the gradient line, at offset 0
+	Start Point
+	// End of the gradient line, at offset 1
+	End Point
+	// Stops of the gradient ordered by offset
+	Stops []GradientStop
+}
+
+// ColorAt returns the color at the projection of the point on the line
+func (g *LinearGradient) ColorAt(p Point) color.Color {
+	dx, dy := g.End.X-g.Start.X, g.End.Y-g.Start.Y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return gradientColor(g.Stops, 0)
+	}
+	return gradientColor(g.Stops, ((p.X-g.Start.X)*dx+(p.Y-g.Start.Y)*dy)/length)
+}
+
+// RadialGradient paints colors varying with the distance from a center
+type RadialGradient struct {
+	// Center of the gradient, at offset 0
+	Center Point
+	// Radius of the gradient circle, at offset 1
+	Radius float64
+	// Stops of the gradient ordered by offset
+	Stops []GradientStop
+}
+
+// ColorAt returns the color at the distance of the point from the center
+func (g *RadialGradient) ColorAt(p Point) color.Color {
+	if g.Radius <= 0 {
+		return gradientColor(g.Stops, 1)
+	}
+	return gradientColor(g.Stops, math.Hypot(p.X-g.Center.X, p.Y-g.Center.Y)/g.Radius)
+}
+
+// gradientColor interpolates the premultiplied colors of the stops at the
+// offset
+func gradientColor(stops []GradientStop, offset float64) color.Color {
+	if len(stops) == 0 {
+		return color.Transparent
+	}
+
+	offset = clamp(offset, 0, 1)
+	if offset <= stops[0].Offset {
+		return stops[0].Color
+	}
+
+	for i := 1; i < len(stops); i++ {
+		prev, next := stops[i-1], stops[i]
+		if offset > next.Offset {
+			continue
+		}
+
+		t := 0.0
+		if next.Offset > prev.Offset {
+			t = (offset - prev.Offset) / (next.Offset - prev.Offset)
+		}
+		r0, g0, b0, a0 := prev.Color.RGBA()
+		r1, g1, b1, a1 := next.Color.RGBA()
+		mix := func(a, b uint32) uint16 {
+			return uint16(float64(a)*(1-t) + float64(b)*t + 0.5)
+		}
+		return color.RGBA64{R: mix(r0, r1), G: mix(g0, g1), B: mix(b0, b1), A: mix(a0, a1)}
+	}
+	return stops[len(stops)-1].Color
+}
+
+// BlendMode determines how the colors of a shape mix with the colors
+// beneath it
+type BlendMode uint8
+
+const (
+	// BlendNormal paints the shape over the background
+	BlendNormal BlendMode = iota
+	// BlendMultiply multiplies the colors, darkening the background
+	BlendMultiply
+	// BlendScreen inverts, multiplies and inverts back, lightening the
+	// background
+	BlendScreen
+	// BlendOverlay multiplies the dark and screens the light background colors
+	BlendOverlay
+	// BlendDarken keeps the darker color
+	BlendDarken
+	// BlendLighten keeps the lighter color
+	BlendLighten
+)
+
+var blendModeNames = [...]string{"normal", "multiply", "screen", "overlay", "darken", "lighten"}
+
+// String returns the CSS name of the blend mode
+func (m BlendMode) String() string {
+	if int(m) < len(blendModeNames) {
+		return blendModeNames[m]
+	}
+	return "normal"
+}
+
+// blend mixes a background and a source color channel, both not
+// premultiplied
+func (m BlendMode) blend(backdrop, source float64) float64 {
+	switch m {
+	case BlendMultiply:
+		return backdrop * source
+	case BlendScreen:
+		return backdrop + source - backdrop*source
+	case BlendOverlay:
+		if backdrop <= 0.5 {
+			return 2 * backdrop * source
+		}
+		return 1 - 2*(1-backdrop)*(1-source)
+	case BlendDarken:
+		return math.Min(backdrop, source)
+	case BlendLighten:
+		return math.Max(backdrop, source)
+	default:
+		return source
+	}
+}
+
+// Style determines how the shapes are filled and stroked
+type Style struct {
+	// Fill paint of the closed shapes, nil leaves them unfilled
+	Fill Paint
+	// Stroke paint of the outlines, nil leaves them unstroked
+	Stroke Paint
+	// StrokeWidth of the outlines
+	StrokeWidth float64
+	// Dash alternates the lengths of the dashes and gaps of the outlines,
+	// solid when empty
+	Dash []float64
+	// DashOffset is the distance into the dash pattern the outlines start at
+	DashOffset float64
+	// Opacity of the shape from 0, transparent, to 1, opaque
+	Opacity float64
+	// BlendMode of the shape with the background
+	BlendMode BlendMode
+}
+
+// DefaultStyle strokes the outlines with a black solid line of 1 pixel
+var DefaultStyle = Style{
+	Stroke:      Solid{Color: color.Black},
+	StrokeWidth: 1,
+	Opacity:     1,
+}
+
+// styleOrDefault returns the style or the default style when it is nil
+func styleOrDefault(style *Style) Style {
+	if style == nil {
+		return DefaultStyle
+	}
+	return *style
+}
+
+// stroked reports whether the style strokes the outlines
+func (s Style) stroked() bool {
+	return s.Stroke != nil && s.StrokeWidth > 0
+}
+
+// textPaint returns the paint of text, the fill or the stroke when there is
+// no fill
+func (s Style) textPaint() Paint {
+	if s.Fill != nil {
+		return s.Fill
+	}
+	return s.Stroke
+}
+
+// dashed returns whether the dash pattern breaks the outlines
+func (s Style) dashed() bool {
+	total := 0.0
+	for _, d := range s.Dash {
+		if d < 0 {
+			return false
+		}
+		total += d
+	}
+	return total > 0
+}
+
+const (
+	// minDashPeriod is the length in pixels of the shortest dash pattern.
+	// Shorter patterns are finer than a pixel and stroke solid outlines.
+	minDashPeriod = 1
+	// maxDashes is the most dashes and gaps a polyline is broken into.
+	// Longer polylines stroke solid outlines.
+	maxDashes = 1 << 16
+)
+
+// dashPolyline breaks a polyline into the dashes of the pattern. The scale
+// is the size of a unit on the device, the polyline is left solid when its
+// dashes would be finer than a pixel or too many.
+func dashPolyline(points []Point, closed bool, dash []float64, offset, scale float64) [][]Point {
+	if len(points) < 2 {
+		return nil
+	}
+	if len(dash)%2 == 1 {
+		dash = append(append([]float64(nil), dash...), dash...)
+	}
+
+	total := 0.0
+	for _, d := range dash {
+		total += d
+	}
+
+	if closed {
+		points = append(append([]Point(nil), points...), points[0])
+	}
+
+	length := 0.0
+	for i := 1; i < len(points); i++ {
+		length += math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+	}
+	if total*scale < minDashPeriod || !(length/total*float64(len(dash)) <= maxDashes) {
+		return [][]Point{points}
+	}
+
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+	index := 0
+	for offset >= dash[index] {
+		offset -= dash[index]
+		index = (index + 1) % len(dash)
+	}
+	left := dash[index] - offset
+
+	var (
+		dashes  [][]Point
+		current []Point
+	)
+	if index%2 == 0 {
+		current = []Point{points[0]}
+	}
+
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		length := math.Hypot(q.X-p.X, q.Y-p.Y)
+		pos := 0.0
+
+		for length-pos > left {
+			pos += left
+			t := pos / length
+			at := Point{X: p.X + (q.X-p.X)*t, Y: p.Y + (q.Y-p.Y)*t}
+			if index%2 == 0 {
+				dashes = append(dashes, append(current, at))
+				current = nil
+			} else {
+				current = []Point{at}
+			}
+			index = (index + 1) % len(dash)
+			left = dash[index]
+		}
+
+		left -= length - pos
+		if index%2 == 0 {
+			current = append(current, q)
+		}
+	}
+
+	if len(current) > 1 {
+		dashes = append(dashes, current)
+	}
+	return dashes
+}
@@ -0,0 +1,110 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPaintColorAt(t *testing.T) {
+	blackToWhite := []GradientStop{{Offset: 0, Color: color.Black}, {Offset: 1, Color: color.White}}
+	gray := color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+
+	tests := []struct {
+		name  string
+		paint Paint
+		point Point
+		want  color.RGBA
+	}{
+		{"solid", Solid{Color: color.White}, Point{X: 7, Y: 3}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"linear middle", &LinearGradient{End: Point{X: 100}, Stops: blackToWhite}, Point{X: 50, Y: 99}, gray},
+		{"linear before start", &LinearGradient{End: Point{X: 100}, Stops: blackToWhite}, Point{X: -10}, color.RGBA{A: 0xff}},
+		{"linear beyond end", &LinearGradient{End: Point{X: 100}, Stops: blackToWhite}, Point{X: 200}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"radial middle", &RadialGradient{Radius: 10, Stops: blackToWhite}, Point{X: 3, Y: 4}, gray},
+		{"radial outside", &RadialGradient{Radius: 10, Stops: blackToWhite}, Point{X: 20}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{
+			name: "three stops",
+			paint: &LinearGradient{End: Point{X: 100}, Stops: []GradientStop{
+				{Offset: 0, Color: color.RGBA{R: 0xff, A: 0xff}},
+				{Offset: 0.5, Color: color.RGBA{G: 0xff, A: 0xff}},
+				{Offset: 1, Color: color.RGBA{B: 0xff, A: 0xff}},
+			}},
+			point: Point{X: 75},
+			want:  color.RGBA{G: 0x80, B: 0x80, A: 0xff},
+		},
+		{"no stops", &LinearGradient{End: Point{X: 100}}, Point{X: 50}, color.RGBA{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(test.paint.ColorAt(test.point)); got != test.want {
+				t.Errorf("the color is %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDashPolyline(t *testing.T) {
+	line := []Point{{X: 0}, {X: 10}}
+	dash := func(from, to float64) []Point { return []Point{{X: from}, {X: to}} }
+	long := []Point{{X: 0}, {X: 1e20}}
+	infinite := []Point{{X: 0}, {X: math.Inf(1)}}
+
+	tests := []struct {
+		name    string
+		line    []Point
+		pattern []float64
+		offset  float64
+		scale   float64
+		want    [][]Point
+	}{
+		{"dashes and gaps", line, []float64{2, 3}, 0, 1, [][]Point{dash(0, 2), dash(5, 7)}},
+		{"offset", line, []float64{2, 3}, 1, 1, [][]Point{dash(0, 1), dash(4, 6), dash(9, 10)}},
+		{"odd pattern", line, []float64{2}, 0, 1, [][]Point{dash(0, 2), dash(4, 6), dash(8, 10)}},
+		{"finer than a pixel", dash(0, 1), []float64{0.25, 0.25}, 0, 1, [][]Point{dash(0, 1)}},
+		{"zoomed in", dash(0, 1), []float64{0.25, 0.25}, 0, 4, [][]Point{dash(0, 0.25), dash(0.5, 0.75)}},
+		{"too many dashes", long, []float64{2, 3}, 0, 1, [][]Point{long}},
+		{"infinite", infinite, []float64{2, 3}, 0, 1, [][]Point{infinite}},
+		{"single point", []Point{{X: 5}}, []float64{2, 3}, 0, 1, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := dashPolyline(test.line, false, test.pattern, test.offset, test.scale); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("dashed %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRasterStyle(t *testing.T) {
+	cyan := color.RGBA{G: 0xff, B: 0xff, A: 0xff}
+	yellow := Solid{Color: color.RGBA{R: 0xff, G: 0xff, A: 0xff}}
+
+	tests := []struct {
+		name  string
+		style Style
+		want  color.RGBA
+	}{
+		{"opaque", Style{Fill: yellow, Opacity: 1}, color.RGBA{R: 0xff, G: 0xff, A: 0xff}},
+		{"half opaque", Style{Fill: Solid{Color: color.Black}, Opacity: 0.5}, color.RGBA{G: 0x80, B: 0x80, A: 0xff}},
+		{"transparent", Style{Fill: yellow}, cyan},
+		{"multiply", Style{Fill: yellow, Opacity: 1, BlendMode: BlendMultiply}, color.RGBA{G: 0xff, A: 0xff}},
+		{"screen", Style{Fill: yellow, Opacity: 1, BlendMode: BlendScreen}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"stroke only", Style{Stroke: yellow, StrokeWidth: 1, Opacity: 1}, cyan},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raster := NewRaster(20, 20)
+			raster.Clear(cyan)
+			raster.SetStyle(test.style)
+			raster.DrawRoundedRect(rect(0, 0, 20, 20), 0)
+
+			if got := raster.Image.RGBAAt(10, 10); got != test.want {
+				t.Errorf("the center is %v, want %v", got, test.want)
+			}
+		})
+	}
+}
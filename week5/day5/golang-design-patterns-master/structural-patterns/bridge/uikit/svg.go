@@ -0,0 +1,371 @@
+package uikit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/internal/bitmapfont"
+)
+
+// SVG drawer renders the shapes as a scalable vector graphics document
+type SVG struct {
+	graphicsState
+
+	// Width of the document
+	Width float64
+	// Height of the document
+	Height float64
+
+	gradients int
+	clips     int
+	group     *clip
+	defs      bytes.Buffer
+	body      bytes.Buffer
+}
+
+// NewSVG creates a SVG drawer of a document of this size drawing in the
+// default style
+func NewSVG(width, height float64) *SVG {
+	return &SVG{
+		graphicsState: newGraphicsState(),
+		Width:         width,
+		Height:        height,
+	}
+}
+
+// DrawEllipseInRect draws an ellipse in rectangle
+func (s *SVG) DrawEllipseInRect(r Rect) error {
+	rx, ry := r.Size.Width/2, r.Size.Height/2
+	s.element(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`+"\n",
+		svgNumber(r.Location.X+rx), svgNumber(r.Location.Y+ry),
+		svgNumber(math.Abs(rx)), svgNumber(math.Abs(ry)), s.paint(true))
+	return nil
+}
+
+// DrawLine draws a straight line
+func (s *SVG) DrawLine(from, to Point) error {
+	s.element(`<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n",
+		svgNumber(from.X), svgNumber(from.Y), svgNumber(to.X), svgNumber(to.Y), s.paint(false))
+	return nil
+}
+
+// DrawPolyline draws connected straight lines
+func (s *SVG) DrawPolyline(points []Point) error {
+	s.element(`<polyline points="%s"%s/>`+"\n", svgPoints(points), s.paint(false))
+	return nil
+}
+
+// DrawRoundedRect draws a rectangle with rounded corners
+func (s *SVG) DrawRoundedRect(r Rect, radius float64) error {
+	radius = math.Max(0, math.Min(radius, math.Min(math.Abs(r.Size.Width), math.Abs(r.Size.Height))/2))
+	s.element(`<rect x="%s" y="%s" width="%s" height="%s" rx="%s"%s/>`+"\n",
+		svgNumber(math.Min(r.Location.X, r.Location.X+r.Size.Width)),
+		svgNumber(math.Min(r.Location.Y, r.Location.Y+r.Size.Height)),
+		svgNumber(math.Abs(r.Size.Width)), svgNumber(math.Abs(r.Size.Height)),
+		svgNumber(radius), s.paint(true))
+	return nil
+}
+
+// DrawArc draws a circular arc
+func (s *SVG) DrawArc(center Point, radius, startAngle, endAngle float64) error {
+	s.element(`<path d="%s"%s/>`+"\n", svgArc(center, radius, startAngle, endAngle), s.paint(false))
+	return nil
+}
+
+// DrawPath draws lines and bezier curves
+func (s *SVG) DrawPath(path *Path) error {
+	s.element(`<path d="%s"%s/>`+"\n", svgPathData(path), s.paint(true))
+	return nil
+}
+
+// DrawText draws a text run with the fill paint, or with the stroke paint
+// when there is no fill
+func (s *SVG) DrawText(text string, origin Point, font Font) error {
+	paint := s.current.style.textPaint()
+	if paint == nil {
+		return nil
+	}
+
+	s.element(`<text x="%s" y="%s" font-family="%s" font-size="%s"%s%s>%s</text>`+"\n",
+		svgNumber(origin.X), svgNumber(origin.Y+font.Size*(bitmapfont.Rows-1)/bitmapfont.Rows),
+		html.EscapeString(font.Family), svgNumber(font.Size), s.paintAttrs("fill", paint), s.blendMode()+s.transform(),
+		html.EscapeString(text))
+	return nil
+}
+
+// DrawImage draws an image scaled into the rectangle
+func (s *SVG) DrawImage(img image.Image, r Rect) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	opacity := ""
+	if o := clamp(s.current.style.Opacity, 0, 1); o != 1 {
+		opacity = ` opacity="` + svgNumber(o) + `"`
+	}
+
+	s.element(`<image x="%s" y="%s" width="%s" height="%s" preserveAspectRatio="none"%s%s href="data:image/png;base64,%s"/>`+"\n",
+		svgNumber(r.Location.X), svgNumber(r.Location.Y), svgNumber(r.Size.Width), svgNumber(r.Size.Height),
+		opacity, s.blendMode()+s.transform(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return nil
+}
+
+// WriteTo writes the SVG document
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`+"\n",
+		svgNumber(s.Width), svgNumber(s.Height))
+	if s.defs.Len() > 0 {
+		doc.WriteString("<defs>\n")
+		doc.Write(s.defs.Bytes())
+		doc.WriteString("</defs>\n")
+	}
+	doc.Write(s.body.Bytes())
+	if s.group != nil {
+		doc.WriteString("</g>\n")
+	}
+	doc.WriteString("</svg>\n")
+	return doc.WriteTo(w)
+}
+
+// String returns the SVG document
+func (s *SVG) String() string {
+	var buf bytes.Buffer
+	s.WriteTo(&buf)
+	return buf.String()
+}
+
+// element writes an element to the body, in a group clipped by the
+// current clip
+func (s *SVG) element(format string, args ...interface{}) {
+	if s.group != s.current.clip {
+		if s.group != nil {
+			s.body.WriteString("</g>\n")
+		}
+		if s.current.clip != nil {
+			fmt.Fprintf(&s.body, `<g clip-path="url(#%s)">`+"\n", s.clipPath(s.current.clip))
+		}
+		s.group = s.current.clip
+	}
+	fmt.Fprintf(&s.body, format, args...)
+}
+
+// clipPath defines the clip path of a clip, clipped by its parent, and
+// returns its id
+func (s *SVG) clipPath(c *clip) string {
+	if c.id != "" {
+		return c.id
+	}
+
+	parent := ""
+	if c.parent != nil {
+		parent = ` clip-path="url(#` + s.clipPath(c.parent) + `)"`
+	}
+
+	s.clips++
+	c.id = fmt.Sprintf("clip%d", s.clips)
+	fmt.Fprintf(&s.defs, `<clipPath id="%s"%s><rect x="%s" y="%s" width="%s" height="%s"%s/></clipPath>`+"\n",
+		c.id, parent,
+		svgNumber(math.Min(c.rect.Location.X, c.rect.Location.X+c.rect.Size.Width)),
+		svgNumber(math.Min(c.rect.Location.Y, c.rect.Location.Y+c.rect.Size.Height)),
+		svgNumber(math.Abs(c.rect.Size.Width)), svgNumber(math.Abs(c.rect.Size.Height)),
+		svgTransform(c.transform))
+	return c.id
+}
+
+// paint returns the style attributes of a shape, the fill is ignored unless
+// the shape is filled
+func (s *SVG) paint(filled bool) string {
+	style := s.current.style
+
+	attrs := ` fill="none"`
+	if filled && style.Fill != nil {
+		attrs = s.paintAttrs("fill", style.Fill)
+	}
+
+	if style.stroked() {
+		attrs += s.paintAttrs("stroke", style.Stroke) +
+			` stroke-width="` + svgNumber(style.StrokeWidth) + `" stroke-linecap="round" stroke-linejoin="round"`
+
+		if style.dashed() {
+			dash := make([]string, len(style.Dash))
+			for i, d := range style.Dash {
+				dash[i] = svgNumber(d)
+			}
+			attrs += ` stroke-dasharray="` + strings.Join(dash, " ") + `"`
+			if style.DashOffset != 0 {
+				attrs += ` stroke-dashoffset="` + svgNumber(style.DashOffset) + `"`
+			}
+		}
+	}
+
+	return attrs + s.blendMode() + s.transform()
+}
+
+// paintAttrs returns the attribute of a paint and its opacity. Gradients are
+// defined in the document and referenced by id.
+func (s *SVG) paintAttrs(attr string, paint Paint) string {
+	opacity := clamp(s.current.style.Opacity, 0, 1)
+
+	var value string
+	switch p := paint.(type) {
+	case *LinearGradient:
+		value = s.defineGradient(fmt.Sprintf(`linearGradient x1="%s" y1="%s" x2="%s" y2="%s"`,
+			svgNumber(p.Start.X), svgNumber(p.Start.Y), svgNumber(p.End.X), svgNumber(p.End.Y)), p.Stops)
+	case *RadialGradient:
+		value = s.defineGradient(fmt.Sprintf(`radialGradient cx="%s" cy="%s" r="%s"`,
+			svgNumber(p.Center.X), svgNumber(p.Center.Y), svgNumber(p.Radius)), p.Stops)
+	default:
+		var alpha float64
+		value, alpha = svgColor(paint.ColorAt(Point{}))
+		opacity *= alpha
+	}
+
+	attrs := ` ` + attr + `="` + value + `"`
+	if opacity != 1 {
+		attrs += ` ` + attr + `-opacity="` + svgNumber(opacity) + `"`
+	}
+	return attrs
+}
+
+// defineGradient defines a gradient element and returns its reference
+func (s *SVG) defineGradient(element string, stops []GradientStop) string {
+	s.gradients++
+	id := fmt.Sprintf("gradient%d", s.gradients)
+	name := strings.Fields(element)[0]
+
+	fmt.Fprintf(&s.defs, `<%s id="%s" gradientUnits="userSpaceOnUse">`+"\n", element, id)
+	for _, stop := range stops {
+		c, alpha := svgColor(stop.Color)
+		fmt.Fprintf(&s.defs, `<stop offset="%s" stop-color="%s"`, svgNumber(stop.Offset), c)
+		if alpha != 1 {
+			fmt.Fprintf(&s.defs, ` stop-opacity="%s"`, svgNumber(alpha))
+		}
+		s.defs.WriteString("/>\n")
+	}
+	fmt.Fprintf(&s.defs, "</%s>\n", name)
+
+	return "url(#" + id + ")"
+}
+
+// blendMode returns the style attribute of the blend mode
+func (s *SVG) blendMode() string {
+	if s.current.style.BlendMode == BlendNormal {
+		return ""
+	}
+	return ` style="mix-blend-mode:` + s.current.style.BlendMode.String() + `"`
+}
+
+// transform returns the transform attribute of the current transform
+func (s *SVG) transform() string {
+	return svgTransform(s.current.transform)
+}
+
+// svgTransform returns the transform attribute of a transform, none for the
+// identity
+func svgTransform(t Transform) string {
+	if t.IsIdentity() {
+		return ""
+	}
+	linear := func(v float64) string {
+		return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+	}
+	return fmt.Sprintf(` transform="matrix(%s %s %s %s %s %s)"`,
+		linear(t.A), linear(t.B), linear(t.C), linear(t.D), svgNumber(t.E), svgNumber(t.F))
+}
+
+// svgColor returns the hex notation of a color and its alpha
+func svgColor(c color.Color) (string, float64) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), float64(n.A) / 0xff
+}
+
+// svgNumber formats a coordinate with at most three decimals
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// svgPoints formats the points of a polyline
+func svgPoints(points []Point) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = svgNumber(p.X) + "," + svgNumber(p.Y)
+	}
+	return strings.Join(coords, " ")
+}
+
+// svgArc returns the path data of a circular arc. Sweeps of a full turn
+// or more are split in two since an arc command can't end where it starts.
+func svgArc(center Point, radius, startAngle, endAngle float64) string {
+	point := func(angle float64) Point {
+		return Point{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)}
+	}
+
+	sweep := endAngle - startAngle
+	if math.Abs(sweep) >= 2*math.Pi {
+		sweep = math.Copysign(2*math.Pi, sweep)
+	}
+
+	steps := []float64{sweep}
+	if math.Abs(sweep) > math.Pi {
+		steps = []float64{sweep / 2, sweep / 2}
+	}
+
+	start := point(startAngle)
+	d := "M" + svgNumber(start.X) + " " + svgNumber(start.Y)
+	angle := startAngle
+	for _, step := range steps {
+		angle += step
+		end := point(angle)
+		d += fmt.Sprintf(" A%s %[1]s 0 0 %d %s %s", svgNumber(radius), boolToInt(step > 0), svgNumber(end.X), svgNumber(end.Y))
+	}
+	return d
+}
+
+// svgPathData returns the path data of a path. Malformed segments are drawn
+// as the raster draws them: a curve with the wrong number of points is a
+// line to its last point, a segment without points is skipped and a path
+// starts at the origin.
+func svgPathData(path *Path) string {
+	var parts []string
+	for _, segment := range path.Segments {
+		if segment.Op == PathClose {
+			if len(parts) > 0 {
+				parts = append(parts, "Z")
+			}
+			continue
+		}
+		if len(segment.Points) == 0 {
+			continue
+		}
+
+		command, points := "L", segment.Points[len(segment.Points)-1:]
+		switch {
+		case segment.Op == PathMoveTo:
+			command = "M"
+		case segment.Op == PathQuadTo && len(segment.Points) == 2:
+			command, points = "Q", segment.Points
+		case segment.Op == PathCubicTo && len(segment.Points) == 3:
+			command, points = "C", segment.Points
+		}
+		if len(parts) == 0 && command != "M" {
+			parts = append(parts, "M0 0")
+		}
+
+		for _, p := range points {
+			command += svgNumber(p.X) + " " + svgNumber(p.Y) + " "
+		}
+		parts = append(parts, strings.TrimSpace(command))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,218 @@
+package uikit
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// TerminalMode is the characters a Terminal draws its cells with
+type TerminalMode uint8
+
+const (
+	// TerminalBraille draws the 2x4 dots of every cell as a braille pattern
+	TerminalBraille TerminalMode = iota
+	// TerminalASCII draws every cell as an ASCII character as dense as the
+	// coverage of its dots
+	TerminalASCII
+)
+
+// terminalRamp are the ASCII characters from the lightest to the densest
+const terminalRamp = " .:-=+*#%@"
+
+// brailleThreshold is the alpha of the dots raised in a braille pattern, low
+// enough for hairlines to stay connected
+const brailleThreshold = 0x40
+
+// brailleDots are the bits of the braille dots of a cell, row by row
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// Terminal drawer renders the shapes as text for terminals and logs. It
+// rasterizes them at 2x4 dots per character cell, so a unit is a dot. Text
+// runs are written as characters at the cell of the middle of their left
+// edge, until a shape painted or a clear over the cell erases them.
+type Terminal struct {
+	*Raster
+
+	// Columns of character cells
+	Columns int
+	// Rows of character cells
+	Rows int
+	// Mode of the cells
+	Mode TerminalMode
+	// Color writes the cells with 256-color ANSI escape sequences
+	Color bool
+
+	text map[int]terminalCell
+}
+
+// terminalCell is a character of a text run
+type terminalCell struct {
+	char  rune
+	color color.Color
+}
+
+// NewTerminal creates a terminal drawer of the columns and rows of braille
+// cells without color
+func NewTerminal(columns, rows int) *Terminal {
+	t := &Terminal{
+		Raster:  NewRaster(2*columns, 4*rows),
+		Columns: columns,
+		Rows:    rows,
+		text:    map[int]terminalCell{},
+	}
+	t.Raster.painted = t.paintedOver
+	return t
+}
+
+// Erase erases the shapes and the text
+func (t *Terminal) Erase() {
+	t.Clear(color.Transparent)
+}
+
+// paintedOver erases the text of the cell of a dot mostly painted over
+func (t *Terminal) paintedOver(x, y int, alpha float64) {
+	if alpha >= 0.5 {
+		delete(t.text, y/4*t.Columns+x/2)
+	}
+}
+
+// DrawText writes the text run as characters with the fill paint, or with
+// the stroke paint when there is no fill
+func (t *Terminal) DrawText(text string, origin Point, font Font) error {
+	paint := t.current.style.textPaint()
+	if paint == nil {
+		return nil
+	}
+
+	p := t.current.transform.Apply(Point{X: origin.X, Y: origin.Y + font.Size/2})
+	column, row := int(math.Floor(p.X/2)), int(math.Floor(p.Y/4))
+	if row < 0 || row >= t.Rows {
+		return nil
+	}
+
+	for _, char := range text {
+		if column >= 0 && column < t.Columns && char >= ' ' {
+			t.text[row*t.Columns+column] = terminalCell{char: char, color: paint.ColorAt(origin)}
+		}
+		column++
+	}
+	return nil
+}
+
+// WriteTo writes the rows of cells, each ended by a new line. The blank
+// cells ending a row are left out.
+func (t *Terminal) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	chars := make([]rune, t.Columns)
+	colors := make([]color.Color, t.Columns)
+
+	for row := 0; row < t.Rows; row++ {
+		end := 0
+		for column := range chars {
+			chars[column], colors[column] = t.cell(column, row)
+			if chars[column] != ' ' {
+				end = column + 1
+			}
+		}
+
+		escape := -1
+		for column, char := range chars[:end] {
+			if t.Color && colors[column] != nil && char != ' ' {
+				if index := ansi256(colors[column]); index != escape {
+					fmt.Fprintf(&out, "\x1b[38;5;%dm", index)
+					escape = index
+				}
+			}
+			out.WriteRune(char)
+		}
+		if escape >= 0 {
+			out.WriteString("\x1b[0m")
+		}
+		out.WriteByte('\n')
+	}
+	return out.WriteTo(w)
+}
+
+// String returns the rows of cells
+func (t *Terminal) String() string {
+	var buf bytes.Buffer
+	t.WriteTo(&buf)
+	return buf.String()
+}
+
+// cell returns the character and the average color of the dots of a cell
+func (t *Terminal) cell(column, row int) (rune, color.Color) {
+	if text, ok := t.text[row*t.Columns+column]; ok {
+		return text.char, text.color
+	}
+
+	var pattern rune
+	var r, g, b, coverage float64
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 2; x++ {
+			dot := t.Image.RGBAAt(2*column+x, 4*row+y)
+			alpha := float64(dot.A) / 0xff
+			if dot.A >= brailleThreshold {
+				pattern |= brailleDots[y][x]
+			}
+			r, g, b = r+float64(dot.R), g+float64(dot.G), b+float64(dot.B)
+			coverage += alpha
+		}
+	}
+
+	var char rune = ' '
+	switch t.Mode {
+	case TerminalASCII:
+		char = rune(terminalRamp[int(math.Round(coverage/8*float64(len(terminalRamp)-1)))])
+	default:
+		if pattern != 0 {
+			char = 0x2800 + pattern
+		}
+	}
+
+	if coverage == 0 {
+		return char, nil
+	}
+	return char, color.NRGBA{
+		R: uint8(math.Min(0xff, r/coverage)),
+		G: uint8(math.Min(0xff, g/coverage)),
+		B: uint8(math.Min(0xff, b/coverage)),
+		A: 0xff,
+	}
+}
+
+// ansi256 returns the index of the nearest color of the 6x6x6 cube or of
+// the gray ramp of the 256-color palette
+func ansi256(c color.Color) int {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	channels := [3]float64{float64(n.R), float64(n.G), float64(n.B)}
+
+	levels := [6]float64{0, 95, 135, 175, 215, 255}
+	cube, cubeDistance := 16, 0.0
+	for i, v := range channels {
+		level := 0
+		for l := range levels {
+			if math.Abs(levels[l]-v) < math.Abs(levels[level]-v) {
+				level = l
+			}
+		}
+		cube += level * []int{36, 6, 1}[i]
+		cubeDistance += (levels[level] - v) * (levels[level] - v)
+	}
+
+	average := (channels[0] + channels[1] + channels[2]) / 3
+	step := int(clamp(math.Round((average-8)/10), 0, 23))
+	grayValue := float64(8 + 10*step)
+	grayDistance := 0.0
+	for _, v := range channels {
+		grayDistance += (grayValue - v) * (grayValue - v)
+	}
+
+	if grayDistance < cubeDistance {
+		return 232 + step
+	}
+	return cube
+}
@@ -0,0 +1,125 @@
+package uikit
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	red := Solid{Color: color.RGBA{R: 0xff, A: 0xff}}
+
+	tests := []struct {
+		name  string
+		mode  TerminalMode
+		color bool
+		want  string
+	}{
+		{"braille", TerminalBraille, false, "⣿⣿⣿⣿\n⣿⣿⣿⣿\n\n"},
+		{"ascii", TerminalASCII, false, "@@@@\n@@@@\n\n"},
+		{"color", TerminalBraille, true, "\x1b[38;5;196m⣿⣿⣿⣿\x1b[0m\n\x1b[38;5;196m⣿⣿⣿⣿\x1b[0m\n\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			terminal := NewTerminal(6, 3)
+			terminal.Mode = test.mode
+			terminal.Color = test.color
+
+			// a square of 4x2 cells of dots
+			terminal.SetStyle(Style{Fill: red, Opacity: 1})
+			terminal.DrawRoundedRect(rect(0, 0, 8, 8), 0)
+
+			if got := terminal.String(); got != test.want {
+				t.Errorf("drew %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTerminalText(t *testing.T) {
+	terminal := NewTerminal(10, 4)
+	terminal.SetStyle(Style{Fill: Solid{Color: color.Black}, Opacity: 1})
+	terminal.DrawRoundedRect(rect(0, 8, 20, 4), 0)
+
+	// the text is written over the shapes from the cell of the middle of
+	// its left edge, 8 dots below the origin in the default font
+	terminal.DrawText("hello", Point{X: 4, Y: 2}, DefaultFont)
+	terminal.DrawText("cut", Point{X: 16, Y: 6}, DefaultFont)
+	terminal.DrawText("below", Point{X: 0, Y: 40}, DefaultFont)
+
+	lines := strings.Split(terminal.String(), "\n")
+	if want := "⣿⣿hello⣿⣿⣿"; lines[2] != want {
+		t.Errorf("the third row is %q, want %q", lines[2], want)
+	}
+	if want := "        cu"; lines[3] != want {
+		t.Errorf("the fourth row is %q, want %q", lines[3], want)
+	}
+
+	terminal.Erase()
+	if got := terminal.String(); got != "\n\n\n\n" {
+		t.Errorf("the cleared terminal is %q", got)
+	}
+}
+
+func TestTerminalTextPaintedOver(t *testing.T) {
+	terminal := NewTerminal(10, 2)
+	terminal.Mode = TerminalASCII
+	terminal.SetStyle(Style{Fill: Solid{Color: color.Black}, Opacity: 1})
+	terminal.DrawText("abcdef", Point{Y: -4}, DefaultFont)
+
+	// a shape over a part of the text, a faint one and a clear of its color
+	terminal.DrawRoundedRect(rect(0, 4, 4, 4), 0)
+	terminal.SetStyle(Style{Fill: Solid{Color: color.Black}, Opacity: 0.25})
+	terminal.DrawRoundedRect(rect(4, 4, 4, 4), 0)
+	if want := "\n@@cdef\n"; terminal.String() != want {
+		t.Errorf("drew %q, want %q", terminal.String(), want)
+	}
+
+	terminal.Clear(color.Transparent)
+	if got := terminal.String(); got != "\n\n" {
+		t.Errorf("the cleared terminal is %q", got)
+	}
+}
+
+func TestTerminalScene(t *testing.T) {
+	terminal := NewTerminal(20, 4)
+	terminal.Mode = TerminalASCII
+	text := &Text{DrawingContext: terminal, Text: "hi", Font: DefaultFont, Style: &Style{Fill: Solid{Color: color.Black}, Opacity: 1}}
+	scene := NewScene(terminal, Size{Width: 40, Height: 16}, text)
+	if _, err := scene.Frame(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the moved text leaves no characters behind
+	text.Origin = Point{X: 20}
+	scene.Invalidate(text)
+	if _, err := scene.Frame(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(terminal.String(), "\n")
+	if want := "@@@@@@@@@@hi@@@@@@@@"; lines[2] != want {
+		t.Errorf("the third row is %q, want %q", lines[2], want)
+	}
+}
+
+func TestANSI256(t *testing.T) {
+	tests := []struct {
+		color color.Color
+		want  int
+	}{
+		{color.Black, 16},
+		{color.White, 231},
+		{color.RGBA{R: 0xff, A: 0xff}, 196},
+		{color.RGBA{G: 0xff, A: 0xff}, 46},
+		{color.RGBA{B: 0xff, A: 0xff}, 21},
+		{color.Gray{Y: 0x80}, 244},
+	}
+
+	for _, test := range tests {
+		if got := ansi256(test.color); got != test.want {
+			t.Errorf("the index of %v is %d, want %d", test.color, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package uikit
+
+import "math"
+
+// Transform is a 2D affine transform mapping a point (x, y) to
+// (A*x + C*y + E, B*x + D*y + F)
+type Transform struct {
+	A, B, C, D, E, F float64
+}
+
+// Identity leaves the points unchanged
+var Identity = Transform{A: 1, D: 1}
+
+// Translate returns a transform moving the points by the offsets
+func Translate(tx, ty float64) Transform {
+	return Transform{A: 1, D: 1, E: tx, F: ty}
+}
+
+// Scale returns a transform scaling the points from the origin
+func Scale(sx, sy float64) Transform {
+	return Transform{A: sx, D: sy}
+}
+
+// Rotate returns a transform rotating the points around the origin by the
+// angle in radians, clockwise on the screen
+func Rotate(angle float64) Transform {
+	sin, cos := math.Sincos(angle)
+	return Transform{A: cos, B: sin, C: -sin, D: cos}
+}
+
+// RotateAround returns a transform rotating the points around the center
+func RotateAround(center Point, angle float64) Transform {
+	return Translate(-center.X, -center.Y).Then(Rotate(angle)).Then(Translate(center.X, center.Y))
+}
+
+// Skew returns a transform slanting the x-axis by ax and the y-axis by ay,
+// both in radians
+func Skew(ax, ay float64) Transform {
+	return Transform{A: 1, B: math.Tan(ay), C: math.Tan(ax), D: 1}
+}
+
+// Then returns the transform applying t and then u
+func (t Transform) Then(u Transform) Transform {
+	return Transform{
+		A: u.A*t.A + u.C*t.B,
+		B: u.B*t.A + u.D*t.B,
+		C: u.A*t.C + u.C*t.D,
+		D: u.B*t.C + u.D*t.D,
+		E: u.A*t.E + u.C*t.F + u.E,
+		F: u.B*t.E + u.D*t.F + u.F,
+	}
+}
+
+// Invert returns the transform undoing t. It fails when t collapses the
+// plane into a line or a point.
+func (t Transform) Invert() (Transform, bool) {
+	det := t.A*t.D - t.B*t.C
+	if math.Abs(det) < 1e-12 {
+		return Transform{}, false
+	}
+
+	return Transform{
+		A: t.D / det,
+		B: -t.B / det,
+		C: -t.C / det,
+		D: t.A / det,
+		E: (t.C*t.F - t.D*t.E) / det,
+		F: (t.B*t.E - t.A*t.F) / det,
+	}, true
+}
+
+// Apply maps the point
+func (t Transform) Apply(p Point) Point {
+	return Point{
+		X: t.A*p.X + t.C*p.Y + t.E,
+		Y: t.B*p.X + t.D*p.Y + t.F,
+	}
+}
+
+// IsIdentity reports whether the transform leaves the points unchanged
+func (t Transform) IsIdentity() bool {
+	return t == Identity
+}
+
+// scaleFactor returns the largest stretch of a unit length by the transform
+func (t Transform) scaleFactor() float64 {
+	return math.Max(math.Hypot(t.A, t.B), math.Hypot(t.C, t.D))
+}
+
+// applyAll maps the points into a new slice
+func (t Transform) applyAll(points []Point) []Point {
+	mapped := make([]Point, len(points))
+	for i, p := range points {
+		mapped[i] = t.Apply(p)
+	}
+	return mapped
+}
+
+// state is the style, the transform and the clip the drawer draws with
+type state struct {
+	style     Style
+	transform Transform
+	clip      *clip
+}
+
+// clip is a rectangle in the coordinates of its transform the shapes are
+// restricted to, within the clip of its parent. Clips are never changed
+// once created, so that the saved states can share them.
+type clip struct {
+	parent    *clip
+	rect      Rect
+	transform Transform
+
+	// mask caches the coverage of the pixels of a Raster
+	mask []float64
+	// id caches the id of the clip path of an SVG
+	id string
+}
+
+// graphicsState keeps the current state of a drawer and a stack of saved
+// ones. Drawers embed it to implement the state methods of Drawer.
+type graphicsState struct {
+	current state
+	saved   []state
+}
+
+func newGraphicsState() graphicsState {
+	return graphicsState{current: state{style: DefaultStyle, transform: Identity}}
+}
+
+// SetStyle sets the style of the shapes drawn next
+func (g *graphicsState) SetStyle(style Style) {
+	g.current.style = style
+}
+
+// Save pushes a copy of the current style, transform and clip
+func (g *graphicsState) Save() {
+	g.saved = append(g.saved, g.current)
+}
+
+// Restore pops the style, transform and clip last saved. It does nothing
+// when none is saved.
+func (g *graphicsState) Restore() {
+	if len(g.saved) == 0 {
+		return
+	}
+	g.current = g.saved[len(g.saved)-1]
+	g.saved = g.saved[:len(g.saved)-1]
+}
+
+// Concat applies the transform to the shapes drawn next before the current
+// transform, so that they are drawn in its local coordinates
+func (g *graphicsState) Concat(t Transform) {
+	g.current.transform = t.Then(g.current.transform)
+}
+
+// ClipRect restricts the shapes drawn next to the rectangle in the current
+// transform, within the current clip
+func (g *graphicsState) ClipRect(r Rect) {
+	g.current.clip = &clip{parent: g.current.clip, rect: r, transform: g.current.transform}
+}
@@ -0,0 +1,126 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// near reports whether the points are within a millionth of each other
+func near(p, q Point) bool {
+	return math.Abs(p.X-q.X) < 1e-6 && math.Abs(p.Y-q.Y) < 1e-6
+}
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		point     Point
+		want      Point
+	}{
+		{"identity", Identity, Point{X: 3, Y: 4}, Point{X: 3, Y: 4}},
+		{"translate", Translate(10, -5), Point{X: 3, Y: 4}, Point{X: 13, Y: -1}},
+		{"scale", Scale(2, 3), Point{X: 3, Y: 4}, Point{X: 6, Y: 12}},
+		{"rotate", Rotate(math.Pi / 2), Point{X: 1, Y: 0}, Point{X: 0, Y: 1}},
+		{"rotate around", RotateAround(Point{X: 10, Y: 10}, math.Pi), Point{X: 12, Y: 10}, Point{X: 8, Y: 10}},
+		{"skew", Skew(math.Pi/4, 0), Point{X: 0, Y: 2}, Point{X: 2, Y: 2}},
+		{"scale then translate", Scale(2, 2).Then(Translate(1, 1)), Point{X: 3, Y: 4}, Point{X: 7, Y: 9}},
+		{"translate then scale", Translate(1, 1).Then(Scale(2, 2)), Point{X: 3, Y: 4}, Point{X: 8, Y: 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.transform.Apply(test.point); !near(got, test.want) {
+				t.Errorf("mapped %v to %v, want %v", test.point, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTransformInvert(t *testing.T) {
+	tests := []struct {
+		name       string
+		transform  Transform
+		invertible bool
+	}{
+		{"identity", Identity, true},
+		{"combined", Skew(0.3, 0.1).Then(Rotate(1.1)).Then(Scale(2, 3)).Then(Translate(5, -7)), true},
+		{"collapsed", Scale(0, 1), false},
+		{"collapsed onto a line", Transform{A: 1, B: 2, C: 2, D: 4}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inverse, ok := test.transform.Invert()
+			if ok != test.invertible {
+				t.Fatalf("invertible: %t, want %t", ok, test.invertible)
+			}
+			if !ok {
+				return
+			}
+
+			p := Point{X: 12, Y: -4}
+			if got := inverse.Apply(test.transform.Apply(p)); !near(got, p) {
+				t.Errorf("the inverse maps back to %v, want %v", got, p)
+			}
+		})
+	}
+}
+
+func TestGraphicsState(t *testing.T) {
+	g := newGraphicsState()
+	g.Save()
+	g.Concat(Translate(10, 0))
+	g.Concat(Scale(2, 2))
+	g.SetStyle(Style{Opacity: 0.5})
+
+	// the shapes are scaled in the local coordinates and then translated
+	if got := g.current.transform.Apply(Point{X: 1, Y: 1}); !near(got, Point{X: 12, Y: 2}) {
+		t.Errorf("mapped to %v, want 12,2", got)
+	}
+
+	g.Restore()
+	if !g.current.transform.IsIdentity() || g.current.style.Opacity != DefaultStyle.Opacity {
+		t.Errorf("the restored state is %+v", g.current)
+	}
+
+	// restoring without a saved state keeps the current one
+	g.Concat(Translate(1, 1))
+	g.Restore()
+	if g.current.transform != Translate(1, 1) {
+		t.Errorf("the transform is %+v after an unbalanced restore", g.current.transform)
+	}
+}
+
+func TestRasterClip(t *testing.T) {
+	raster := NewRaster(100, 100)
+	raster.SetStyle(Style{Fill: Solid{Color: color.Black}, Opacity: 1})
+
+	// a rotated clip within a clip is restored with its state
+	raster.Save()
+	raster.ClipRect(Rect{Size: Size{Width: 50, Height: 100}})
+	raster.Concat(RotateAround(Point{X: 50, Y: 50}, math.Pi/4))
+	raster.ClipRect(Rect{Location: Point{X: 30, Y: 30}, Size: Size{Width: 40, Height: 40}})
+	raster.DrawRoundedRect(Rect{Size: Size{Width: 200, Height: 200}}, 0)
+	raster.Restore()
+	raster.DrawRoundedRect(Rect{Location: Point{X: 90, Y: 90}, Size: Size{Width: 10, Height: 10}}, 0)
+
+	tests := []struct {
+		x, y  int
+		alpha uint8
+	}{
+		{40, 50, 0xff},
+		{27, 50, 0xff},
+		{20, 50, 0},
+		{60, 50, 0},
+		{50, 25, 0},
+		{40, 25, 0},
+		{95, 95, 0xff},
+	}
+
+	for _, test := range tests {
+		if alpha := raster.Image.RGBAAt(test.x, test.y).A; alpha != test.alpha {
+			t.Errorf("the alpha at %d,%d is %d, want %d", test.x, test.y, alpha, test.alpha)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package uikit
 
-import "fmt"
+import (
+	"fmt"
+	"image"
+	"math"
+)
 
 // Point represents a point on the screen
 type Point struct {
@@ -26,30 +30,226 @@ type Rect struct {
 	Size Size
 }
 
+// Empty reports whether the rectangle has no area
+func (r Rect) Empty() bool {
+	return !(r.Size.Width > 0 && r.Size.Height > 0)
+}
+
+// Intersect returns the largest rectangle in both rectangles, an empty one
+// when they don't overlap
+func (r Rect) Intersect(other Rect) Rect {
+	x0 := math.Max(r.Location.X, other.Location.X)
+	y0 := math.Max(r.Location.Y, other.Location.Y)
+	x1 := math.Min(r.Location.X+r.Size.Width, other.Location.X+other.Size.Width)
+	y1 := math.Min(r.Location.Y+r.Size.Height, other.Location.Y+other.Size.Height)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{}
+	}
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
+// Union returns the smallest rectangle containing both rectangles. Empty
+// rectangles are ignored.
+func (r Rect) Union(other Rect) Rect {
+	if r.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return r
+	}
+
+	x0 := math.Min(r.Location.X, other.Location.X)
+	y0 := math.Min(r.Location.Y, other.Location.Y)
+	x1 := math.Max(r.Location.X+r.Size.Width, other.Location.X+other.Size.Width)
+	y1 := math.Max(r.Location.Y+r.Size.Height, other.Location.Y+other.Size.Height)
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
 // Drawer draws on the underlying graphics device
 type Drawer interface {
+	// SetStyle sets the style of the shapes drawn next
+	SetStyle(Style)
+	// Save pushes a copy of the current style, transform and clip
+	Save()
+	// Restore pops the style, transform and clip last saved
+	Restore()
+	// Concat applies the transform to the shapes drawn next, in front of the
+	// current transform
+	Concat(Transform)
+	// ClipRect restricts the shapes drawn next to the rectangle, in the
+	// current transform, within the current clip
+	ClipRect(Rect)
 	// DrawEllipseInRect draws an ellipse in rectanlge
 	DrawEllipseInRect(Rect) error
+	// DrawLine draws a straight line
+	DrawLine(from, to Point) error
+	// DrawPolyline draws connected straight lines
+	DrawPolyline(points []Point) error
+	// DrawRoundedRect draws a rectangle with corners of this radius
+	DrawRoundedRect(r Rect, radius float64) error
+	// DrawArc draws a circular arc. The angles are in radians clockwise from
+	// the x-axis.
+	DrawArc(center Point, radius, startAngle, endAngle float64) error
+	// DrawPath draws lines and bezier curves
+	DrawPath(path *Path) error
+	// DrawText draws a text run whose top-left corner is at the origin
+	DrawText(text string, origin Point, font Font) error
+	// DrawImage draws an image scaled into the rectangle
+	DrawImage(img image.Image, r Rect) error
 }
 
 // OpenGL drawer
 type OpenGL struct{}
 
+// SetStyle sets the style of the shapes drawn next
+func (gl *OpenGL) SetStyle(style Style) {
+	fmt.Printf("OpenGL is using style %+v", style)
+}
+
+// Save pushes a copy of the current style and transform
+func (gl *OpenGL) Save() {
+	fmt.Printf("OpenGL is saving the graphics state")
+}
+
+// Restore pops the style and transform last saved
+func (gl *OpenGL) Restore() {
+	fmt.Printf("OpenGL is restoring the graphics state")
+}
+
+// Concat applies the transform to the shapes drawn next
+func (gl *OpenGL) Concat(t Transform) {
+	fmt.Printf("OpenGL is concatenating transform %+v", t)
+}
+
+// ClipRect restricts the shapes drawn next to the rectangle
+func (gl *OpenGL) ClipRect(r Rect) {
+	fmt.Printf("OpenGL is clipping to rect %v", r)
+}
+
 // DrawEllipseInRect draws an ellipse in rectangle
 func (gl *OpenGL) DrawEllipseInRect(r Rect) error {
 	fmt.Printf("OpenGL is drawing ellipse in rect %v", r)
 	return nil
 }
 
+// DrawLine draws a straight line
+func (gl *OpenGL) DrawLine(from, to Point) error {
+	fmt.Printf("OpenGL is drawing line from %v to %v", from, to)
+	return nil
+}
+
+// DrawPolyline draws connected straight lines
+func (gl *OpenGL) DrawPolyline(points []Point) error {
+	fmt.Printf("OpenGL is drawing polyline %v", points)
+	return nil
+}
+
+// DrawRoundedRect draws a rectangle with rounded corners
+func (gl *OpenGL) DrawRoundedRect(r Rect, radius float64) error {
+	fmt.Printf("OpenGL is drawing rect %v with corner radius %v", r, radius)
+	return nil
+}
+
+// DrawArc draws a circular arc
+func (gl *OpenGL) DrawArc(center Point, radius, startAngle, endAngle float64) error {
+	fmt.Printf("OpenGL is drawing arc at %v with radius %v from %v to %v", center, radius, startAngle, endAngle)
+	return nil
+}
+
+// DrawPath draws lines and bezier curves
+func (gl *OpenGL) DrawPath(path *Path) error {
+	fmt.Printf("OpenGL is drawing path of %d segments", len(path.Segments))
+	return nil
+}
+
+// DrawText draws a text run
+func (gl *OpenGL) DrawText(text string, origin Point, font Font) error {
+	fmt.Printf("OpenGL is drawing text %q at %v", text, origin)
+	return nil
+}
+
+// DrawImage draws an image scaled into the rectangle
+func (gl *OpenGL) DrawImage(img image.Image, r Rect) error {
+	fmt.Printf("OpenGL is drawing image %v in rect %v", img.Bounds(), r)
+	return nil
+}
+
 // Direct2D drawer
 type Direct2D struct{}
 
+// SetStyle sets the style of the shapes drawn next
+func (d2d *Direct2D) SetStyle(style Style) {
+	fmt.Printf("Direct2D is using style %+v", style)
+}
+
+// Save pushes a copy of the current style and transform
+func (d2d *Direct2D) Save() {
+	fmt.Printf("Direct2D is saving the graphics state")
+}
+
+// Restore pops the style and transform last saved
+func (d2d *Direct2D) Restore() {
+	fmt.Printf("Direct2D is restoring the graphics state")
+}
+
+// Concat applies the transform to the shapes drawn next
+func (d2d *Direct2D) Concat(t Transform) {
+	fmt.Printf("Direct2D is concatenating transform %+v", t)
+}
+
+// ClipRect restricts the shapes drawn next to the rectangle
+func (d2d *Direct2D) ClipRect(r Rect) {
+	fmt.Printf("Direct2D is clipping to rect %v", r)
+}
+
 // DrawEllipseInRect draws an ellipse in rectangle
 func (d2d *Direct2D) DrawEllipseInRect(r Rect) error {
 	fmt.Printf("Direct2D is drawing ellipse in rect %v", r)
 	return nil
 }
 
+// DrawLine draws a straight line
+func (d2d *Direct2D) DrawLine(from, to Point) error {
+	fmt.Printf("Direct2D is drawing line from %v to %v", from, to)
+	return nil
+}
+
+// DrawPolyline draws connected straight lines
+func (d2d *Direct2D) DrawPolyline(points []Point) error {
+	fmt.Printf("Direct2D is drawing polyline %v", points)
+	return nil
+}
+
+// DrawRoundedRect draws a rectangle with rounded corners
+func (d2d *Direct2D) DrawRoundedRect(r Rect, radius float64) error {
+	fmt.Printf("Direct2D is drawing rect %v with corner radius %v", r, radius)
+	return nil
+}
+
+// DrawArc draws a circular arc
+func (d2d *Direct2D) DrawArc(center Point, radius, startAngle, endAngle float64) error {
+	fmt.Printf("Direct2D is drawing arc at %v with radius %v from %v to %v", center, radius, startAngle, endAngle)
+	return nil
+}
+
+// DrawPath draws lines and bezier curves
+func (d2d *Direct2D) DrawPath(path *Path) error {
+	fmt.Printf("Direct2D is drawing path of %d segments", len(path.Segments))
+	return nil
+}
+
+// DrawText draws a text run
+func (d2d *Direct2D) DrawText(text string, origin Point, font Font) error {
+	fmt.Printf("Direct2D is drawing text %q at %v", text, origin)
+	return nil
+}
+
+// DrawImage draws an image scaled into the rectangle
+func (d2d *Direct2D) DrawImage(img image.Image, r Rect) error {
+	fmt.Printf("Direct2D is drawing image %v in rect %v", img.Bounds(), r)
+	return nil
+}
+
 // Circle represents a circle shape
 type Circle struct {
 	// DrawingContext for this circle
@@ -58,6 +258,8 @@ type Circle struct {
 	Center Point
 	// Radius of the circle
 	Radius float64
+	// Style of the circle, the default style when nil
+	Style *Style
 }
 
 // Draw draws a circle
@@ -73,5 +275,6 @@ func (circle *Circle) Draw() error {
 		},
 	}
 
+	circle.DrawingContext.SetStyle(styleOrDefault(circle.Style))
 	return circle.DrawingContext.DrawEllipseInRect(rect)
 }
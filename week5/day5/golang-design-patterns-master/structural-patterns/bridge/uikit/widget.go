@@ -0,0 +1,240 @@
+package uikit
+
+import "math"
+
+// Insets is the space along the four sides of a rectangle
+type Insets struct {
+	Top, Right, Bottom, Left float64
+}
+
+// UniformInsets returns the same space along every side
+func UniformInsets(space float64) Insets {
+	return Insets{Top: space, Right: space, Bottom: space, Left: space}
+}
+
+// Horizontal returns the space on the left and right sides
+func (i Insets) Horizontal() float64 {
+	return i.Left + i.Right
+}
+
+// Vertical returns the space on the top and bottom sides
+func (i Insets) Vertical() float64 {
+	return i.Top + i.Bottom
+}
+
+// shrink returns the rectangle inside the insets
+func (i Insets) shrink(r Rect) Rect {
+	return Rect{
+		Location: Point{X: r.Location.X + i.Left, Y: r.Location.Y + i.Top},
+		Size: Size{
+			Width:  math.Max(0, r.Size.Width-i.Horizontal()),
+			Height: math.Max(0, r.Size.Height-i.Vertical()),
+		},
+	}
+}
+
+// Alignment places a widget within the space given by its parent
+type Alignment uint8
+
+const (
+	// AlignStretch fills the whole space
+	AlignStretch Alignment = iota
+	// AlignStart places the widget at the left or top
+	AlignStart
+	// AlignCenter centers the widget
+	AlignCenter
+	// AlignEnd places the widget at the right or bottom
+	AlignEnd
+)
+
+// place returns the offset and the length of a widget of the desired length
+// in the space
+func (a Alignment) place(space, desired float64) (float64, float64) {
+	if a == AlignStretch || desired > space {
+		return 0, space
+	}
+
+	switch a {
+	case AlignCenter:
+		return (space - desired) / 2, desired
+	case AlignEnd:
+		return space - desired, desired
+	default:
+		return 0, desired
+	}
+}
+
+// Widget is a node of the widget tree. Its layout is computed in two passes:
+// Measure asks every widget the size it wants, Arrange gives every widget
+// its final rectangle.
+type Widget interface {
+	// Base returns the view holding the common properties of the widget
+	Base() *View
+	// MeasureContent returns the size of the content within the available
+	// size, the padding excluded
+	MeasureContent(available Size) Size
+	// ArrangeContent arranges the children in the content rectangle
+	ArrangeContent(content Rect)
+	// DrawContent draws the content, the children are drawn on top of it
+	DrawContent(d Drawer, content Rect) error
+}
+
+// View is a rectangular widget, and the base of all the widgets. Its children
+// are stacked on top of each other.
+type View struct {
+	// Children of the view
+	Children []Widget
+	// Margin is the space around the view
+	Margin Insets
+	// Padding is the space between the border and the content of the view
+	Padding Insets
+	// Width of the view, sized to its content when zero
+	Width float64
+	// Height of the view, sized to its content when zero
+	Height float64
+	// HorizontalAlignment within the space given by the parent
+	HorizontalAlignment Alignment
+	// VerticalAlignment within the space given by the parent
+	VerticalAlignment Alignment
+	// Flex is the share of the space left in a Stack the view grows into
+	Flex float64
+	// Background of the view, none when nil
+	Background *Style
+	// CornerRadius of the background
+	CornerRadius float64
+
+	// DesiredSize is the size measured, margin included
+	DesiredSize Size
+	// Bounds is the rectangle arranged, margin excluded
+	Bounds Rect
+
+	listeners []listener
+}
+
+// Base returns the view
+func (v *View) Base() *View {
+	return v
+}
+
+// MeasureContent returns the size of the largest child
+func (v *View) MeasureContent(available Size) Size {
+	var size Size
+	for _, child := range v.Children {
+		desired := Measure(child, available)
+		size.Width = math.Max(size.Width, desired.Width)
+		size.Height = math.Max(size.Height, desired.Height)
+	}
+	return size
+}
+
+// ArrangeContent gives every child the whole content rectangle
+func (v *View) ArrangeContent(content Rect) {
+	for _, child := range v.Children {
+		Arrange(child, content)
+	}
+}
+
+// DrawContent draws nothing, a view has no content but its children
+func (v *View) DrawContent(d Drawer, content Rect) error {
+	return nil
+}
+
+// Measure measures the widget and its children within the available size
+// and returns the size it wants, margin included
+func Measure(w Widget, available Size) Size {
+	v := w.Base()
+
+	inner := Size{
+		Width:  available.Width - v.Margin.Horizontal() - v.Padding.Horizontal(),
+		Height: available.Height - v.Margin.Vertical() - v.Padding.Vertical(),
+	}
+	if v.Width > 0 {
+		inner.Width = v.Width - v.Padding.Horizontal()
+	}
+	if v.Height > 0 {
+		inner.Height = v.Height - v.Padding.Vertical()
+	}
+	inner.Width = math.Max(0, inner.Width)
+	inner.Height = math.Max(0, inner.Height)
+
+	content := w.MeasureContent(inner)
+	size := Size{
+		Width:  content.Width + v.Padding.Horizontal(),
+		Height: content.Height + v.Padding.Vertical(),
+	}
+	if v.Width > 0 {
+		size.Width = v.Width
+	}
+	if v.Height > 0 {
+		size.Height = v.Height
+	}
+
+	v.DesiredSize = Size{
+		Width:  size.Width + v.Margin.Horizontal(),
+		Height: size.Height + v.Margin.Vertical(),
+	}
+	return v.DesiredSize
+}
+
+// Arrange places the measured widget in the slot given by its parent, then
+// arranges its children
+func Arrange(w Widget, slot Rect) {
+	v := w.Base()
+	area := v.Margin.shrink(slot)
+
+	desired := Size{
+		Width:  v.DesiredSize.Width - v.Margin.Horizontal(),
+		Height: v.DesiredSize.Height - v.Margin.Vertical(),
+	}
+
+	horizontal, vertical := v.HorizontalAlignment, v.VerticalAlignment
+	if horizontal == AlignStretch && v.Width > 0 {
+		horizontal = AlignCenter
+	}
+	if vertical == AlignStretch && v.Height > 0 {
+		vertical = AlignCenter
+	}
+
+	x, width := horizontal.place(area.Size.Width, desired.Width)
+	y, height := vertical.place(area.Size.Height, desired.Height)
+	v.Bounds = Rect{
+		Location: Point{X: area.Location.X + x, Y: area.Location.Y + y},
+		Size:     Size{Width: width, Height: height},
+	}
+
+	w.ArrangeContent(v.Padding.shrink(v.Bounds))
+}
+
+// Layout measures and arranges the widget tree in a rectangle of the size
+// at the origin
+func Layout(root Widget, size Size) {
+	Measure(root, size)
+	Arrange(root, Rect{Size: size})
+}
+
+// Render draws the arranged widget tree: the background, the content and the
+// children of every widget
+func Render(w Widget, d Drawer) error {
+	v := w.Base()
+
+	d.Save()
+	defer d.Restore()
+
+	if v.Background != nil {
+		d.SetStyle(*v.Background)
+		if err := d.DrawRoundedRect(v.Bounds, v.CornerRadius); err != nil {
+			return err
+		}
+	}
+
+	if err := w.DrawContent(d, v.Padding.shrink(v.Bounds)); err != nil {
+		return err
+	}
+
+	for _, child := range v.Children {
+		if err := Render(child, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
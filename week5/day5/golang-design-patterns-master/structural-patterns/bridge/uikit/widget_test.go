@@ -0,0 +1,105 @@
+package uikit
+
+import "testing"
+
+// rect returns the rectangle at x, y of the width and height
+func rect(x, y, width, height float64) Rect {
+	return Rect{Location: Point{X: x, Y: y}, Size: Size{Width: width, Height: height}}
+}
+
+func TestAlignmentPlace(t *testing.T) {
+	tests := []struct {
+		alignment Alignment
+		desired   float64
+		offset    float64
+		length    float64
+	}{
+		{AlignStretch, 20, 0, 100},
+		{AlignStart, 20, 0, 20},
+		{AlignCenter, 20, 40, 20},
+		{AlignEnd, 20, 80, 20},
+		{AlignEnd, 120, 0, 100},
+	}
+
+	for _, test := range tests {
+		offset, length := test.alignment.place(100, test.desired)
+		if offset != test.offset || length != test.length {
+			t.Errorf("%d placed %v at %v of %v, want %v of %v",
+				test.alignment, test.desired, offset, length, test.offset, test.length)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	a := &View{Width: 20, Height: 10}
+	spacer := &View{Flex: 1}
+	b := &View{Width: 30, Height: 10}
+	stack := NewStack(Horizontal, a, spacer, b)
+	stack.Spacing = 10
+	stack.Padding = UniformInsets(5)
+
+	c0 := &View{Height: 10}
+	c1 := &View{Height: 20}
+	c2 := &View{Height: 15}
+	c3 := &View{Height: 5}
+	grid := NewGrid([]Track{{Size: 50}, {Flex: 1}}, c0, c1, c2, c3)
+	grid.ColumnSpacing = 10
+	grid.RowSpacing = 5
+
+	corner := &View{Width: 20, Height: 20, HorizontalAlignment: AlignEnd, VerticalAlignment: AlignStart}
+	framed := &View{Children: []Widget{corner}, Margin: UniformInsets(10), Padding: UniformInsets(5)}
+
+	tests := []struct {
+		name    string
+		root    Widget
+		desired Size
+		bounds  map[*View]Rect
+	}{
+		{
+			name:    "stack",
+			root:    stack,
+			desired: Size{Width: 80, Height: 20},
+			bounds: map[*View]Rect{
+				&stack.View: rect(0, 0, 200, 100),
+				a:           rect(5, 45, 20, 10),
+				spacer:      rect(35, 5, 120, 90),
+				b:           rect(165, 45, 30, 10),
+			},
+		},
+		{
+			name:    "grid",
+			root:    grid,
+			desired: Size{Width: 60, Height: 40},
+			bounds: map[*View]Rect{
+				c0: rect(0, 5, 50, 10),
+				c1: rect(60, 0, 140, 20),
+				c2: rect(0, 25, 50, 15),
+				c3: rect(60, 30, 140, 5),
+			},
+		},
+		{
+			name:    "margin and padding",
+			root:    framed,
+			desired: Size{Width: 50, Height: 50},
+			bounds: map[*View]Rect{
+				framed: rect(10, 10, 180, 80),
+				corner: rect(165, 15, 20, 20),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			Layout(test.root, Size{Width: 200, Height: 100})
+
+			if desired := test.root.Base().DesiredSize; desired != test.desired {
+				t.Errorf("the root measured %v, want %v", desired, test.desired)
+			}
+			for view, want := range test.bounds {
+				if view.Bounds != want {
+					t.Errorf("a view is arranged in %v, want %v", view.Bounds, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package uikit
+
+import (
+	"image/color"
+	"math"
+)
+
+// Label is a widget showing a text run
+type Label struct {
+	View
+	// Text shown
+	Text string
+	// Font of the text, the default font when its size is zero
+	Font Font
+	// TextStyle of the text, the default style when nil
+	TextStyle *Style
+}
+
+// NewLabel creates a label of the text in the default font
+func NewLabel(text string) *Label {
+	return &Label{Text: text, Font: DefaultFont}
+}
+
+// MeasureContent returns the size of the text
+func (l *Label) MeasureContent(available Size) Size {
+	return MeasureText(l.Text, l.font())
+}
+
+// DrawContent draws the text at the top-left corner of the content
+func (l *Label) DrawContent(d Drawer, content Rect) error {
+	d.SetStyle(styleOrDefault(l.TextStyle))
+	return d.DrawText(l.Text, content.Location, l.font())
+}
+
+func (l *Label) font() Font {
+	if l.Font.Size <= 0 {
+		return DefaultFont
+	}
+	return l.Font
+}
+
+// ButtonStyle is the background of the buttons created by NewButton
+var ButtonStyle = Style{
+	Fill:        Solid{Color: color.RGBA{R: 0xe6, G: 0xe6, B: 0xe6, A: 0xff}},
+	Stroke:      Solid{Color: color.RGBA{R: 0x88, G: 0x88, B: 0x88, A: 0xff}},
+	StrokeWidth: 1,
+	Opacity:     1,
+}
+
+// Button is a label on a background with its text centered
+type Button struct {
+	Label
+}
+
+// NewButton creates a button of the text with the button style
+func NewButton(text string) *Button {
+	button := &Button{Label: *NewLabel(text)}
+	button.Padding = Insets{Top: 6, Right: 12, Bottom: 6, Left: 12}
+	button.Background = &ButtonStyle
+	button.CornerRadius = 4
+	return button
+}
+
+// DrawContent draws the text centered in the content
+func (b *Button) DrawContent(d Drawer, content Rect) error {
+	size := MeasureText(b.Text, b.font())
+	d.SetStyle(styleOrDefault(b.TextStyle))
+	return d.DrawText(b.Text, Point{
+		X: content.Location.X + (content.Size.Width-size.Width)/2,
+		Y: content.Location.Y + (content.Size.Height-size.Height)/2,
+	}, b.font())
+}
+
+// Orientation is the direction a Stack lays out its children in
+type Orientation uint8
+
+const (
+	// Vertical lays out the children from the top to the bottom
+	Vertical Orientation = iota
+	// Horizontal lays out the children from the left to the right
+	Horizontal
+)
+
+// Stack is a widget laying out its children in a row or a column. The
+// space left is shared by the children of positive Flex in its proportion.
+type Stack struct {
+	View
+	// Orientation of the stack
+	Orientation Orientation
+	// Spacing between the children
+	Spacing float64
+}
+
+// NewStack creates a stack of the children
+func NewStack(orientation Orientation, children ...Widget) *Stack {
+	stack := &Stack{Orientation: orientation}
+	stack.Children = children
+	return stack
+}
+
+// MeasureContent returns the length of the children along the stack and
+// the breadth of the broadest one
+func (s *Stack) MeasureContent(available Size) Size {
+	var length, breadth float64
+	unbounded := s.size(math.Inf(1), s.breadth(available))
+
+	for i, child := range s.Children {
+		desired := Measure(child, unbounded)
+		length += s.length(desired)
+		breadth = math.Max(breadth, s.breadth(desired))
+		if i > 0 {
+			length += s.Spacing
+		}
+	}
+	return s.size(length, breadth)
+}
+
+// ArrangeContent places the children one after the other, growing the
+// flexible ones into the space left
+func (s *Stack) ArrangeContent(content Rect) {
+	var used, flex float64
+	for i, child := range s.Children {
+		used += s.length(child.Base().DesiredSize)
+		flex += math.Max(0, child.Base().Flex)
+		if i > 0 {
+			used += s.Spacing
+		}
+	}
+	extra := math.Max(0, s.length(content.Size)-used)
+
+	offset := 0.0
+	for _, child := range s.Children {
+		length := s.length(child.Base().DesiredSize)
+		if f := child.Base().Flex; f > 0 && flex > 0 {
+			length += extra * f / flex
+		}
+
+		slot := Rect{Location: content.Location, Size: s.size(length, s.breadth(content.Size))}
+		if s.Orientation == Horizontal {
+			slot.Location.X += offset
+		} else {
+			slot.Location.Y += offset
+		}
+		Arrange(child, slot)
+
+		offset += length + s.Spacing
+	}
+}
+
+// length returns the extent of the size along the stack
+func (s *Stack) length(size Size) float64 {
+	if s.Orientation == Horizontal {
+		return size.Width
+	}
+	return size.Height
+}
+
+// breadth returns the extent of the size across the stack
+func (s *Stack) breadth(size Size) float64 {
+	if s.Orientation == Horizontal {
+		return size.Height
+	}
+	return size.Width
+}
+
+// size returns the size of the extents along and across the stack
+func (s *Stack) size(length, breadth float64) Size {
+	if s.Orientation == Horizontal {
+		return Size{Width: length, Height: breadth}
+	}
+	return Size{Width: breadth, Height: length}
+}
+
+// Track is a column or a row of a Grid. It has a fixed Size when positive,
+// shares the space left by its Flex when positive, or fits its content
+// otherwise.
+type Track struct {
+	// Size of a fixed track
+	Size float64
+	// Flex is the share of the space left a flexible track grows into
+	Flex float64
+}
+
+// Grid is a widget laying out its children in cells, row by row
+type Grid struct {
+	View
+	// Columns of the grid, a single one fitting its content when empty
+	Columns []Track
+	// Rows of the grid, the rows missing fit their content
+	Rows []Track
+	// ColumnSpacing between the columns
+	ColumnSpacing float64
+	// RowSpacing between the rows
+	RowSpacing float64
+}
+
+// NewGrid creates a grid of the columns and children
+func NewGrid(columns []Track, children ...Widget) *Grid {
+	grid := &Grid{Columns: columns}
+	grid.Children = children
+	return grid
+}
+
+// MeasureContent returns the size of the tracks fitting the children
+func (g *Grid) MeasureContent(available Size) Size {
+	columns, rows := g.tracks()
+
+	for i, child := range g.Children {
+		cell := available
+		if column := columns[i%len(columns)]; column.Size > 0 {
+			cell.Width = column.Size
+		}
+		if row := rows[i/len(columns)]; row.Size > 0 {
+			cell.Height = row.Size
+		}
+		Measure(child, cell)
+	}
+
+	widths := g.trackSizes(columns, len(columns), g.ColumnSpacing, math.Inf(-1), true)
+	heights := g.trackSizes(rows, len(columns), g.RowSpacing, math.Inf(-1), false)
+	return Size{
+		Width:  span(widths, g.ColumnSpacing),
+		Height: span(heights, g.RowSpacing),
+	}
+}
+
+// ArrangeContent places the children in their cells
+func (g *Grid) ArrangeContent(content Rect) {
+	columns, rows := g.tracks()
+	widths := g.trackSizes(columns, len(columns), g.ColumnSpacing, content.Size.Width, true)
+	heights := g.trackSizes(rows, len(columns), g.RowSpacing, content.Size.Height, false)
+
+	y := content.Location.Y
+	for row, height := range heights {
+		x := content.Location.X
+		for column, width := range widths {
+			i := row*len(columns) + column
+			if i >= len(g.Children) {
+				return
+			}
+			Arrange(g.Children[i], Rect{Location: Point{X: x, Y: y}, Size: Size{Width: width, Height: height}})
+			x += width + g.ColumnSpacing
+		}
+		y += height + g.RowSpacing
+	}
+}
+
+// tracks returns the columns and rows of the children
+func (g *Grid) tracks() ([]Track, []Track) {
+	columns := g.Columns
+	if len(columns) == 0 {
+		columns = []Track{{}}
+	}
+
+	count := (len(g.Children) + len(columns) - 1) / len(columns)
+	rows := g.Rows
+	if len(rows) < count {
+		rows = append(append([]Track(nil), rows...), make([]Track, count-len(rows))...)
+	}
+	return columns, rows
+}
+
+// trackSizes returns the size of the columns, or of the rows, of a grid of
+// perRow columns. The flexible tracks fit their content when the space is
+// unbounded, and share the space left by the other tracks otherwise.
+func (g *Grid) trackSizes(tracks []Track, perRow int, spacing, space float64, columns bool) []float64 {
+	sizes := make([]float64, len(tracks))
+	bounded := !math.IsInf(space, 0)
+	flex := 0.0
+
+	for i, track := range tracks {
+		if track.Size > 0 {
+			sizes[i] = track.Size
+			continue
+		}
+		if track.Flex > 0 {
+			flex += track.Flex
+			if bounded {
+				continue
+			}
+		}
+
+		for j, child := range g.Children {
+			column, row := j%perRow, j/perRow
+			if (columns && column != i) || (!columns && row != i) {
+				continue
+			}
+			desired := child.Base().DesiredSize
+			if columns {
+				sizes[i] = math.Max(sizes[i], desired.Width)
+			} else {
+				sizes[i] = math.Max(sizes[i], desired.Height)
+			}
+		}
+	}
+
+	if extra := space - span(sizes, spacing); bounded && flex > 0 && extra > 0 {
+		for i, track := range tracks {
+			if track.Size <= 0 && track.Flex > 0 {
+				sizes[i] = extra * track.Flex / flex
+			}
+		}
+	}
+	return sizes
+}
+
+// span returns the length of the tracks of the sizes and the spacing
+// between them
+func span(sizes []float64, spacing float64) float64 {
+	total := 0.0
+	for i, size := range sizes {
+		total += size
+		if i > 0 {
+			total += spacing
+		}
+	}
+	return total
+}
@@ -0,0 +1,226 @@
+package photoshop
+
+import (
+	"math"
+	"sort"
+)
+
+// Alignment is the edge or the center elements are aligned on
+type Alignment uint8
+
+const (
+	// AlignLeft aligns the left edges
+	AlignLeft Alignment = iota
+	// AlignCenter aligns the horizontal centers
+	AlignCenter
+	// AlignRight aligns the right edges
+	AlignRight
+	// AlignTop aligns the top edges
+	AlignTop
+	// AlignMiddle aligns the vertical centers
+	AlignMiddle
+	// AlignBottom aligns the bottom edges
+	AlignBottom
+)
+
+// Distribution is the axis elements are spaced evenly along
+type Distribution uint8
+
+const (
+	// DistributeHorizontally spaces the elements evenly from left to right
+	DistributeHorizontally Distribution = iota
+	// DistributeVertically spaces the elements evenly from top to bottom
+	DistributeVertically
+)
+
+// Align returns the edit moving the elements so that they align with the
+// rectangle of them all, nil when none moves. The elements which aren't
+// Movable stay.
+func Align(elements []VisualElement, alignment Alignment) Edit {
+	var bounds Rect
+	for _, element := range elements {
+		bounds = bounds.Union(element.Bounds())
+	}
+
+	var edits EditGroup
+	for _, element := range elements {
+		movable, ok := element.(Movable)
+		if !ok {
+			continue
+		}
+
+		r := element.Bounds()
+		var offset Point
+		switch alignment {
+		case AlignLeft:
+			offset.X = bounds.Location.X - r.Location.X
+		case AlignCenter:
+			offset.X = bounds.Center().X - r.Center().X
+		case AlignRight:
+			offset.X = bounds.Max().X - r.Max().X
+		case AlignTop:
+			offset.Y = bounds.Location.Y - r.Location.Y
+		case AlignMiddle:
+			offset.Y = bounds.Center().Y - r.Center().Y
+		case AlignBottom:
+			offset.Y = bounds.Max().Y - r.Max().Y
+		}
+
+		if offset != (Point{}) {
+			edits = append(edits, &MoveElement{Element: movable, Offset: offset})
+		}
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	return edits
+}
+
+// Distribute returns the edit moving the elements along the axis so that
+// the spaces between them are equal, nil when none moves. The first and the
+// last element stay, as well as the elements which aren't Movable.
+func Distribute(elements []VisualElement, distribution Distribution) Edit {
+	horizontal := distribution == DistributeHorizontally
+	start := func(r Rect) float64 {
+		if horizontal {
+			return r.Location.X
+		}
+		return r.Location.Y
+	}
+	length := func(r Rect) float64 {
+		if horizontal {
+			return r.Size.Width
+		}
+		return r.Size.Height
+	}
+
+	if len(elements) < 3 {
+		return nil
+	}
+
+	sorted := append([]VisualElement(nil), elements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return start(sorted[i].Bounds()) < start(sorted[j].Bounds())
+	})
+
+	first, last := sorted[0].Bounds(), sorted[len(sorted)-1].Bounds()
+	occupied := 0.0
+	for _, element := range sorted {
+		occupied += length(element.Bounds())
+	}
+	space := (start(last) + length(last) - start(first) - occupied) / float64(len(sorted)-1)
+
+	var edits EditGroup
+	position := start(first) + length(first) + space
+	for _, element := range sorted[1 : len(sorted)-1] {
+		r := element.Bounds()
+		if movable, ok := element.(Movable); ok && position != start(r) {
+			offset := Point{Y: position - start(r)}
+			if horizontal {
+				offset = Point{X: position - start(r)}
+			}
+			edits = append(edits, &MoveElement{Element: movable, Offset: offset})
+		}
+		position += length(r) + space
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	return edits
+}
+
+// Snapper snaps the rectangles of the elements being moved to the lines of
+// a grid and to the edges and centers of guides, such as the other
+// elements
+type Snapper struct {
+	// Grid is the spacing of the grid lines, no grid when zero
+	Grid float64
+	// Tolerance is the distance within which an edge snaps
+	Tolerance float64
+	// Guides are the rectangles whose edges and centers are snapped to
+	Guides []Rect
+}
+
+// NewSnapper creates a snapper to the grid and to the elements of the tree
+// of the layer, layers excluded, but the selected ones and theirs
+func NewSnapper(grid, tolerance float64, selection *Selection) *Snapper {
+	snapper := &Snapper{Grid: grid, Tolerance: tolerance}
+	snapper.addGuides(selection.Root, selection)
+	return snapper
+}
+
+// addGuides adds the bounds of the elements of the layer which aren't
+// selected
+func (s *Snapper) addGuides(layer *Layer, selection *Selection) {
+	for _, element := range layer.Elements {
+		if selection.Contains(element) {
+			continue
+		}
+		if group, ok := element.(*Layer); ok {
+			if !group.Hidden {
+				s.addGuides(group, selection)
+			}
+			continue
+		}
+		s.Guides = append(s.Guides, element.Bounds())
+	}
+}
+
+// Snap returns the offset moving the rectangle the least so that an edge
+// or the center of it lies on a line of the grid or on an edge or the
+// center of a guide, for every axis within the tolerance, zero otherwise
+func (s *Snapper) Snap(r Rect) Point {
+	var xs, ys []float64
+	for _, guide := range s.Guides {
+		xs = append(xs, guide.Location.X, guide.Center().X, guide.Max().X)
+		ys = append(ys, guide.Location.Y, guide.Center().Y, guide.Max().Y)
+	}
+
+	return Point{
+		X: s.snap([]float64{r.Location.X, r.Center().X, r.Max().X}, xs),
+		Y: s.snap([]float64{r.Location.Y, r.Center().Y, r.Max().Y}, ys),
+	}
+}
+
+// snap returns the smallest offset within the tolerance from one of the
+// values to a grid line or to one of the lines
+func (s *Snapper) snap(values, lines []float64) float64 {
+	offset, found := 0.0, false
+	consider := func(d float64) {
+		if math.Abs(d) <= s.Tolerance && (!found || math.Abs(d) < math.Abs(offset)) {
+			offset, found = d, true
+		}
+	}
+
+	for _, v := range values {
+		if s.Grid > 0 {
+			consider(math.Round(v/s.Grid)*s.Grid - v)
+		}
+		for _, line := range lines {
+			consider(line - v)
+		}
+	}
+	return offset
+}
+
+// SnapMove returns the edit moving the selected elements by the offset,
+// snapped by the snapper. The offset is from where the elements are now.
+// The elements of selected layers move with their layers. The moves of the
+// same selection merge, so that a drag is undone at once.
+func SnapMove(selection *Selection, offset Point, snapper *Snapper) Edit {
+	bounds := selection.Bounds()
+	bounds.Location = Point{X: bounds.Location.X + offset.X, Y: bounds.Location.Y + offset.Y}
+	snap := snapper.Snap(bounds)
+	offset = Point{X: offset.X + snap.X, Y: offset.Y + snap.Y}
+
+	var edits EditGroup
+	for _, element := range selection.Topmost() {
+		if movable, ok := element.(Movable); ok {
+			edits = append(edits, &MoveElement{Element: movable, Offset: offset})
+		}
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	return edits
+}
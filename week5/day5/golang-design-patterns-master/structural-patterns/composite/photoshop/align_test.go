@@ -0,0 +1,371 @@
+package photoshop
+
+import "testing"
+
+func TestSnapMoveDragMerges(t *testing.T) {
+	circle := &Circle{Center: Point{X: 50, Y: 50}, Radius: 10}
+	square := &Square{Location: Point{X: 100, Y: 100}, Side: 20}
+	root := NewLayer("Document", circle, square)
+
+	selection := NewSelection(root)
+	if err := selection.Select(circle, square); err != nil {
+		t.Fatal(err)
+	}
+
+	history := NewHistory(0)
+	history.BeginMerge()
+	for i := 0; i < 5; i++ {
+		history.Do(SnapMove(selection, Point{X: 3, Y: 1}, &Snapper{}))
+	}
+	history.Seal()
+
+	if circle.Center != (Point{X: 65, Y: 55}) || square.Location != (Point{X: 115, Y: 105}) {
+		t.Fatalf("dragged to %v and %v", circle.Center, square.Location)
+	}
+
+	if err := history.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if history.CanUndo() {
+		t.Errorf("the drag is not undone at once")
+	}
+	if circle.Center != (Point{X: 50, Y: 50}) || square.Location != (Point{X: 100, Y: 100}) {
+		t.Errorf("undone to %v and %v", circle.Center, square.Location)
+	}
+}
+
+func TestEditGroupMerge(t *testing.T) {
+	circle := &Circle{Radius: 10}
+	square := &Square{Side: 20}
+	offset := Point{X: 1, Y: 1}
+
+	tests := []struct {
+		name   string
+		next   Edit
+		merges bool
+	}{
+		{
+			name:   "same elements",
+			next:   EditGroup{&MoveElement{Element: circle, Offset: offset}, &MoveElement{Element: square, Offset: offset}},
+			merges: true,
+		},
+		{
+			name: "other elements",
+			next: EditGroup{&MoveElement{Element: square, Offset: offset}, &MoveElement{Element: circle, Offset: offset}},
+		},
+		{
+			name: "other edits",
+			next: EditGroup{&MoveElement{Element: circle, Offset: offset}, &ResizeElement{Element: square}},
+		},
+		{
+			name: "fewer edits",
+			next: EditGroup{&MoveElement{Element: circle, Offset: offset}},
+		},
+		{
+			name: "not a group",
+			next: &MoveElement{Element: circle, Offset: offset},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := EditGroup{&MoveElement{Element: circle}, &MoveElement{Element: square}}
+
+			if merges := group.Merge(test.next); merges != test.merges {
+				t.Fatalf("merged is %v, want %v", merges, test.merges)
+			}
+
+			want := Point{}
+			if test.merges {
+				want = offset
+			}
+			for _, edit := range group {
+				if move := edit.(*MoveElement); move.Offset != want {
+					t.Errorf("the move of %T is by %v, want %v", move.Element, move.Offset, want)
+				}
+			}
+		})
+	}
+
+	if (EditGroup{&AddElement{}}).Merge(EditGroup{&AddElement{}}) {
+		t.Errorf("a group of edits not merging merged")
+	}
+}
+
+func TestSelectionTopmost(t *testing.T) {
+	circle := &Circle{Center: Point{X: 50, Y: 50}, Radius: 10}
+	square := &Square{Location: Point{X: 100, Y: 100}, Side: 20}
+	group := NewLayer("Group", circle)
+	root := NewLayer("Document", group, square)
+
+	selection := NewSelection(root)
+	if err := selection.Select(circle, group, square); err != nil {
+		t.Fatal(err)
+	}
+
+	topmost := selection.Topmost()
+	if len(topmost) != 2 || topmost[0] != group || topmost[1] != square {
+		t.Fatalf("topmost elements are %v", topmost)
+	}
+
+	if err := SnapMove(selection, Point{X: 5, Y: 5}, &Snapper{}).Do(); err != nil {
+		t.Fatal(err)
+	}
+	if circle.Center != (Point{X: 55, Y: 55}) {
+		t.Errorf("the circle of the selected layer moved to %v", circle.Center)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		alignment      Alignment
+		circle, square Point
+	}{
+		{AlignLeft, Point{X: 40, Y: 40}, Point{X: 40, Y: 100}},
+		{AlignCenter, Point{X: 70, Y: 40}, Point{X: 70, Y: 100}},
+		{AlignRight, Point{X: 100, Y: 40}, Point{X: 100, Y: 100}},
+		{AlignTop, Point{X: 40, Y: 40}, Point{X: 100, Y: 40}},
+		{AlignMiddle, Point{X: 40, Y: 70}, Point{X: 100, Y: 70}},
+		{AlignBottom, Point{X: 40, Y: 100}, Point{X: 100, Y: 100}},
+	}
+
+	names := []string{"left", "center", "right", "top", "middle", "bottom"}
+	for _, test := range tests {
+		t.Run(names[test.alignment], func(t *testing.T) {
+			circle := &Circle{Center: Point{X: 50, Y: 50}, Radius: 10}
+			square := &Square{Location: Point{X: 100, Y: 100}, Side: 20}
+
+			history := NewHistory(0)
+			if err := history.Do(Align([]VisualElement{circle, square}, test.alignment)); err != nil {
+				t.Fatal(err)
+			}
+			if got := circle.Bounds().Location; got != test.circle {
+				t.Errorf("the circle is at %v, want %v", got, test.circle)
+			}
+			if got := square.Bounds().Location; got != test.square {
+				t.Errorf("the square is at %v, want %v", got, test.square)
+			}
+
+			if err := history.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if circle.Center != (Point{X: 50, Y: 50}) || square.Location != (Point{X: 100, Y: 100}) {
+				t.Errorf("undone to %v and %v", circle.Center, square.Location)
+			}
+		})
+	}
+}
+
+func TestAlignNothingMoves(t *testing.T) {
+	circle := &Circle{Center: Point{X: 50, Y: 50}, Radius: 10}
+	square := &Square{Location: Point{X: 40, Y: 100}, Side: 20}
+
+	edit := Align([]VisualElement{circle, square}, AlignLeft)
+	if edit != nil {
+		t.Fatalf("aligning aligned elements returned %v, want nil", edit)
+	}
+
+	history := NewHistory(0)
+	if err := history.Do(edit); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Do(EditGroup{}); err != nil {
+		t.Fatal(err)
+	}
+	if history.CanUndo() {
+		t.Errorf("the history kept an edit changing nothing")
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution Distribution
+		// starts are where the squares of side 10 start along the axis
+		starts []float64
+		want   []float64
+		moves  bool
+	}{
+		{"horizontally", DistributeHorizontally, []float64{0, 15, 90}, []float64{0, 45, 90}, true},
+		{"vertically", DistributeVertically, []float64{0, 15, 90}, []float64{0, 45, 90}, true},
+		{"unsorted", DistributeHorizontally, []float64{90, 0, 15}, []float64{90, 0, 45}, true},
+		{"four elements", DistributeVertically, []float64{0, 10, 20, 90}, []float64{0, 30, 60, 90}, true},
+		{"spaced already", DistributeHorizontally, []float64{0, 45, 90}, []float64{0, 45, 90}, false},
+		{"two elements", DistributeHorizontally, []float64{0, 90}, []float64{0, 90}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var squares []*Square
+			var elements []VisualElement
+			for _, start := range test.starts {
+				square := &Square{Location: Point{X: start, Y: 5}, Side: 10}
+				if test.distribution == DistributeVertically {
+					square.Location = Point{X: 5, Y: start}
+				}
+				squares = append(squares, square)
+				elements = append(elements, square)
+			}
+
+			edit := Distribute(elements, test.distribution)
+			if (edit != nil) != test.moves {
+				t.Fatalf("the edit is %v, want one moving elements: %v", edit, test.moves)
+			}
+			if edit != nil {
+				if err := edit.Do(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, square := range squares {
+				want := Point{X: test.want[i], Y: 5}
+				if test.distribution == DistributeVertically {
+					want = Point{X: 5, Y: test.want[i]}
+				}
+				if square.Location != want {
+					t.Errorf("square %d is at %v, want %v", i, square.Location, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapperSnap(t *testing.T) {
+	tests := []struct {
+		name    string
+		snapper Snapper
+		rect    Rect
+		want    Point
+	}{
+		{
+			name:    "grid within the tolerance",
+			snapper: Snapper{Grid: 10, Tolerance: 2},
+			rect:    Rect{Location: Point{X: 11, Y: 28}, Size: Size{Width: 5, Height: 5}},
+			want:    Point{X: -1, Y: -0.5},
+		},
+		{
+			name:    "grid outside the tolerance",
+			snapper: Snapper{Grid: 10, Tolerance: 0.4},
+			rect:    Rect{Location: Point{X: 13, Y: 24}, Size: Size{Width: 4, Height: 2}},
+		},
+		{
+			name:    "edge within the tolerance",
+			snapper: Snapper{Tolerance: 3, Guides: []Rect{{Location: Point{X: 50, Y: 50}, Size: Size{Width: 20, Height: 20}}}},
+			rect:    Rect{Location: Point{X: 72, Y: 30}, Size: Size{Width: 10, Height: 10}},
+			want:    Point{X: -2},
+		},
+		{
+			name:    "edge outside the tolerance",
+			snapper: Snapper{Tolerance: 1, Guides: []Rect{{Location: Point{X: 50, Y: 50}, Size: Size{Width: 20, Height: 20}}}},
+			rect:    Rect{Location: Point{X: 72, Y: 30}, Size: Size{Width: 10, Height: 10}},
+		},
+		{
+			name:    "center of a guide",
+			snapper: Snapper{Tolerance: 3, Guides: []Rect{{Location: Point{X: 50, Y: 50}, Size: Size{Width: 20, Height: 20}}}},
+			rect:    Rect{Location: Point{X: 58, Y: 61}, Size: Size{Width: 6, Height: 6}},
+			want:    Point{X: -1, Y: -1},
+		},
+		{
+			name:    "closest of the grid and the edges",
+			snapper: Snapper{Grid: 10, Tolerance: 3, Guides: []Rect{{Size: Size{Width: 13, Height: 13}}}},
+			rect:    Rect{Location: Point{X: 14, Y: 14}, Size: Size{Width: 4, Height: 4}},
+			want:    Point{X: -1, Y: -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.snapper.Snap(test.rect); got != test.want {
+				t.Errorf("snapped by %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// selectionTree returns a tree of a background, a layer of a circle and of
+// a hidden layer, a square on top and a hidden layer covering it all
+func selectionTree() (*Layer, map[string]VisualElement) {
+	background := &Square{Side: 100}
+	circle := &Circle{Center: Point{X: 50, Y: 50}, Radius: 10}
+	hidden := NewLayer("Hidden", &Square{Location: Point{X: 20, Y: 20}, Side: 10})
+	hidden.Hidden = true
+	group := NewLayer("Group", circle, hidden)
+	top := &Square{Location: Point{X: 80, Y: 80}, Side: 10}
+	cover := NewLayer("Cover", &Square{Side: 100})
+	cover.Hidden = true
+
+	root := NewLayer("Document", background, group, top, cover)
+	return root, map[string]VisualElement{
+		"background": background,
+		"circle":     circle,
+		"hidden":     hidden,
+		"group":      group,
+		"top":        top,
+		"cover":      cover,
+	}
+}
+
+func TestSelectionSelectAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		point Point
+		// want is the name of the element selected, none when empty
+		want string
+	}{
+		{"element on top", Point{X: 85, Y: 85}, "top"},
+		{"element of a layer", Point{X: 50, Y: 50}, "group"},
+		{"element of a hidden layer", Point{X: 25, Y: 25}, "background"},
+		{"under a hidden layer", Point{X: 5, Y: 5}, "background"},
+		{"nothing", Point{X: 150, Y: 150}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, elements := selectionTree()
+			selection := NewSelection(root)
+			selection.Select(elements["circle"])
+
+			selected := selection.SelectAt(test.point)
+			if test.want == "" {
+				if selected != nil || selection.Len() != 0 {
+					t.Errorf("selected %v, want nothing", selection.Elements())
+				}
+				return
+			}
+			if want := elements[test.want]; selected != want || selection.Len() != 1 || !selection.Contains(want) {
+				t.Errorf("selected %v, want only the %s", selection.Elements(), test.want)
+			}
+		})
+	}
+}
+
+func TestSelectionBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		selected []string
+		want     Rect
+	}{
+		{"nothing", nil, Rect{}},
+		{"element of a layer", []string{"circle"}, Rect{Location: Point{X: 40, Y: 40}, Size: Size{Width: 20, Height: 20}}},
+		{"layer with a hidden layer", []string{"group"}, Rect{Location: Point{X: 20, Y: 20}, Size: Size{Width: 40, Height: 40}}},
+		{"layer and its element", []string{"group", "circle"}, Rect{Location: Point{X: 20, Y: 20}, Size: Size{Width: 40, Height: 40}}},
+		{"apart", []string{"circle", "top"}, Rect{Location: Point{X: 40, Y: 40}, Size: Size{Width: 50, Height: 50}}},
+		{"hidden layer", []string{"cover"}, Rect{Size: Size{Width: 100, Height: 100}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, elements := selectionTree()
+			selection := NewSelection(root)
+			for _, name := range test.selected {
+				if err := selection.Add(elements[name]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := selection.Bounds(); got != test.want {
+				t.Errorf("the bounds are %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/internal/binenc"
+)
+
+// binaryMagic starts the binary form of a document
+const binaryMagic = "PSDC"
+
+// maxBinaryLength bounds the lengths read from the binary form
+const maxBinaryLength = 1 << 26
+
+// maxBinaryDepth bounds the nesting of the values read from the binary form
+const maxBinaryDepth = 512
+
+// The tags of the values of the binary form. A string is written once and
+// referred to by its index afterwards.
+const (
+	tagNull byte = iota
+	tagFalse
+	tagTrue
+	tagInt
+	tagFloat
+	tagString
+	tagStringRef
+	tagList
+	tagObject
+)
+
+// writeBinaryTree writes the decoded JSON value in the binary form
+func writeBinaryTree(w io.Writer, value interface{}) error {
+	bw := &binaryWriter{Writer: binenc.NewWriter(w), strings: map[string]uint64{}}
+
+	bw.Raw([]byte(binaryMagic))
+	if err := bw.value(value); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// readBinaryTree reads a value written by writeBinaryTree
+func readBinaryTree(r io.Reader) (interface{}, error) {
+	br := &binaryReader{Reader: binenc.NewReader(r, maxBinaryLength, "Document")}
+
+	if magic := br.Raw(len(binaryMagic)); br.Err() != nil || string(magic) != binaryMagic {
+		return nil, errors.New("Invalid document header")
+	}
+
+	value := br.value(0)
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// binaryWriter writes the binary form, keeping the first error
+type binaryWriter struct {
+	*binenc.Writer
+	strings map[string]uint64
+}
+
+func (bw *binaryWriter) int(v int64) {
+	bw.Byte(tagInt)
+	bw.Varint(v)
+}
+
+func (bw *binaryWriter) float(f float64) {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		bw.int(int64(f))
+		return
+	}
+
+	bw.Byte(tagFloat)
+	bw.Float(f)
+}
+
+func (bw *binaryWriter) string(s string) {
+	if index, ok := bw.strings[s]; ok {
+		bw.Byte(tagStringRef)
+		bw.Uvarint(index)
+		return
+	}
+
+	bw.strings[s] = uint64(len(bw.strings))
+	bw.Byte(tagString)
+	bw.Bytes([]byte(s))
+}
+
+// value writes a value decoded from JSON, the keys of the objects sorted
+func (bw *binaryWriter) value(value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		bw.Byte(tagNull)
+	case bool:
+		if value {
+			bw.Byte(tagTrue)
+		} else {
+			bw.Byte(tagFalse)
+		}
+	case int:
+		bw.int(int64(value))
+	case int64:
+		bw.int(value)
+	case float64:
+		bw.float(value)
+	case json.Number:
+		if v, err := value.Int64(); err == nil {
+			bw.int(v)
+			break
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		bw.float(f)
+	case string:
+		bw.string(value)
+	case []interface{}:
+		bw.Byte(tagList)
+		bw.Uvarint(uint64(len(value)))
+		for _, item := range value {
+			if err := bw.value(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		bw.Byte(tagObject)
+		bw.Uvarint(uint64(len(keys)))
+		for _, key := range keys {
+			bw.string(key)
+			if err := bw.value(value[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Unsupported document value %T", value)
+	}
+	return nil
+}
+
+// binaryReader reads the binary form, keeping the first error
+type binaryReader struct {
+	*binenc.Reader
+	strings []string
+}
+
+// string reads a string, written inline or referred to by its index
+func (br *binaryReader) string(tag byte) string {
+	switch tag {
+	case tagString:
+		s := string(br.Bytes())
+		if br.Err() == nil {
+			br.strings = append(br.strings, s)
+		}
+		return s
+	case tagStringRef:
+		index := br.Uvarint()
+		if index >= uint64(len(br.strings)) {
+			br.Fail(fmt.Errorf("Invalid document string %d", index))
+			return ""
+		}
+		return br.strings[index]
+	default:
+		br.Fail(fmt.Errorf("Invalid document string tag %d", tag))
+		return ""
+	}
+}
+
+// value reads a value at the depth of nesting
+func (br *binaryReader) value(depth int) interface{} {
+	if depth > maxBinaryDepth {
+		br.Fail(errors.New("The document is nested too deeply"))
+		return nil
+	}
+
+	tag := br.Byte()
+	if br.Err() != nil {
+		return nil
+	}
+
+	switch tag {
+	case tagNull:
+		return nil
+	case tagFalse:
+		return false
+	case tagTrue:
+		return true
+	case tagInt:
+		return br.Varint()
+	case tagFloat:
+		return br.Float()
+	case tagString, tagStringRef:
+		return br.string(tag)
+	case tagList:
+		list := []interface{}{}
+		for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+			list = append(list, br.value(depth+1))
+		}
+		return list
+	case tagObject:
+		object := map[string]interface{}{}
+		for i, n := 0, br.Length(); i < n && br.Err() == nil; i++ {
+			key := br.string(br.Byte())
+			object[key] = br.value(depth + 1)
+		}
+		return object
+	default:
+		br.Fail(fmt.Errorf("Invalid document value tag %d", tag))
+		return nil
+	}
+}
@@ -0,0 +1,379 @@
+package photoshop
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// BooleanOp is a boolean operation combining the areas of two shapes
+type BooleanOp uint8
+
+const (
+	// BooleanUnion keeps the area of either shape
+	BooleanUnion BooleanOp = iota
+	// BooleanIntersect keeps the area of both shapes
+	BooleanIntersect
+	// BooleanSubtract keeps the area of the first shape outside the second
+	BooleanSubtract
+	// BooleanXor keeps the area of a single shape
+	BooleanXor
+)
+
+var booleanOpNames = [...]string{"union", "intersect", "subtract", "xor"}
+
+// String returns the name of the operation
+func (op BooleanOp) String() string {
+	if int(op) < len(booleanOpNames) {
+		return booleanOpNames[op]
+	}
+	return fmt.Sprintf("BooleanOp(%d)", op)
+}
+
+// keeps tells whether the operation keeps a point inside or outside the
+// shapes
+func (op BooleanOp) keeps(a, b bool) bool {
+	switch op {
+	case BooleanIntersect:
+		return a && b
+	case BooleanSubtract:
+		return a && !b
+	case BooleanXor:
+		return a != b
+	default:
+		return a || b
+	}
+}
+
+// Outlined elements have an outline the boolean operations combine
+type Outlined interface {
+	VisualElement
+	// Outline returns the closed polygons of the area of the element,
+	// filled by the nonzero winding rule. Curves are flattened.
+	Outline() [][]Point
+}
+
+// Combine returns the path element of the area of the outlines of the
+// elements the operation keeps, in the style of the first element. Its
+// curves are flattened to lines.
+func Combine(op BooleanOp, a, b Outlined) *PathElement {
+	var style *Style
+	if styled, ok := a.(Styled); ok && styled.CurrentStyle() != nil {
+		copied := *styled.CurrentStyle()
+		style = &copied
+	}
+
+	return &PathElement{
+		Path:  polygonPath(combinePolygons(op, a.Outline(), b.Outline())),
+		Style: style,
+	}
+}
+
+// Outline returns the corners of the square
+func (square *Square) Outline() [][]Point {
+	return [][]Point{rectPolygon(Rect{Location: square.Location, Size: square.Size()})}
+}
+
+// Outline returns the polygon approximating the circle
+func (circle *Circle) Outline() [][]Point {
+	return [][]Point{ellipsePolygon(Rect{
+		Location: Point{X: circle.Center.X - circle.Radius, Y: circle.Center.Y - circle.Radius},
+		Size:     circle.Size(),
+	})}
+}
+
+// Outline returns the corners of the rectangle
+func (rectangle *Rectangle) Outline() [][]Point {
+	return [][]Point{rectPolygon(rectangle.Rect)}
+}
+
+// Outline returns the polygon approximating the ellipse
+func (ellipse *Ellipse) Outline() [][]Point {
+	return [][]Point{ellipsePolygon(ellipse.box())}
+}
+
+// Outline returns the polygon
+func (polygon *Polygon) Outline() [][]Point {
+	return [][]Point{append([]Point(nil), polygon.Points...)}
+}
+
+// Outline returns the tips and the notches of the star
+func (star *Star) Outline() [][]Point {
+	return [][]Point{star.vertices()}
+}
+
+// Outline returns the flattened subpaths of the path
+func (element *PathElement) Outline() [][]Point {
+	if element.Path == nil {
+		return nil
+	}
+	return element.Path.polygons()
+}
+
+// Outline returns the squares of the pixels of the glyphs of the text
+func (text *Text) Outline() [][]Point {
+	return textPolygons(text.Text, text.Origin, text.Font)
+}
+
+// rectPolygon returns the corners of the rectangle
+func rectPolygon(r Rect) []Point {
+	max := r.Max()
+	return []Point{r.Location, {X: max.X, Y: r.Location.Y}, max, {X: r.Location.X, Y: max.Y}}
+}
+
+// snapPrecision is the grid the points of the combined polygons are
+// snapped to, so that the same point computed from different edges matches
+const snapPrecision = 1e-6
+
+// booleanEdge is an edge of a shape from left to right
+type booleanEdge struct {
+	from, to Point
+	// winding is 1 when the edge goes from left to right in its polygon,
+	// -1 otherwise
+	winding int
+	// shape is 0 for the first shape, 1 for the second
+	shape int
+}
+
+// yAt returns the y-coordinate of the edge at x, within its ends
+func (e booleanEdge) yAt(x float64) float64 {
+	switch x {
+	case e.from.X:
+		return e.from.Y
+	case e.to.X:
+		return e.to.Y
+	}
+	return e.from.Y + (x-e.from.X)*(e.to.Y-e.from.Y)/(e.to.X-e.from.X)
+}
+
+// segment is a directed line of the boundary of a combined area
+type segment struct {
+	from, to Point
+}
+
+// combinePolygons returns the polygons of the area of the polygons a and b
+// the operation keeps. The plane is cut into vertical slabs at every vertex
+// and crossing, so that no edges cross within a slab; the area kept in
+// every slab is a stack of trapezoids, whose outer sides are traced into
+// polygons.
+func combinePolygons(op BooleanOp, a, b [][]Point) [][]Point {
+	edges := append(booleanEdges(a, 0), booleanEdges(b, 1)...)
+
+	var xs []float64
+	for i, e := range edges {
+		xs = append(xs, e.from.X, e.to.X)
+		for _, other := range edges[i+1:] {
+			if x, ok := crossingX(e, other); ok {
+				xs = append(xs, x)
+			}
+		}
+	}
+	sort.Float64s(xs)
+
+	var boundary []segment
+	for i := 0; i+1 < len(xs); i++ {
+		x0, x1 := xs[i], xs[i+1]
+		if x1-x0 < snapPrecision {
+			continue
+		}
+		boundary = append(boundary, slabTrapezoids(op, edges, x0, x1)...)
+	}
+	return tracePolygons(boundary)
+}
+
+// booleanEdges returns the edges of the polygons which aren't vertical,
+// from left to right
+func booleanEdges(polygons [][]Point, shape int) []booleanEdge {
+	var edges []booleanEdge
+	for _, polygon := range polygons {
+		for i, from := range polygon {
+			to := polygon[(i+1)%len(polygon)]
+			switch {
+			case from.X < to.X:
+				edges = append(edges, booleanEdge{from: from, to: to, winding: 1, shape: shape})
+			case from.X > to.X:
+				edges = append(edges, booleanEdge{from: to, to: from, winding: -1, shape: shape})
+			}
+		}
+	}
+	return edges
+}
+
+// crossingX returns the x-coordinate where the edges cross within their
+// ends
+func crossingX(e, f booleanEdge) (float64, bool) {
+	dx1, dy1 := e.to.X-e.from.X, e.to.Y-e.from.Y
+	dx2, dy2 := f.to.X-f.from.X, f.to.Y-f.from.Y
+	denominator := dx1*dy2 - dy1*dx2
+	if denominator == 0 {
+		return 0, false
+	}
+
+	t := ((f.from.X-e.from.X)*dy2 - (f.from.Y-e.from.Y)*dx2) / denominator
+	u := ((f.from.X-e.from.X)*dy1 - (f.from.Y-e.from.Y)*dx1) / denominator
+	if t <= 0 || t >= 1 || u <= 0 || u >= 1 {
+		return 0, false
+	}
+	return e.from.X + t*dx1, true
+}
+
+// slabTrapezoids returns the sides of the trapezoids of the area the
+// operation keeps between x0 and x1, turning clockwise on the screen
+func slabTrapezoids(op BooleanOp, edges []booleanEdge, x0, x1 float64) []segment {
+	middle := (x0 + x1) / 2
+	var crossing []booleanEdge
+	for _, e := range edges {
+		if e.from.X <= middle && e.to.X >= middle {
+			crossing = append(crossing, e)
+		}
+	}
+	sort.SliceStable(crossing, func(i, j int) bool {
+		return crossing[i].yAt(middle) < crossing[j].yAt(middle)
+	})
+
+	var sides []segment
+	var winding [2]int
+	var top booleanEdge
+	inside := false
+	for _, e := range crossing {
+		winding[e.shape] += e.winding
+		keeps := op.keeps(winding[0] != 0, winding[1] != 0)
+		if keeps == inside {
+			continue
+		}
+		inside = keeps
+		if inside {
+			top = e
+			continue
+		}
+
+		corners := []Point{
+			snap(Point{X: x0, Y: top.yAt(x0)}),
+			snap(Point{X: x1, Y: top.yAt(x1)}),
+			snap(Point{X: x1, Y: e.yAt(x1)}),
+			snap(Point{X: x0, Y: e.yAt(x0)}),
+		}
+		for i, from := range corners {
+			if to := corners[(i+1)%4]; from != to {
+				sides = append(sides, segment{from: from, to: to})
+			}
+		}
+	}
+	return sides
+}
+
+// tracePolygons cancels the sides the trapezoids share and chains the
+// others into polygons
+func tracePolygons(sides []segment) [][]Point {
+	sides = cancelSides(sides)
+
+	outgoing := map[Point][]int{}
+	for i, side := range sides {
+		outgoing[side.from] = append(outgoing[side.from], i)
+	}
+
+	used := make([]bool, len(sides))
+	var polygons [][]Point
+	for i := range sides {
+		if used[i] {
+			continue
+		}
+
+		var polygon []Point
+		for next := i; next >= 0 && !used[next]; {
+			used[next] = true
+			polygon = append(polygon, sides[next].from)
+
+			candidates, to := outgoing[sides[next].to], -1
+			for _, candidate := range candidates {
+				if !used[candidate] {
+					to = candidate
+					break
+				}
+			}
+			next = to
+		}
+
+		if polygon = simplifyPolygon(polygon); len(polygon) >= 3 {
+			polygons = append(polygons, polygon)
+		}
+	}
+	return polygons
+}
+
+// cancelSides drops the pairs of opposite sides, the vertical ones split
+// where other vertical sides on their line end
+func cancelSides(sides []segment) []segment {
+	vertical := map[float64][]segment{}
+	count := map[segment]int{}
+	for _, side := range sides {
+		if side.from.X == side.to.X {
+			vertical[side.from.X] = append(vertical[side.from.X], side)
+			continue
+		}
+		count[side]++
+	}
+
+	for x, line := range vertical {
+		var ys []float64
+		for _, side := range line {
+			ys = append(ys, side.from.Y, side.to.Y)
+		}
+		sort.Float64s(ys)
+
+		for _, side := range line {
+			low, high := math.Min(side.from.Y, side.to.Y), math.Max(side.from.Y, side.to.Y)
+			for i := 0; i+1 < len(ys); i++ {
+				if ys[i] < low || ys[i+1] > high || ys[i] == ys[i+1] {
+					continue
+				}
+				piece := segment{from: Point{X: x, Y: ys[i]}, to: Point{X: x, Y: ys[i+1]}}
+				if side.from.Y > side.to.Y {
+					piece.from, piece.to = piece.to, piece.from
+				}
+				count[piece]++
+			}
+		}
+	}
+
+	var kept []segment
+	for side, n := range count {
+		n -= count[segment{from: side.to, to: side.from}]
+		for ; n > 0; n-- {
+			kept = append(kept, side)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		a, b := kept[i], kept[j]
+		if a.from != b.from {
+			return a.from.X < b.from.X || (a.from.X == b.from.X && a.from.Y < b.from.Y)
+		}
+		return a.to.X < b.to.X || (a.to.X == b.to.X && a.to.Y < b.to.Y)
+	})
+	return kept
+}
+
+// simplifyPolygon drops the vertices in the middle of straight lines
+func simplifyPolygon(polygon []Point) []Point {
+	for changed := true; changed && len(polygon) >= 3; {
+		changed = false
+		for i := 0; i < len(polygon) && len(polygon) >= 3; i++ {
+			a := polygon[(i+len(polygon)-1)%len(polygon)]
+			p, b := polygon[i], polygon[(i+1)%len(polygon)]
+			if math.Abs((p.X-a.X)*(b.Y-p.Y)-(p.Y-a.Y)*(b.X-p.X)) < snapPrecision {
+				polygon = append(polygon[:i], polygon[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	return polygon
+}
+
+// snap returns the point on the grid of the precision
+func snap(p Point) Point {
+	return Point{
+		X: math.Round(p.X/snapPrecision) * snapPrecision,
+		Y: math.Round(p.Y/snapPrecision) * snapPrecision,
+	}
+}
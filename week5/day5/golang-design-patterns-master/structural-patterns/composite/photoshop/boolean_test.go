@@ -0,0 +1,146 @@
+package photoshop
+
+import (
+	"bytes"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+// outlineArea returns the area of the outline, the holes taken off
+func outlineArea(outline [][]Point) float64 {
+	total := 0.0
+	for _, polygon := range outline {
+		for i, p := range polygon {
+			q := polygon[(i+1)%len(polygon)]
+			total += p.X*q.Y - q.X*p.Y
+		}
+	}
+	return math.Abs(total / 2)
+}
+
+func TestCombine(t *testing.T) {
+	square := &Rectangle{Rect: Rect{Size: Size{Width: 10, Height: 10}}, Style: &Style{Fill: color.Black}}
+	overlapping := &Rectangle{Rect: Rect{Location: Point{X: 5, Y: 5}, Size: Size{Width: 10, Height: 10}}}
+	adjacent := &Rectangle{Rect: Rect{Location: Point{X: 10}, Size: Size{Width: 10, Height: 10}}}
+	inner := &Rectangle{Rect: Rect{Location: Point{X: 2, Y: 2}, Size: Size{Width: 6, Height: 6}}}
+
+	tests := []struct {
+		name     string
+		op       BooleanOp
+		b        Outlined
+		area     float64
+		polygons int
+		corners  int
+	}{
+		{"union", BooleanUnion, overlapping, 175, 1, 8},
+		{"intersect", BooleanIntersect, overlapping, 25, 1, 4},
+		{"subtract", BooleanSubtract, overlapping, 75, 1, 6},
+		{"xor", BooleanXor, overlapping, 150, 2, 6},
+		{"union of adjacent", BooleanUnion, adjacent, 200, 1, 4},
+		{"intersect of adjacent", BooleanIntersect, adjacent, 0, 0, 0},
+		{"hole", BooleanSubtract, inner, 64, 2, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			combined := Combine(test.op, square, test.b)
+			outline := combined.Outline()
+
+			if area := outlineArea(outline); math.Abs(area-test.area) > 1e-6 {
+				t.Errorf("the area is %v, want %v", area, test.area)
+			}
+			if len(outline) != test.polygons {
+				t.Fatalf("the outline is %v, want %d polygons", outline, test.polygons)
+			}
+			if test.polygons > 0 && len(outline[0]) != test.corners {
+				t.Errorf("the first polygon is %v, want %d corners", outline[0], test.corners)
+			}
+			if combined.Style == nil || combined.Style == square.Style {
+				t.Errorf("the style of the first shape is not copied")
+			}
+		})
+	}
+}
+
+func TestCombineCurves(t *testing.T) {
+	circle := &Circle{Radius: 10}
+	ellipse := &Ellipse{Center: Point{X: 10}, RadiusX: 10, RadiusY: 10}
+
+	// the union and the intersection cover both shapes once
+	union := outlineArea(Combine(BooleanUnion, circle, ellipse).Outline())
+	intersection := outlineArea(Combine(BooleanIntersect, circle, ellipse).Outline())
+	areas := outlineArea(circle.Outline()) + outlineArea(ellipse.Outline())
+	if math.Abs(union+intersection-areas) > 1e-4 {
+		t.Errorf("the union %v and the intersection %v don't add up to %v", union, intersection, areas)
+	}
+
+	star := &Star{Center: Point{X: 20, Y: 20}, Points: 5, OuterRadius: 10, InnerRadius: 4}
+	triangle := &Polygon{Points: []Point{{X: 15, Y: 15}, {X: 30, Y: 15}, {X: 30, Y: 30}}}
+	// the corners where the outlines cross are snapped, so the areas are
+	// close but not exact
+	xor := outlineArea(Combine(BooleanXor, star, triangle).Outline())
+	overlap := outlineArea(Combine(BooleanIntersect, star, triangle).Outline())
+	if want := outlineArea(star.Outline()) + 112.5 - 2*overlap; math.Abs(xor-want) > 1e-4 {
+		t.Errorf("the xor of the star and the triangle is %v, want %v", xor, want)
+	}
+}
+
+func TestShapes(t *testing.T) {
+	fill := &Style{Fill: color.NRGBA{R: 0xff, A: 0xff}}
+	path := &Path{}
+	path.MoveTo(Point{X: 2, Y: 30})
+	path.QuadTo(Point{X: 10, Y: 20}, Point{X: 18, Y: 30})
+	path.Close()
+
+	doc := NewDocument(40, 40)
+	doc.Root.Add(
+		&Rectangle{Rect: Rect{Location: Point{X: 1, Y: 1}, Size: Size{Width: 8, Height: 4}}, Style: fill},
+		&Ellipse{Center: Point{X: 25, Y: 5}, RadiusX: 8, RadiusY: 3, Style: fill},
+		&Polygon{Points: []Point{{X: 1, Y: 10}, {X: 9, Y: 10}, {X: 5, Y: 18}}, Style: fill},
+		&Star{Center: Point{X: 30, Y: 20}, Points: 5, OuterRadius: 6, InnerRadius: 3, Style: fill},
+		&PathElement{Path: path, Style: &Style{Stroke: color.Black, StrokeWidth: 1}},
+		&Text{Text: "Hi", Origin: Point{X: 22, Y: 32}, Font: DefaultFont, Style: fill},
+	)
+
+	img, err := Render(doc.Root, 40, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []Point{{X: 4, Y: 3}, {X: 25, Y: 5}, {X: 5, Y: 12}, {X: 30, Y: 20}} {
+		if c := img.RGBAAt(int(p.X), int(p.Y)); c.R != 0xff {
+			t.Errorf("the pixel at %v is %v, want it filled", p, c)
+		}
+	}
+
+	var data bytes.Buffer
+	if err := doc.WriteJSON(&data); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"rectangle", "ellipse", "polygon", "star", "path", "text", "quad_to"} {
+		if !strings.Contains(data.String(), `"`+kind+`"`) {
+			t.Errorf("the document has no %s", kind)
+		}
+	}
+
+	loaded, err := ReadDocument(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Render(loaded.Root, 40, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reloaded.Pix, img.Pix) {
+		t.Errorf("the loaded shapes are drawn differently")
+	}
+
+	svg := NewSVG(40, 40)
+	if err := doc.Draw(svg); err != nil {
+		t.Fatal(err)
+	}
+	if out := svg.String(); !strings.Contains(out, "<path") || !strings.Contains(out, "<text") || !strings.Contains(out, " Q") {
+		t.Errorf("the SVG drawer drew\n%s", out)
+	}
+}
@@ -0,0 +1,98 @@
+package photoshop
+
+import "math"
+
+// Bounds returns the rectangle of the painted square
+func (square *Square) Bounds() Rect {
+	return strokeBounds(Rect{Location: square.Location, Size: square.Size()}, square.Style)
+}
+
+// Bounds returns the rectangle of the painted circle
+func (circle *Circle) Bounds() Rect {
+	return strokeBounds(Rect{
+		Location: Point{X: circle.Center.X - circle.Radius, Y: circle.Center.Y - circle.Radius},
+		Size:     circle.Size(),
+	}, circle.Style)
+}
+
+// Bounds returns the rectangle of the elements of the layer, hidden or not,
+// empty when it has none
+func (layer *Layer) Bounds() Rect {
+	var bounds Rect
+	for _, element := range layer.Elements {
+		bounds = bounds.Union(element.Bounds())
+	}
+	return bounds
+}
+
+// Empty tells whether the rectangle has no area
+func (r Rect) Empty() bool {
+	return r.Size.Width <= 0 || r.Size.Height <= 0
+}
+
+// Max returns the bottom right corner of the rectangle
+func (r Rect) Max() Point {
+	return Point{X: r.Location.X + r.Size.Width, Y: r.Location.Y + r.Size.Height}
+}
+
+// Center returns the center of the rectangle
+func (r Rect) Center() Point {
+	return Point{X: r.Location.X + r.Size.Width/2, Y: r.Location.Y + r.Size.Height/2}
+}
+
+// Contains tells whether the point is in the rectangle
+func (r Rect) Contains(p Point) bool {
+	max := r.Max()
+	return p.X >= r.Location.X && p.X < max.X && p.Y >= r.Location.Y && p.Y < max.Y
+}
+
+// Union returns the smallest rectangle containing both rectangles. An empty
+// rectangle adds nothing.
+func (r Rect) Union(other Rect) Rect {
+	if r.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return r
+	}
+
+	max, otherMax := r.Max(), other.Max()
+	x0, y0 := math.Min(r.Location.X, other.Location.X), math.Min(r.Location.Y, other.Location.Y)
+	x1, y1 := math.Max(max.X, otherMax.X), math.Max(max.Y, otherMax.Y)
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
+// Intersect returns the rectangle both rectangles contain, empty when they
+// don't overlap
+func (r Rect) Intersect(other Rect) Rect {
+	max, otherMax := r.Max(), other.Max()
+	x0, y0 := math.Max(r.Location.X, other.Location.X), math.Max(r.Location.Y, other.Location.Y)
+	x1, y1 := math.Min(max.X, otherMax.X), math.Min(max.Y, otherMax.Y)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{}
+	}
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
+// pointBounds returns the smallest rectangle containing the points
+func pointBounds(points []Point) Rect {
+	if len(points) == 0 {
+		return Rect{}
+	}
+
+	x0, y0, x1, y1 := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points[1:] {
+		x0, y0 = math.Min(x0, p.X), math.Min(y0, p.Y)
+		x1, y1 = math.Max(x1, p.X), math.Max(y1, p.Y)
+	}
+	return Rect{Location: Point{X: x0, Y: y0}, Size: Size{Width: x1 - x0, Height: y1 - y0}}
+}
+
+// strokeBounds grows the bounds of an outline by half the width of the
+// stroke of the style
+func strokeBounds(r Rect, style *Style) Rect {
+	if s := styleOrDefault(style); s.Stroke != nil && s.StrokeWidth > 0 {
+		return inset(r, -s.StrokeWidth/2)
+	}
+	return r
+}
@@ -1,8 +1,23 @@
 package main
 
-import "github.com/svett/golang-design-patterns/structural-patterns/composite/photoshop"
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/composite/photoshop"
+)
 
 func main() {
+	pngOut := flag.String("png", "", "renders the document to the PNG file")
+	svgOut := flag.String("svg", "", "renders the document to the SVG file")
+	docOut := flag.String("save", "", "saves the document to the file, as JSON when it ends in .json, and loads it back")
+	flag.Parse()
+
 	circle := &photoshop.Circle{
 		Center: photoshop.Point{X: 100, Y: 100},
 		Radius: 50,
@@ -20,5 +35,264 @@ func main() {
 		},
 	}
 
-	layer.Draw(&photoshop.Drawer{})
+	layer.Draw(&photoshop.Printer{})
+
+	fmt.Println()
+
+	background := photoshop.NewLayer("Background", &photoshop.Square{Side: 200})
+	shapes := photoshop.NewLayer("Shapes", circle, square)
+	badge := &photoshop.Circle{Center: photoshop.Point{X: 180, Y: 20}, Radius: 10}
+	overlay := photoshop.NewLayer("Overlay", badge)
+	overlay.Opacity = 0.5
+	overlay.BlendMode = photoshop.BlendMultiply
+
+	document := photoshop.NewLayer("Document", background, shapes, overlay)
+
+	background.Locked = true
+	if err := document.Move(badge, background, 0); err != nil {
+		fmt.Printf("Moving the badge into %s failed: %v\n", background.Name, err)
+	}
+	if err := document.Move(badge, shapes, 0); err != nil {
+		fmt.Printf("Moving the badge into %s failed: %v\n", shapes.Name, err)
+	}
+	shapes.BringToFront(circle)
+	document.Lower(overlay)
+	overlay.Hidden = true
+
+	printTree(document, 0)
+	document.Draw(&photoshop.Printer{})
+	edit(circle)
+	arrange(document, circle, square)
+
+	if *pngOut == "" && *svgOut == "" && *docOut == "" {
+		return
+	}
+
+	colorize(document)
+	sketch(document)
+	if *docOut != "" {
+		loaded, err := saveAndLoad(*docOut, &photoshop.Document{
+			Width:    200,
+			Height:   200,
+			Metadata: map[string]string{"title": "Composite"},
+			Root:     document,
+		})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		document = loaded.Root
+	}
+
+	recorder := &photoshop.Recorder{}
+	if err := document.Draw(recorder); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Recorded %d drawing commands\n", len(recorder.Commands))
+
+	raster := photoshop.NewRaster(200, 200)
+	svg := photoshop.NewSVG(200, 200)
+	for _, drawer := range []photoshop.Drawer{raster, svg} {
+		if err := recorder.Play(drawer); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if *pngOut != "" {
+		if err := save(*pngOut, func(w io.Writer) error { return png.Encode(w, raster.Image) }); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *svgOut != "" {
+		if err := save(*svgOut, func(w io.Writer) error { _, err := svg.WriteTo(w); return err }); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// edit drags and resizes the circle, then undoes and redoes the edits and
+// undoes them again
+func edit(circle *photoshop.Circle) {
+	history := photoshop.NewHistory(100)
+	history.BeginMerge()
+	for i := 0; i < 10; i++ {
+		history.Do(&photoshop.MoveElement{Element: circle, Offset: photoshop.Point{X: 2, Y: 1}})
+	}
+	history.Seal()
+	history.Do(&photoshop.ResizeElement{Element: circle, Size: photoshop.Size{Width: 60, Height: 60}})
+	fmt.Printf("Edited circle at %v of radius %v\n", circle.Center, circle.Radius)
+
+	for history.CanUndo() {
+		history.Undo()
+		fmt.Printf("Undone to circle at %v of radius %v\n", circle.Center, circle.Radius)
+	}
+	for history.CanRedo() {
+		history.Redo()
+	}
+	fmt.Printf("Redone to circle at %v of radius %v\n", circle.Center, circle.Radius)
+
+	for history.CanUndo() {
+		history.Undo()
+	}
+}
+
+// arrange selects the circle and the square, aligns them and snaps them to
+// a grid, then undoes it all
+func arrange(document *photoshop.Layer, circle *photoshop.Circle, square *photoshop.Square) {
+	selection := photoshop.NewSelection(document)
+	if err := selection.Select(circle, square); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Selected %d elements in %v\n", selection.Len(), selection.Bounds())
+
+	history := photoshop.NewHistory(0)
+	history.Do(photoshop.Align(selection.Topmost(), photoshop.AlignTop))
+	history.Do(photoshop.Align(selection.Topmost(), photoshop.AlignCenter))
+	fmt.Printf("Aligned the square to %v\n", square.Bounds())
+
+	snapper := photoshop.NewSnapper(16, 4, selection)
+	history.Do(photoshop.SnapMove(selection, photoshop.Point{X: 7, Y: 7}, snapper))
+	fmt.Printf("Snapped the selection to %v\n", selection.Bounds())
+
+	for history.CanUndo() {
+		history.Undo()
+	}
+}
+
+// colorize styles the shapes of the document, tints them with a layer and
+// casts their shadow
+func colorize(document *photoshop.Layer) {
+	background := document.Find("Background")
+	background.Elements[0].(*photoshop.Square).Style = &photoshop.Style{
+		Fill: color.RGBA{R: 0xf0, G: 0xe6, B: 0xd2, A: 0xff},
+	}
+
+	shapes := document.Find("Shapes")
+	for _, element := range shapes.Elements {
+		switch element := element.(type) {
+		case *photoshop.Circle:
+			element.Style = &photoshop.Style{
+				Fill:        color.RGBA{R: 0x20, G: 0x60, B: 0xc0, A: 0xff},
+				Stroke:      color.Black,
+				StrokeWidth: 3,
+			}
+		case *photoshop.Square:
+			element.Style = &photoshop.Style{Fill: color.RGBA{R: 0xe0, G: 0x40, B: 0x30, A: 0xff}}
+		}
+	}
+
+	shade := &photoshop.Square{
+		Location: photoshop.Point{X: 70, Y: 70},
+		Side:     100,
+		Style:    &photoshop.Style{Fill: color.RGBA{R: 0xff, G: 0xc0, B: 0x00, A: 0xff}},
+	}
+	tint := photoshop.NewLayer("Tint", shade)
+	tint.BlendMode = photoshop.BlendMultiply
+	tint.Operator = photoshop.SourceAtop
+
+	shapes.Add(tint)
+	shapes.Filters = []photoshop.Filter{
+		&photoshop.HueSaturation{Saturation: 0.2},
+		&photoshop.DropShadow{Offset: photoshop.Point{X: 4, Y: 4}, Blur: 3, Opacity: 0.5},
+	}
+}
+
+// sketch adds a layer of vector shapes, one of them cut out of another
+func sketch(document *photoshop.Layer) {
+	star := &photoshop.Star{
+		Center:      photoshop.Point{X: 160, Y: 160},
+		Points:      5,
+		OuterRadius: 28,
+		InnerRadius: 12,
+		Style:       &photoshop.Style{Fill: color.RGBA{R: 0xf0, G: 0xc0, B: 0x20, A: 0xff}, Stroke: color.Black, StrokeWidth: 1},
+	}
+
+	ring := &photoshop.Ellipse{
+		Center:  photoshop.Point{X: 40, Y: 160},
+		RadiusX: 28,
+		RadiusY: 20,
+		Style:   &photoshop.Style{Fill: color.RGBA{R: 0x30, G: 0x90, B: 0x50, A: 0xff}},
+	}
+	hole := &photoshop.Rectangle{Rect: photoshop.Rect{
+		Location: photoshop.Point{X: 28, Y: 152},
+		Size:     photoshop.Size{Width: 24, Height: 16},
+	}}
+	cutout := photoshop.Combine(photoshop.BooleanSubtract, ring, hole)
+
+	title := &photoshop.Text{
+		Text:   "PS",
+		Origin: photoshop.Point{X: 10, Y: 10},
+		Font:   photoshop.DefaultFont,
+		Style:  &photoshop.Style{Fill: color.Black},
+	}
+
+	document.Add(photoshop.NewLayer("Vector", star, cutout, title))
+}
+
+// saveAndLoad saves the document to the file and loads it back
+func saveAndLoad(path string, doc *photoshop.Document) (*photoshop.Document, error) {
+	err := save(path, func(w io.Writer) error {
+		if strings.HasSuffix(path, ".json") {
+			return doc.WriteJSON(w)
+		}
+		return doc.WriteBinary(w)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	loaded, err := photoshop.ReadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Loaded %q from %s\n", loaded.Metadata["title"], path)
+	return loaded, nil
+}
+
+// save creates the file and writes it
+func save(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+// printTree prints the layers and the elements of the tree
+func printTree(layer *photoshop.Layer, depth int) {
+	var flags []string
+	if layer.Hidden {
+		flags = append(flags, "hidden")
+	}
+	if layer.Locked {
+		flags = append(flags, "locked")
+	}
+	if layer.BlendMode != photoshop.BlendNormal {
+		flags = append(flags, layer.BlendMode.String())
+	}
+	if layer.Opacity != 1 {
+		flags = append(flags, fmt.Sprintf("%.0f%%", layer.Opacity*100))
+	}
+
+	fmt.Printf("%s%s %v\n", strings.Repeat("  ", depth), layer.Name, flags)
+	for i := len(layer.Elements) - 1; i >= 0; i-- {
+		if group, ok := layer.Elements[i].(*photoshop.Layer); ok {
+			printTree(group, depth+1)
+			continue
+		}
+		fmt.Printf("%s%T\n", strings.Repeat("  ", depth+1), layer.Elements[i])
+	}
 }
@@ -0,0 +1,196 @@
+package photoshop
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// BlendMode determines how the colors of a layer mix with the colors of
+// the layers below
+type BlendMode uint8
+
+const (
+	// BlendNormal paints the layer over the layers below
+	BlendNormal BlendMode = iota
+	// BlendMultiply multiplies the colors, darkening the layers below
+	BlendMultiply
+	// BlendScreen inverts, multiplies and inverts back, lightening the
+	// layers below
+	BlendScreen
+	// BlendOverlay multiplies the dark and screens the light colors below
+	BlendOverlay
+	// BlendDarken keeps the darker color
+	BlendDarken
+	// BlendLighten keeps the lighter color
+	BlendLighten
+)
+
+var blendModeNames = [...]string{"normal", "multiply", "screen", "overlay", "darken", "lighten"}
+
+// String returns the name of the blend mode
+func (m BlendMode) String() string {
+	if int(m) < len(blendModeNames) {
+		return blendModeNames[m]
+	}
+	return fmt.Sprintf("BlendMode(%d)", m)
+}
+
+// blend mixes a backdrop and a source color channel, both not premultiplied
+func (m BlendMode) blend(backdrop, source float64) float64 {
+	switch m {
+	case BlendMultiply:
+		return backdrop * source
+	case BlendScreen:
+		return backdrop + source - backdrop*source
+	case BlendOverlay:
+		if backdrop <= 0.5 {
+			return 2 * backdrop * source
+		}
+		return 1 - 2*(1-backdrop)*(1-source)
+	case BlendDarken:
+		return math.Min(backdrop, source)
+	case BlendLighten:
+		return math.Max(backdrop, source)
+	default:
+		return source
+	}
+}
+
+// Operator is a Porter-Duff operator composing a source layer with the
+// destination, the layers below it
+type Operator uint8
+
+const (
+	// SourceOver paints the source over the destination
+	SourceOver Operator = iota
+	// DestinationOver paints the source under the destination
+	DestinationOver
+	// Source replaces the destination with the source
+	Source
+	// Destination keeps the destination and drops the source
+	Destination
+	// Clear drops both the source and the destination
+	Clear
+	// SourceIn keeps the source where the destination is
+	SourceIn
+	// DestinationIn keeps the destination where the source is
+	DestinationIn
+	// SourceOut keeps the source where the destination isn't
+	SourceOut
+	// DestinationOut keeps the destination where the source isn't
+	DestinationOut
+	// SourceAtop paints the source over the destination, where the
+	// destination is
+	SourceAtop
+	// DestinationAtop paints the destination over the source, where the
+	// source is
+	DestinationAtop
+	// Xor keeps the source and the destination where they don't overlap
+	Xor
+)
+
+var operatorNames = [...]string{
+	"source-over", "destination-over", "source", "destination", "clear", "source-in",
+	"destination-in", "source-out", "destination-out", "source-atop", "destination-atop", "xor",
+}
+
+// String returns the name of the operator
+func (op Operator) String() string {
+	if int(op) < len(operatorNames) {
+		return operatorNames[op]
+	}
+	return fmt.Sprintf("Operator(%d)", op)
+}
+
+// fractions returns the fractions of the source and of the destination
+// the operator keeps, given their alphas
+func (op Operator) fractions(as, ab float64) (float64, float64) {
+	switch op {
+	case DestinationOver:
+		return 1 - ab, 1
+	case Source:
+		return 1, 0
+	case Destination:
+		return 0, 1
+	case Clear:
+		return 0, 0
+	case SourceIn:
+		return ab, 0
+	case DestinationIn:
+		return 0, as
+	case SourceOut:
+		return 1 - ab, 0
+	case DestinationOut:
+		return 0, 1 - as
+	case SourceAtop:
+		return ab, 1 - as
+	case DestinationAtop:
+		return 1 - ab, as
+	case Xor:
+		return 1 - ab, 1 - as
+	default:
+		return 1, 1 - as
+	}
+}
+
+// Render paints the element on a transparent image of the size
+func Render(element VisualElement, width, height int) (*image.RGBA, error) {
+	d := NewRaster(width, height)
+	if err := element.Draw(d); err != nil {
+		return nil, err
+	}
+	return d.Image, nil
+}
+
+// RenderPNG paints the element on a transparent image of the size and
+// encodes it as PNG
+func RenderPNG(w io.Writer, element VisualElement, width, height int) error {
+	img, err := Render(element, width, height)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// opacity returns the opacity of the layer within 0 and 1
+func (layer *Layer) opacity() float64 {
+	return math.Max(0, math.Min(1, layer.Opacity))
+}
+
+// compose composes the source scaled by the opacity with the destination,
+// blending the colors where both are
+func compose(dst, src *image.RGBA, op Operator, mode BlendMode, opacity float64) {
+	bounds := dst.Bounds().Intersect(src.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			d := dst.Pix[dst.PixOffset(x, y):][:4:4]
+			s := src.Pix[src.PixOffset(x, y):][:4:4]
+
+			as := float64(s[3]) / 0xff * opacity
+			ab := float64(d[3]) / 0xff
+			fs, fb := op.fractions(as, ab)
+
+			var out [4]float64
+			for ch := 0; ch < 3; ch++ {
+				var cs, cb float64
+				if s[3] > 0 {
+					cs = float64(s[ch]) / float64(s[3])
+				}
+				if d[3] > 0 {
+					cb = float64(d[ch]) / float64(d[3])
+				}
+
+				mixed := (1-ab)*cs + ab*mode.blend(cb, cs)
+				out[ch] = fs*as*mixed + fb*ab*cb
+			}
+			out[3] = fs*as + fb*ab
+
+			for ch := range d {
+				d[ch] = uint8(math.Max(0, math.Min(1, out[ch]))*0xff + 0.5)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package photoshop
+
+import (
+	"image/color"
+	"testing"
+)
+
+var (
+	red         = color.RGBA{R: 0xff, A: 0xff}
+	green       = color.RGBA{G: 0xff, A: 0xff}
+	transparent = color.RGBA{}
+)
+
+// overlappingSquares returns a document of a green square over a red one,
+// overlapping from 5 to 10 on a 20x10 canvas, and the layer of the green one
+func overlappingSquares() (*Layer, *Layer) {
+	bottom := NewLayer("Bottom", &Square{Side: 10, Style: &Style{Fill: red}})
+	top := NewLayer("Top", &Square{Location: Point{X: 5}, Side: 10, Style: &Style{Fill: green}})
+	return NewLayer("Document", bottom, top), top
+}
+
+func TestRenderOperators(t *testing.T) {
+	tests := []struct {
+		operator                 Operator
+		bottom, overlap, topOnly color.RGBA
+	}{
+		{SourceOver, red, green, green},
+		{DestinationOver, red, red, green},
+		{Source, transparent, green, green},
+		{Destination, red, red, transparent},
+		{Clear, transparent, transparent, transparent},
+		{SourceIn, transparent, green, transparent},
+		{DestinationIn, transparent, red, transparent},
+		{SourceOut, transparent, transparent, green},
+		{DestinationOut, red, transparent, transparent},
+		{SourceAtop, red, green, transparent},
+		{DestinationAtop, transparent, red, green},
+		{Xor, red, transparent, green},
+	}
+
+	for _, test := range tests {
+		t.Run(test.operator.String(), func(t *testing.T) {
+			document, top := overlappingSquares()
+			top.Operator = test.operator
+
+			img, err := Render(document, 20, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for x, want := range map[int]color.RGBA{2: test.bottom, 7: test.overlap, 12: test.topOnly} {
+				if got := img.RGBAAt(x, 5); got != want {
+					t.Errorf("the pixel at %d is %v, want %v", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderBlendModes(t *testing.T) {
+	tests := []struct {
+		mode             BlendMode
+		opacity          float64
+		overlap, topOnly color.RGBA
+	}{
+		{BlendNormal, 1, green, green},
+		{BlendScreen, 1, color.RGBA{R: 0xff, G: 0xff, A: 0xff}, green},
+		{BlendMultiply, 1, color.RGBA{A: 0xff}, green},
+		{BlendLighten, 1, color.RGBA{R: 0xff, G: 0xff, A: 0xff}, green},
+		{BlendDarken, 1, color.RGBA{A: 0xff}, green},
+		{BlendMultiply, 0.5, color.RGBA{R: 0x80, A: 0xff}, color.RGBA{G: 0x80, A: 0x80}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			document, top := overlappingSquares()
+			top.BlendMode = test.mode
+			top.Opacity = test.opacity
+
+			img, err := Render(document, 20, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := img.RGBAAt(7, 5); got != test.overlap {
+				t.Errorf("the overlap is %v, want %v", got, test.overlap)
+			}
+			if got := img.RGBAAt(12, 5); got != test.topOnly {
+				t.Errorf("the top square is %v, want %v", got, test.topOnly)
+			}
+		})
+	}
+}
+
+func TestRenderStroke(t *testing.T) {
+	ring := &Circle{Center: Point{X: 5, Y: 5}, Radius: 4.5, Style: &Style{Stroke: color.Black, StrokeWidth: 1}}
+
+	img, err := Render(ring, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inside := img.RGBAAt(5, 5); inside.A != 0 {
+		t.Errorf("the inside of the ring is %v", inside)
+	}
+	if edge := img.RGBAAt(5, 0); edge.A < 100 {
+		t.Errorf("the edge of the ring is %v", edge)
+	}
+}
@@ -0,0 +1,258 @@
+package photoshop
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DocumentVersion is the version of the documents written
+const DocumentVersion = 4
+
+// documentFormat names the format in the documents
+const documentFormat = "photoshop"
+
+// Document is a tree of layers with its canvas and metadata, saved as JSON
+// or in a compact binary form. Both forms are versioned; the documents of
+// older versions are migrated when loaded.
+type Document struct {
+	// Width of the canvas
+	Width float64 `json:"width"`
+	// Height of the canvas
+	Height float64 `json:"height"`
+	// Metadata such as the title or the author
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Root layer of the tree
+	Root *Layer `json:"root"`
+}
+
+// migration upgrades the decoded tree of a document to the next version
+type migration func(doc map[string]interface{}) error
+
+// migrations upgrade the documents by the version they upgrade from
+var migrations = map[int]migration{
+	1: migrateElementsToRoot,
+	2: migrateUnfiltered,
+	3: migrateOpaqueZero,
+}
+
+// NewDocument creates a document of a canvas of the size with an empty root
+// layer
+func NewDocument(width, height float64) *Document {
+	return &Document{Width: width, Height: height, Root: NewLayer("Document")}
+}
+
+// Draw draws the root layer of the document
+func (doc *Document) Draw(drawer Drawer) error {
+	if doc.Root == nil {
+		return nil
+	}
+	return doc.Root.Draw(drawer)
+}
+
+// WriteJSON writes the document as indented JSON
+func (doc *Document) WriteJSON(w io.Writer) error {
+	tree, err := doc.tree()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree)
+}
+
+// WriteBinary writes the document in the compact binary form
+func (doc *Document) WriteBinary(w io.Writer) error {
+	tree, err := doc.tree()
+	if err != nil {
+		return err
+	}
+	return writeBinaryTree(w, tree)
+}
+
+// ReadDocument reads a document written by WriteJSON or WriteBinary,
+// telling the forms by their first bytes
+func ReadDocument(r io.Reader) (*Document, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(binaryMagic))
+	if err == nil && string(magic) == binaryMagic {
+		return ReadBinaryDocument(buffered)
+	}
+	return ReadJSONDocument(buffered)
+}
+
+// ReadJSONDocument reads a document written by WriteJSON
+func ReadJSONDocument(r io.Reader) (*Document, error) {
+	var tree map[string]interface{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return documentOf(tree)
+}
+
+// ReadBinaryDocument reads a document written by WriteBinary
+func ReadBinaryDocument(r io.Reader) (*Document, error) {
+	value, err := readBinaryTree(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("The document is not an object")
+	}
+	return documentOf(tree)
+}
+
+// tree returns the document as decoded JSON with its format and version
+func (doc *Document) tree() (map[string]interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	tree["format"] = documentFormat
+	tree["version"] = DocumentVersion
+	return tree, nil
+}
+
+// documentOf migrates the decoded tree of a document to the current
+// version and decodes the document
+func documentOf(tree map[string]interface{}) (*Document, error) {
+	if format, ok := tree["format"]; ok && format != documentFormat {
+		return nil, fmt.Errorf("Unknown document format %v", format)
+	}
+
+	version, err := documentVersion(tree)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > DocumentVersion {
+		return nil, fmt.Errorf("Unsupported document version %d", version)
+	}
+
+	for ; version < DocumentVersion; version++ {
+		if err := migrations[version](tree); err != nil {
+			return nil, fmt.Errorf("Migrating the document from version %d: %v", version, err)
+		}
+	}
+	delete(tree, "format")
+	delete(tree, "version")
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Root == nil {
+		return nil, errors.New("The document has no root layer")
+	}
+	return doc, nil
+}
+
+// documentVersion returns the version of the decoded tree of a document
+func documentVersion(tree map[string]interface{}) (int, error) {
+	switch version := tree["version"].(type) {
+	case json.Number:
+		v, err := version.Int64()
+		return int(v), err
+	case int64:
+		return int(version), nil
+	default:
+		return 0, errors.New("The document has no version")
+	}
+}
+
+// migrateElementsToRoot upgrades version 1 documents, from before layers
+// nested, whose elements were those of a single layer
+func migrateElementsToRoot(doc map[string]interface{}) error {
+	elements, ok := doc["elements"].([]interface{})
+	if !ok && doc["elements"] != nil {
+		return errors.New("The elements are not a list")
+	}
+	if elements == nil {
+		elements = []interface{}{}
+	}
+
+	delete(doc, "elements")
+	doc["root"] = map[string]interface{}{
+		"name":       "Document",
+		"opacity":    1,
+		"blend_mode": BlendNormal.String(),
+		"operator":   SourceOver.String(),
+		"elements":   elements,
+	}
+	return nil
+}
+
+// migrateUnfiltered upgrades version 2 documents, from before the layers
+// had filters, which are valid as they are
+func migrateUnfiltered(doc map[string]interface{}) error {
+	return nil
+}
+
+// migrateOpaqueZero upgrades version 3 documents, whose layers and drop
+// shadows were opaque at a zero opacity
+func migrateOpaqueZero(doc map[string]interface{}) error {
+	if root, ok := doc["root"].(map[string]interface{}); ok {
+		opaqueZero(root)
+	}
+	return nil
+}
+
+// opaqueZero makes the layers of the decoded tree of the layer and their
+// drop shadows opaque where their opacity is zero or missing
+func opaqueZero(layer map[string]interface{}) {
+	if zeroNumber(layer["opacity"]) {
+		layer["opacity"] = 1
+	}
+
+	filters, _ := layer["filters"].([]interface{})
+	for _, filter := range filters {
+		shadow, ok := filter.(map[string]interface{})
+		if ok && shadow["type"] == "drop_shadow" && zeroNumber(shadow["opacity"]) {
+			shadow["opacity"] = 1
+		}
+	}
+
+	elements, _ := layer["elements"].([]interface{})
+	for _, element := range elements {
+		if group, ok := element.(map[string]interface{}); ok && group["type"] == "layer" {
+			opaqueZero(group)
+		}
+	}
+}
+
+// zeroNumber reports whether the decoded value is zero or missing
+func zeroNumber(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	default:
+		return false
+	}
+}
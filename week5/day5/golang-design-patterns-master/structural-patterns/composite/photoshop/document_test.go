@@ -0,0 +1,232 @@
+package photoshop
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleDocument returns a document of a locked background, a group of
+// shapes with its own compositing and filters, and a hidden layer
+func sampleDocument() *Document {
+	doc := NewDocument(40, 30)
+	doc.Metadata = map[string]string{"title": "Sample", "author": "Mike"}
+
+	background := NewLayer("Background", &Square{Side: 40, Style: &Style{Fill: color.NRGBA{R: 10, G: 20, B: 30, A: 0xff}}})
+	background.Locked = true
+
+	shapes := NewLayer("Shapes",
+		&Circle{
+			Center: Point{X: 20, Y: 15},
+			Radius: 10.25,
+			Style:  &Style{Fill: color.NRGBA{R: 200, A: 128}, Stroke: color.NRGBA{A: 0xff}, StrokeWidth: 1.5},
+		},
+		&Star{Center: Point{X: 8, Y: 8}, Points: 5, OuterRadius: 6, InnerRadius: 3},
+		&Text{Origin: Point{X: 2, Y: 20}, Text: "Hello", Font: DefaultFont},
+	)
+	shapes.BlendMode = BlendScreen
+	shapes.Operator = SourceAtop
+	shapes.Opacity = 0.75
+	shapes.Filters = []Filter{
+		&DropShadow{Offset: Point{X: 2, Y: 2}, Blur: 1, Color: color.NRGBA{A: 0xff}, Opacity: 0.5},
+		&HueSaturation{Hue: 10, Saturation: 0.5},
+	}
+
+	hidden := NewLayer("Hidden")
+	hidden.Hidden = true
+
+	doc.Root.Add(background, shapes, hidden)
+	return doc
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(doc *Document, out *bytes.Buffer) error
+		read  func(in *bytes.Buffer) (*Document, error)
+	}{
+		{
+			name:  "json",
+			write: func(doc *Document, out *bytes.Buffer) error { return doc.WriteJSON(out) },
+			read:  func(in *bytes.Buffer) (*Document, error) { return ReadJSONDocument(in) },
+		},
+		{
+			name:  "binary",
+			write: func(doc *Document, out *bytes.Buffer) error { return doc.WriteBinary(out) },
+			read:  func(in *bytes.Buffer) (*Document, error) { return ReadBinaryDocument(in) },
+		},
+		{
+			name:  "detected json",
+			write: func(doc *Document, out *bytes.Buffer) error { return doc.WriteJSON(out) },
+			read:  func(in *bytes.Buffer) (*Document, error) { return ReadDocument(in) },
+		},
+		{
+			name:  "detected binary",
+			write: func(doc *Document, out *bytes.Buffer) error { return doc.WriteBinary(out) },
+			read:  func(in *bytes.Buffer) (*Document, error) { return ReadDocument(in) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := sampleDocument()
+
+			var data bytes.Buffer
+			if err := test.write(doc, &data); err != nil {
+				t.Fatal(err)
+			}
+			read, err := test.read(&data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, doc) {
+				t.Errorf("read %+v, want %+v", read.Root, doc.Root)
+			}
+		})
+	}
+}
+
+func TestStyleColorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		color  color.Color
+		digits int
+	}{
+		{"8-bit", color.NRGBA{R: 200, A: 128}, 8},
+		{"8-bit premultiplied", color.RGBA{R: 100, A: 128}, 16},
+		{"opaque 16-bit", color.RGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0xffff}, 16},
+		{"translucent 16-bit", color.NRGBA64{R: 0x1234, G: 0xffff, A: 0x8001}, 16},
+		{"premultiplied 16-bit", color.RGBA64{R: 0x1001, G: 0x7fff, A: 0x8001}, 16},
+		{"transparent", color.Transparent, 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if text := formatColor(test.color); len(text) != 1+test.digits {
+				t.Errorf("the color is written as %q, want %d digits", text, test.digits)
+			}
+
+			data, err := json.Marshal(Style{Fill: test.color})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var style Style
+			if err := json.Unmarshal(data, &style); err != nil {
+				t.Fatal(err)
+			}
+			if !sameColor(style.Fill, test.color) {
+				t.Errorf("the color %v is read back as %v from %s", test.color, style.Fill, data)
+			}
+		})
+	}
+}
+
+func TestReadBinaryDocumentTruncated(t *testing.T) {
+	var data bytes.Buffer
+	if err := sampleDocument().WriteBinary(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	for n := 0; n < data.Len(); n++ {
+		if _, err := ReadBinaryDocument(bytes.NewReader(data.Bytes()[:n])); err == nil {
+			t.Fatalf("read %d of %d bytes without an error", n, data.Len())
+		}
+	}
+}
+
+func TestReadDocumentOpacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []float64
+		// shadow is the opacity of the drop shadow of the root
+		shadow float64
+	}{
+		{
+			name:     "version 3 opaque at zero",
+			document: `{"version":3,"root":{"opacity":0,"filters":[{"type":"drop_shadow","opacity":0}],"elements":[{"type":"layer","opacity":0.5,"elements":[{"type":"layer","elements":[]}]}]}}`,
+			want:     []float64{1, 0.5, 1},
+			shadow:   1,
+		},
+		{
+			name:     "transparent at zero",
+			document: `{"version":4,"root":{"opacity":0,"filters":[{"type":"drop_shadow","opacity":0}],"elements":[{"type":"layer","opacity":0.5,"elements":[{"type":"layer","elements":[]}]}]}}`,
+			want:     []float64{0, 0.5, 1},
+		},
+		{
+			name:     "opaque when missing",
+			document: `{"version":4,"root":{"filters":[{"type":"drop_shadow"}],"elements":[]}}`,
+			want:     []float64{1},
+			shadow:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := ReadDocument(strings.NewReader(test.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []float64
+			for layer := doc.Root; layer != nil; {
+				got = append(got, layer.Opacity)
+				if len(layer.Elements) == 0 {
+					break
+				}
+				layer = layer.Elements[0].(*Layer)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("the opacities are %v, want %v", got, test.want)
+			}
+			if shadow := doc.Root.Filters[0].(*DropShadow); shadow.Opacity != test.shadow {
+				t.Errorf("the shadow opacity is %v, want %v", shadow.Opacity, test.shadow)
+			}
+		})
+	}
+}
+
+func TestReadDocumentVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		elements int
+		err      string
+	}{
+		{
+			name:     "version 1",
+			document: `{"version":1,"width":10,"height":10,"elements":[{"type":"square","side":5}]}`,
+			elements: 1,
+		},
+		{
+			name:     "version 2",
+			document: `{"version":2,"width":10,"height":10,"root":{"name":"Document","opacity":1,"elements":[{"type":"circle","radius":2}]}}`,
+			elements: 1,
+		},
+		{name: "future version", document: `{"version":5,"root":{}}`, err: "version"},
+		{name: "no version", document: `{"root":{}}`, err: "version"},
+		{name: "unknown element", document: `{"version":3,"root":{"elements":[{"type":"blob"}]}}`, err: "blob"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := ReadDocument(strings.NewReader(test.document))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("error is %v, want one about %s", err, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Root.Name != "Document" || len(doc.Root.Elements) != test.elements {
+				t.Errorf("read the root %+v", doc.Root)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package photoshop
+
+// AddElement edit puts an element in a layer
+type AddElement struct {
+	// Layer the element is put in
+	Layer *Layer
+	// Element put in the layer
+	Element VisualElement
+	// Index of the element, 0 being the bottom
+	Index int
+}
+
+// NewAddElement creates an edit putting the element on top of the layer
+func NewAddElement(layer *Layer, element VisualElement) *AddElement {
+	return &AddElement{Layer: layer, Element: element, Index: len(layer.Elements)}
+}
+
+// Do puts the element in the layer
+func (edit *AddElement) Do() error {
+	return edit.Layer.Insert(edit.Index, edit.Element)
+}
+
+// Undo takes the element off the layer
+func (edit *AddElement) Undo() error {
+	return edit.Layer.Remove(edit.Element)
+}
+
+// RemoveElement edit takes an element off a layer
+type RemoveElement struct {
+	// Layer the element is taken off
+	Layer *Layer
+	// Element taken off the layer
+	Element VisualElement
+
+	index int
+}
+
+// Do takes the element off the layer
+func (edit *RemoveElement) Do() error {
+	edit.index = edit.Layer.IndexOf(edit.Element)
+	return edit.Layer.Remove(edit.Element)
+}
+
+// Undo puts the element back where it was
+func (edit *RemoveElement) Undo() error {
+	return edit.Layer.Insert(edit.index, edit.Element)
+}
+
+// MoveElement edit moves an element by an offset. The moves of the same
+// element merge.
+type MoveElement struct {
+	// Element moved
+	Element Movable
+	// Offset of the move
+	Offset Point
+}
+
+// Do moves the element by the offset
+func (edit *MoveElement) Do() error {
+	edit.Element.MoveBy(edit.Offset)
+	return nil
+}
+
+// Undo moves the element back
+func (edit *MoveElement) Undo() error {
+	edit.Element.MoveBy(Point{X: -edit.Offset.X, Y: -edit.Offset.Y})
+	return nil
+}
+
+// Merge adds the offset of the next move of the element
+func (edit *MoveElement) Merge(next Edit) bool {
+	if !edit.merges(next) {
+		return false
+	}
+	move := next.(*MoveElement)
+	edit.Offset.X += move.Offset.X
+	edit.Offset.Y += move.Offset.Y
+	return true
+}
+
+func (edit *MoveElement) merges(next Edit) bool {
+	move, ok := next.(*MoveElement)
+	return ok && move.Element == edit.Element
+}
+
+// ResizeElement edit resizes an element. The resizes of the same element
+// merge.
+type ResizeElement struct {
+	// Element resized
+	Element Resizable
+	// Size the element is resized to
+	Size Size
+
+	previous Size
+}
+
+// Do resizes the element
+func (edit *ResizeElement) Do() error {
+	edit.previous = edit.Element.Size()
+	edit.Element.Resize(edit.Size)
+	return nil
+}
+
+// Undo resizes the element back
+func (edit *ResizeElement) Undo() error {
+	edit.Element.Resize(edit.previous)
+	return nil
+}
+
+// Merge takes the size of the next resize of the element, keeping the size
+// before this one
+func (edit *ResizeElement) Merge(next Edit) bool {
+	if !edit.merges(next) {
+		return false
+	}
+	resize := next.(*ResizeElement)
+	edit.Size = resize.Size
+	return true
+}
+
+func (edit *ResizeElement) merges(next Edit) bool {
+	resize, ok := next.(*ResizeElement)
+	return ok && resize.Element == edit.Element
+}
+
+// ChangeStyle edit sets the style of an element. The changes of the style
+// of the same element merge.
+type ChangeStyle struct {
+	// Element styled
+	Element Styled
+	// Style set, nil for the default style
+	Style *Style
+
+	previous *Style
+}
+
+// Do sets the style of the element
+func (edit *ChangeStyle) Do() error {
+	edit.previous = edit.Element.CurrentStyle()
+	edit.Element.SetStyle(edit.Style)
+	return nil
+}
+
+// Undo sets the style the element had
+func (edit *ChangeStyle) Undo() error {
+	edit.Element.SetStyle(edit.previous)
+	return nil
+}
+
+// Merge takes the style of the next change of the style of the element,
+// keeping the style before this one
+func (edit *ChangeStyle) Merge(next Edit) bool {
+	if !edit.merges(next) {
+		return false
+	}
+	change := next.(*ChangeStyle)
+	edit.Style = change.Style
+	return true
+}
+
+func (edit *ChangeStyle) merges(next Edit) bool {
+	change, ok := next.(*ChangeStyle)
+	return ok && change.Element == edit.Element
+}
+
+// ReorderElement edit moves an element of a layer to another index, such
+// as a layer of a group to the front
+type ReorderElement struct {
+	// Layer of the element
+	Layer *Layer
+	// Element reordered
+	Element VisualElement
+	// Index the element is moved to, 0 being the bottom
+	Index int
+
+	previous int
+}
+
+// Do moves the element to the index
+func (edit *ReorderElement) Do() error {
+	edit.previous = edit.Layer.IndexOf(edit.Element)
+	return edit.Layer.reorder(edit.Element, func(int) int { return edit.Index })
+}
+
+// Undo moves the element back to its index
+func (edit *ReorderElement) Undo() error {
+	return edit.Layer.reorder(edit.Element, func(int) int { return edit.previous })
+}
+
+// mergeable edits tell whether they merge the next edit without merging it
+type mergeable interface {
+	Merger
+	merges(next Edit) bool
+}
+
+// EditGroup edit does its edits as one, such as the moves of an alignment.
+// The groups of the same edits of the same elements merge edit by edit,
+// such as the moves of the elements of a selection dragged.
+type EditGroup []Edit
+
+// Do does the edits in order. When one fails, those done are undone.
+func (group EditGroup) Do() error {
+	for i, edit := range group {
+		if err := edit.Do(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				group[j].Undo()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Undo undoes the edits in reverse order
+func (group EditGroup) Undo() error {
+	for i := len(group) - 1; i >= 0; i-- {
+		if err := group[i].Undo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge merges the edits of the next group into the edits of this one,
+// when each of them merges
+func (group EditGroup) Merge(next Edit) bool {
+	if !group.merges(next) {
+		return false
+	}
+	for i, edit := range next.(EditGroup) {
+		group[i].(Merger).Merge(edit)
+	}
+	return true
+}
+
+func (group EditGroup) merges(next Edit) bool {
+	other, ok := next.(EditGroup)
+	if !ok || len(other) != len(group) || len(group) == 0 {
+		return false
+	}
+	for i, edit := range group {
+		if merger, ok := edit.(mergeable); !ok || !merger.merges(other[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,228 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+)
+
+// jsonStyle is the JSON encoding of a style
+type jsonStyle struct {
+	Fill        string  `json:"fill,omitempty"`
+	Stroke      string  `json:"stroke,omitempty"`
+	StrokeWidth float64 `json:"stroke_width,omitempty"`
+}
+
+// jsonLayer is the JSON encoding of a layer
+type jsonLayer struct {
+	Name      string            `json:"name,omitempty"`
+	Hidden    bool              `json:"hidden,omitempty"`
+	Opacity   float64           `json:"opacity"`
+	BlendMode BlendMode         `json:"blend_mode"`
+	Operator  Operator          `json:"operator"`
+	Locked    bool              `json:"locked,omitempty"`
+	Filters   []json.RawMessage `json:"filters,omitempty"`
+	Elements  []json.RawMessage `json:"elements"`
+}
+
+// MarshalJSON encodes the colors of the style as #rrggbbaa
+func (style Style) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonStyle{
+		Fill:        formatColor(style.Fill),
+		Stroke:      formatColor(style.Stroke),
+		StrokeWidth: style.StrokeWidth,
+	})
+}
+
+// UnmarshalJSON decodes a style encoded by MarshalJSON
+func (style *Style) UnmarshalJSON(data []byte) error {
+	var in jsonStyle
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	fill, err := parseColor(in.Fill)
+	if err != nil {
+		return err
+	}
+	stroke, err := parseColor(in.Stroke)
+	if err != nil {
+		return err
+	}
+
+	*style = Style{Fill: fill, Stroke: stroke, StrokeWidth: in.StrokeWidth}
+	return nil
+}
+
+// MarshalJSON encodes the layer, its filters and its elements, each with
+// its kind
+func (layer *Layer) MarshalJSON() ([]byte, error) {
+	out := jsonLayer{
+		Name:      layer.Name,
+		Hidden:    layer.Hidden,
+		Opacity:   layer.Opacity,
+		BlendMode: layer.BlendMode,
+		Operator:  layer.Operator,
+		Locked:    layer.Locked,
+		Elements:  make([]json.RawMessage, len(layer.Elements)),
+	}
+
+	for _, filter := range layer.Filters {
+		data, err := encodeFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		out.Filters = append(out.Filters, data)
+	}
+	for i, element := range layer.Elements {
+		data, err := encodeElement(element)
+		if err != nil {
+			return nil, err
+		}
+		out.Elements[i] = data
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a layer encoded by MarshalJSON. The layer is opaque
+// when the opacity is missing.
+func (layer *Layer) UnmarshalJSON(data []byte) error {
+	in := jsonLayer{Opacity: 1}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	var filters []Filter
+	for _, data := range in.Filters {
+		filter, err := decodeFilter(data)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+
+	var elements []VisualElement
+	for _, data := range in.Elements {
+		element, err := decodeElement(data)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, element)
+	}
+
+	*layer = Layer{
+		Name:      in.Name,
+		Elements:  elements,
+		Hidden:    in.Hidden,
+		Opacity:   in.Opacity,
+		BlendMode: in.BlendMode,
+		Operator:  in.Operator,
+		Filters:   filters,
+		Locked:    in.Locked,
+	}
+	return nil
+}
+
+// MarshalText encodes the blend mode as its name
+func (m BlendMode) MarshalText() ([]byte, error) {
+	if int(m) >= len(blendModeNames) {
+		return nil, fmt.Errorf("Unknown blend mode %d", m)
+	}
+	return []byte(blendModeNames[m]), nil
+}
+
+// UnmarshalText decodes the blend mode of the name
+func (m *BlendMode) UnmarshalText(text []byte) error {
+	for i, name := range blendModeNames {
+		if name == string(text) {
+			*m = BlendMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown blend mode %q", text)
+}
+
+// MarshalText encodes the operator as its name
+func (op Operator) MarshalText() ([]byte, error) {
+	if int(op) >= len(operatorNames) {
+		return nil, fmt.Errorf("Unknown operator %d", op)
+	}
+	return []byte(operatorNames[op]), nil
+}
+
+// UnmarshalText decodes the operator of the name
+func (op *Operator) UnmarshalText(text []byte) error {
+	for i, name := range operatorNames {
+		if name == string(text) {
+			*op = Operator(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown operator %q", text)
+}
+
+// formatColor returns the #rrggbbaa notation of the color, or the
+// #rrrrggggbbbbaaaa notation of its 16-bit channels when 8 bits lose
+// precision, empty for none
+func formatColor(c color.Color) string {
+	if c == nil {
+		return ""
+	}
+
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if sameColor(n, c) {
+		return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+	}
+
+	// the channels are rounded up so that premultiplying them gives back
+	// those of the color
+	r, g, b, a := c.RGBA()
+	unpremultiply := func(v uint32) uint32 {
+		if a == 0 {
+			return 0
+		}
+		if v >= a {
+			return 0xffff
+		}
+		return (v*0xffff + a - 1) / a
+	}
+	return fmt.Sprintf("#%04x%04x%04x%04x", unpremultiply(r), unpremultiply(g), unpremultiply(b), a)
+}
+
+// parseColor returns the color of the #rrggbbaa, #rrggbb or
+// #rrrrggggbbbbaaaa notation, nil for none
+func parseColor(s string) (color.Color, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	switch len(s) {
+	case 7:
+		n := color.NRGBA{A: 0xff}
+		if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &n.R, &n.G, &n.B); err != nil {
+			return nil, fmt.Errorf("Invalid color %q", s)
+		}
+		return n, nil
+	case 9:
+		var n color.NRGBA
+		if _, err := fmt.Sscanf(s, "#%02x%02x%02x%02x", &n.R, &n.G, &n.B, &n.A); err != nil {
+			return nil, fmt.Errorf("Invalid color %q", s)
+		}
+		return n, nil
+	case 17:
+		var n color.NRGBA64
+		if _, err := fmt.Sscanf(s, "#%04x%04x%04x%04x", &n.R, &n.G, &n.B, &n.A); err != nil {
+			return nil, fmt.Errorf("Invalid color %q", s)
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("Invalid color %q", s)
+	}
+}
+
+// sameColor reports whether the colors premultiply to the same channels
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
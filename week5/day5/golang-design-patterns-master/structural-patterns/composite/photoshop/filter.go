@@ -0,0 +1,133 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// tileSize is the side of the square tiles the filters process in parallel
+const tileSize = 128
+
+// Filter processes the pixels of a layer before it is composed with the
+// layers below. Filters don't change the elements of the layer, they are
+// applied every time it is drawn.
+type Filter interface {
+	// Apply returns the filtered image, leaving the source untouched
+	Apply(src *image.RGBA) *image.RGBA
+}
+
+// filterKinds registers the kinds of the filters
+var filterKinds = newRegistry("filter")
+
+func init() {
+	RegisterFilter("gaussian_blur", func() Filter { return &GaussianBlur{} })
+	RegisterFilter("sharpen", func() Filter { return &Sharpen{} })
+	RegisterFilter("brightness_contrast", func() Filter { return &BrightnessContrast{} })
+	RegisterFilter("hue_saturation", func() Filter { return &HueSaturation{} })
+	RegisterFilter("grayscale", func() Filter { return &Grayscale{} })
+	RegisterFilter("drop_shadow", func() Filter { return &DropShadow{} })
+}
+
+// RegisterFilter registers the kind of the filters the factory makes, so
+// documents encode and decode them as they do the elements
+func RegisterFilter(kind string, factory func() Filter) {
+	filterKinds.register(kind, func() interface{} { return factory() })
+}
+
+// encodeFilter returns the JSON encoding of the filter with its kind
+func encodeFilter(filter Filter) (json.RawMessage, error) {
+	return filterKinds.encode(filter)
+}
+
+// decodeFilter returns the filter of the JSON encoding, made by the factory
+// of its kind
+func decodeFilter(data json.RawMessage) (Filter, error) {
+	value, err := filterKinds.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return value.(Filter), nil
+}
+
+// applyFilters returns the image filtered by the filters in order
+func applyFilters(img *image.RGBA, filters []Filter) *image.RGBA {
+	for _, filter := range filters {
+		img = filter.Apply(img)
+	}
+	return img
+}
+
+// forEachTile calls fn for every tile of the rectangle, in parallel when
+// there are several
+func forEachTile(r image.Rectangle, fn func(tile image.Rectangle)) {
+	var tiles []image.Rectangle
+	for y := r.Min.Y; y < r.Max.Y; y += tileSize {
+		for x := r.Min.X; x < r.Max.X; x += tileSize {
+			tiles = append(tiles, image.Rect(x, y, x+tileSize, y+tileSize).Intersect(r))
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(tiles) {
+		workers = len(tiles)
+	}
+	if workers <= 1 {
+		for _, tile := range tiles {
+			fn(tile)
+		}
+		return
+	}
+
+	queue := make(chan image.Rectangle)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for tile := range queue {
+				fn(tile)
+			}
+		}()
+	}
+	for _, tile := range tiles {
+		queue <- tile
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// mapColors returns the image of the colors of the pixels mapped by fn, not
+// premultiplied within 0 and 1. The alpha is kept; the transparent pixels
+// stay.
+func mapColors(src *image.RGBA, fn func(r, g, b float64) (float64, float64, float64)) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	forEachTile(src.Bounds(), func(tile image.Rectangle) {
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				s := src.Pix[src.PixOffset(x, y):][:4:4]
+				d := dst.Pix[dst.PixOffset(x, y):][:4:4]
+				if s[3] == 0 {
+					continue
+				}
+
+				a := float64(s[3]) / 0xff
+				r, g, b := fn(float64(s[0])/0xff/a, float64(s[1])/0xff/a, float64(s[2])/0xff/a)
+				d[0], d[1], d[2], d[3] = unit(clamp(r)*a), unit(clamp(g)*a), unit(clamp(b)*a), s[3]
+			}
+		}
+	})
+	return dst
+}
+
+// unit returns the byte of the value within 0 and 1
+func unit(v float64) uint8 {
+	return uint8(clamp(v)*0xff + 0.5)
+}
+
+// clamp returns the value within 0 and 1
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
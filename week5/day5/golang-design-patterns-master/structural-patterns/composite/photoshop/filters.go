@@ -0,0 +1,376 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// maxBlurRadius is the largest standard deviation blurred, the larger ones
+// blur as much
+const maxBlurRadius = 250
+
+// GaussianBlur filter blurs the layer
+type GaussianBlur struct {
+	// Radius is the standard deviation of the blur in pixels, up to 250
+	Radius float64 `json:"radius"`
+}
+
+// jsonGaussianBlur is the JSON encoding of a blur
+type jsonGaussianBlur GaussianBlur
+
+// UnmarshalJSON decodes a blur, clamping its radius
+func (f *GaussianBlur) UnmarshalJSON(data []byte) error {
+	var in jsonGaussianBlur
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	radius, err := decodeBlurRadius(in.Radius)
+	if err != nil {
+		return err
+	}
+	*f = GaussianBlur{Radius: radius}
+	return nil
+}
+
+// Apply returns the blurred image. The pixels beyond the image are
+// transparent.
+func (f *GaussianBlur) Apply(src *image.RGBA) *image.RGBA {
+	kernel := gaussianKernel(f.Radius)
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	if len(kernel) == 1 {
+		copy(dst.Pix, src.Pix)
+		return dst
+	}
+
+	half := len(kernel) / 2
+	width := bounds.Dx()
+	rows := make([]float32, 4*width*bounds.Dy())
+	index := func(x, y int) int {
+		return 4 * ((y-bounds.Min.Y)*width + x - bounds.Min.X)
+	}
+
+	forEachTile(bounds, func(tile image.Rectangle) {
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				sum := rows[index(x, y):][:4:4]
+				for k, weight := range kernel {
+					if sx := x + k - half; sx >= bounds.Min.X && sx < bounds.Max.X {
+						p := src.Pix[src.PixOffset(sx, y):][:4:4]
+						for ch := range sum {
+							sum[ch] += weight * float32(p[ch])
+						}
+					}
+				}
+			}
+		}
+	})
+
+	forEachTile(bounds, func(tile image.Rectangle) {
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				var sum [4]float32
+				for k, weight := range kernel {
+					if sy := y + k - half; sy >= bounds.Min.Y && sy < bounds.Max.Y {
+						p := rows[index(x, sy):][:4:4]
+						for ch := range sum {
+							sum[ch] += weight * p[ch]
+						}
+					}
+				}
+
+				d := dst.Pix[dst.PixOffset(x, y):][:4:4]
+				for ch := range d {
+					d[ch] = uint8(math.Max(0, math.Min(0xff, float64(sum[ch])+0.5)))
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// gaussianKernel returns the normalized weights of the Gaussian of the
+// standard deviation, up to three deviations from the center. The deviation
+// is clamped by blurRadius.
+func gaussianKernel(sigma float64) []float32 {
+	sigma = blurRadius(sigma)
+	if sigma == 0 {
+		return []float32{1}
+	}
+
+	half := int(math.Ceil(3 * sigma))
+	weights := make([]float64, 2*half+1)
+	total := 0.0
+	for i := range weights {
+		d := float64(i - half)
+		weights[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		total += weights[i]
+	}
+
+	kernel := make([]float32, len(weights))
+	for i, w := range weights {
+		kernel[i] = float32(w / total)
+	}
+	return kernel
+}
+
+// blurRadius returns the radius within 0 and maxBlurRadius, 0 when it is
+// not a number
+func blurRadius(radius float64) float64 {
+	if math.IsNaN(radius) {
+		return 0
+	}
+	return math.Max(0, math.Min(maxBlurRadius, radius))
+}
+
+// decodeBlurRadius returns the decoded radius clamped by blurRadius,
+// rejecting infinite radii and those which aren't numbers
+func decodeBlurRadius(radius float64) (float64, error) {
+	if math.IsNaN(radius) || math.IsInf(radius, 0) {
+		return 0, fmt.Errorf("Invalid blur radius %v", radius)
+	}
+	return blurRadius(radius), nil
+}
+
+// Sharpen filter sharpens the layer by an unsharp mask: the difference of
+// the layer and of its blur is added to it
+type Sharpen struct {
+	// Radius of the blur, the standard deviation in pixels up to 250
+	Radius float64 `json:"radius"`
+	// Amount of the difference added, 1 doubling it
+	Amount float64 `json:"amount"`
+}
+
+// jsonSharpen is the JSON encoding of a sharpening
+type jsonSharpen Sharpen
+
+// UnmarshalJSON decodes a sharpening, clamping its radius
+func (f *Sharpen) UnmarshalJSON(data []byte) error {
+	var in jsonSharpen
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	radius, err := decodeBlurRadius(in.Radius)
+	if err != nil {
+		return err
+	}
+	*f = Sharpen{Radius: radius, Amount: in.Amount}
+	return nil
+}
+
+// Apply returns the sharpened image
+func (f *Sharpen) Apply(src *image.RGBA) *image.RGBA {
+	blurred := (&GaussianBlur{Radius: f.Radius}).Apply(src)
+	dst := image.NewRGBA(src.Bounds())
+
+	forEachTile(src.Bounds(), func(tile image.Rectangle) {
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				i := src.PixOffset(x, y)
+				s, b, d := src.Pix[i:][:4:4], blurred.Pix[i:][:4:4], dst.Pix[i:][:4:4]
+
+				sharpen := func(ch int, max float64) uint8 {
+					v := float64(s[ch]) + f.Amount*(float64(s[ch])-float64(b[ch]))
+					return uint8(math.Max(0, math.Min(max, v+0.5)))
+				}
+
+				d[3] = sharpen(3, 0xff)
+				for ch := 0; ch < 3; ch++ {
+					d[ch] = sharpen(ch, float64(d[3]))
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// BrightnessContrast filter adjusts the brightness and the contrast of the
+// layer
+type BrightnessContrast struct {
+	// Brightness added to the colors, from -1 to 1
+	Brightness float64 `json:"brightness"`
+	// Contrast from -1, flat gray, to 1, the double
+	Contrast float64 `json:"contrast"`
+}
+
+// Apply returns the adjusted image
+func (f *BrightnessContrast) Apply(src *image.RGBA) *image.RGBA {
+	adjust := func(v float64) float64 {
+		return (v-0.5)*(1+f.Contrast) + 0.5 + f.Brightness
+	}
+	return mapColors(src, func(r, g, b float64) (float64, float64, float64) {
+		return adjust(r), adjust(g), adjust(b)
+	})
+}
+
+// HueSaturation filter shifts the hue and adjusts the saturation and the
+// lightness of the layer
+type HueSaturation struct {
+	// Hue shift in degrees
+	Hue float64 `json:"hue"`
+	// Saturation from -1, gray, to 1, the double
+	Saturation float64 `json:"saturation"`
+	// Lightness from -1, black, to 1, white
+	Lightness float64 `json:"lightness"`
+}
+
+// Apply returns the adjusted image
+func (f *HueSaturation) Apply(src *image.RGBA) *image.RGBA {
+	return mapColors(src, func(r, g, b float64) (float64, float64, float64) {
+		h, s, l := rgbToHSL(r, g, b)
+		h = math.Mod(h+f.Hue/360+1, 1)
+		s = math.Max(0, math.Min(1, s*(1+f.Saturation)))
+		if f.Lightness < 0 {
+			l *= 1 + f.Lightness
+		} else {
+			l += (1 - l) * f.Lightness
+		}
+		return hslToRGB(h, s, l)
+	})
+}
+
+// rgbToHSL returns the hue, saturation and lightness, within 0 and 1, of
+// the color
+func rgbToHSL(r, g, b float64) (float64, float64, float64) {
+	max, min := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l := (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
+
+// hslToRGB returns the color of the hue, saturation and lightness within 0
+// and 1
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h*6, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch int(h*6) % 6 {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// Grayscale filter turns the colors of the layer into grays of the same
+// luminance
+type Grayscale struct{}
+
+// Apply returns the gray image
+func (f *Grayscale) Apply(src *image.RGBA) *image.RGBA {
+	return mapColors(src, func(r, g, b float64) (float64, float64, float64) {
+		y := 0.2126*r + 0.7152*g + 0.0722*b
+		return y, y, y
+	})
+}
+
+// DropShadow filter paints a blurred shadow of the layer under it
+type DropShadow struct {
+	// Offset of the shadow, rounded to whole pixels
+	Offset Point
+	// Blur of the shadow, the standard deviation in pixels up to 250
+	Blur float64
+	// Color of the shadow, black when nil
+	Color color.Color
+	// Opacity of the shadow from 0, transparent, to 1
+	Opacity float64
+}
+
+// jsonDropShadow is the JSON encoding of a drop shadow
+type jsonDropShadow struct {
+	Offset  Point   `json:"offset"`
+	Blur    float64 `json:"blur"`
+	Color   string  `json:"color,omitempty"`
+	Opacity float64 `json:"opacity"`
+}
+
+// Apply returns the image over its shadow
+func (f *DropShadow) Apply(src *image.RGBA) *image.RGBA {
+	shade := f.Color
+	if shade == nil {
+		shade = color.Black
+	}
+	opacity := clamp(f.Opacity)
+	r, g, b, a := shade.RGBA()
+
+	bounds := src.Bounds()
+	dx, dy := int(math.Round(f.Offset.X)), int(math.Round(f.Offset.Y))
+	shadow := image.NewRGBA(bounds)
+	forEachTile(bounds, func(tile image.Rectangle) {
+		for y := tile.Min.Y; y < tile.Max.Y; y++ {
+			for x := tile.Min.X; x < tile.Max.X; x++ {
+				if !(image.Point{X: x - dx, Y: y - dy}).In(bounds) {
+					continue
+				}
+
+				k := float64(src.Pix[src.PixOffset(x-dx, y-dy)+3]) / 0xff * opacity / 0xffff
+				d := shadow.Pix[shadow.PixOffset(x, y):][:4:4]
+				d[0], d[1], d[2], d[3] = unit(float64(r)*k), unit(float64(g)*k), unit(float64(b)*k), unit(float64(a)*k)
+			}
+		}
+	})
+
+	dst := (&GaussianBlur{Radius: f.Blur}).Apply(shadow)
+	compose(dst, src, SourceOver, BlendNormal, 1)
+	return dst
+}
+
+// MarshalJSON encodes the color of the shadow as #rrggbbaa
+func (f *DropShadow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDropShadow{
+		Offset:  f.Offset,
+		Blur:    f.Blur,
+		Color:   formatColor(f.Color),
+		Opacity: f.Opacity,
+	})
+}
+
+// UnmarshalJSON decodes a drop shadow encoded by MarshalJSON, clamping its
+// blur. The shadow is opaque when the opacity is missing.
+func (f *DropShadow) UnmarshalJSON(data []byte) error {
+	in := jsonDropShadow{Opacity: 1}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	shade, err := parseColor(in.Color)
+	if err != nil {
+		return err
+	}
+	blur, err := decodeBlurRadius(in.Blur)
+	if err != nil {
+		return err
+	}
+	*f = DropShadow{Offset: in.Offset, Blur: blur, Color: shade, Opacity: in.Opacity}
+	return nil
+}
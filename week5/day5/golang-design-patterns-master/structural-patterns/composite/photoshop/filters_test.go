@@ -0,0 +1,177 @@
+package photoshop
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// dots returns a large transparent image of an opaque red dot in its center
+// and a half transparent green one near its corner
+func dots() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 301, 301))
+	img.SetRGBA(150, 150, color.RGBA{R: 0xff, A: 0xff})
+	img.SetRGBA(10, 290, color.RGBA{G: 200, A: 200})
+	return img
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		x, y   int
+		want   color.RGBA
+	}{
+		{"grayscale", &Grayscale{}, 150, 150, color.RGBA{R: 54, G: 54, B: 54, A: 0xff}},
+		{"hue", &HueSaturation{Hue: 120}, 150, 150, color.RGBA{G: 0xff, A: 0xff}},
+		{"brightness", &BrightnessContrast{Brightness: 0.5}, 10, 290, color.RGBA{R: 100, G: 200, B: 100, A: 200}},
+		{
+			name:   "drop shadow",
+			filter: &DropShadow{Offset: Point{X: 3, Y: 4}, Color: color.RGBA{B: 0xff, A: 0xff}, Opacity: 0.5},
+			x:      153,
+			y:      154,
+			want:   color.RGBA{B: 0x80, A: 0x80},
+		},
+		{
+			name:   "transparent drop shadow",
+			filter: &DropShadow{Offset: Point{X: 3, Y: 4}, Color: color.RGBA{B: 0xff, A: 0xff}},
+			x:      153,
+			y:      154,
+		},
+		{
+			name:   "drop shadow under the image",
+			filter: &DropShadow{Offset: Point{X: 3, Y: 4}, Color: color.RGBA{B: 0xff, A: 0xff}, Opacity: 0.5},
+			x:      150,
+			y:      150,
+			want:   color.RGBA{R: 0xff, A: 0xff},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := dots()
+			filtered := test.filter.Apply(img)
+
+			if got := filtered.RGBAAt(test.x, test.y); got != test.want {
+				t.Errorf("the pixel is %v, want %v", got, test.want)
+			}
+			if !bytes.Equal(img.Pix, dots().Pix) {
+				t.Errorf("the filter changed its source")
+			}
+		})
+	}
+}
+
+func TestGaussianBlur(t *testing.T) {
+	img := dots()
+	blurred := (&GaussianBlur{Radius: 2}).Apply(img)
+
+	center := blurred.RGBAAt(150, 150)
+	if center.A == 0 || center.A == 0xff {
+		t.Errorf("the blurred dot is %v", center)
+	}
+	if blurred.RGBAAt(148, 150) != blurred.RGBAAt(152, 150) || blurred.RGBAAt(150, 148) != blurred.RGBAAt(150, 152) {
+		t.Errorf("the blur is not symmetric")
+	}
+
+	// the tiles blurred in parallel give the pixels of a serial blur
+	procs := runtime.GOMAXPROCS(1)
+	serial := (&GaussianBlur{Radius: 2}).Apply(img)
+	runtime.GOMAXPROCS(procs)
+	if !bytes.Equal(serial.Pix, blurred.Pix) {
+		t.Errorf("the parallel blur differs from the serial one")
+	}
+
+	sharpened := (&Sharpen{Radius: 1, Amount: 1}).Apply(blurred)
+	if sharpened.RGBAAt(150, 150).R <= center.R {
+		t.Errorf("sharpening the blurred dot gave %v", sharpened.RGBAAt(150, 150))
+	}
+}
+
+func TestBlurRadius(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius float64
+		// want is the radius decoded, none when it is rejected
+		want    float64
+		invalid bool
+	}{
+		{"blur", 2, 2, false},
+		{"negative", -3, 0, false},
+		{"too large", 1e15, maxBlurRadius, false},
+		{"not a number", math.NaN(), 0, true},
+		{"infinite", math.Inf(1), 0, true},
+		{"negative infinite", math.Inf(-1), 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			radius, err := decodeBlurRadius(test.radius)
+			if (err != nil) != test.invalid || radius != test.want {
+				t.Errorf("decoded %v with error %v, want %v rejected: %v", radius, err, test.want, test.invalid)
+			}
+
+			// the filters clamp the radius given to them
+			img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+			img.SetRGBA(4, 4, color.RGBA{R: 0xff, A: 0xff})
+			for _, filter := range []Filter{
+				&GaussianBlur{Radius: test.radius},
+				&Sharpen{Radius: test.radius, Amount: 1},
+				&DropShadow{Blur: test.radius, Opacity: 1},
+			} {
+				if got := filter.Apply(img); got.Bounds() != img.Bounds() {
+					t.Errorf("%T filtered to %v", filter, got.Bounds())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeFilterBlur(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Filter
+	}{
+		{"blur", `{"type":"gaussian_blur","radius":1e15}`, &GaussianBlur{Radius: maxBlurRadius}},
+		{"sharpen", `{"type":"sharpen","radius":-1,"amount":2}`, &Sharpen{Amount: 2}},
+		{"drop shadow", `{"type":"drop_shadow","blur":1e15,"opacity":0.5}`, &DropShadow{Blur: maxBlurRadius, Opacity: 0.5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := decodeFilter(json.RawMessage(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(filter, test.want) {
+				t.Errorf("decoded %+v, want %+v", filter, test.want)
+			}
+		})
+	}
+}
+
+func TestLayerFilters(t *testing.T) {
+	square := &Square{Location: Point{X: 10, Y: 10}, Side: 20, Style: &Style{Fill: color.NRGBA{R: 0xff, A: 0xff}}}
+	layer := NewLayer("Layer", square)
+	layer.Filters = []Filter{
+		&DropShadow{Offset: Point{X: 5, Y: 5}, Blur: 2, Color: color.NRGBA{A: 0xff}, Opacity: 1},
+		&Grayscale{},
+	}
+
+	img, err := Render(layer, 50, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(20, 20); c.R != c.G || c.A != 0xff {
+		t.Errorf("the square is %v, want it gray", c)
+	}
+	if c := img.RGBAAt(33, 33); c.A == 0 || c.R != 0 {
+		t.Errorf("the shadow is %v", c)
+	}
+}
@@ -0,0 +1,134 @@
+package photoshop
+
+import "errors"
+
+var (
+	// ErrNothingToUndo is returned when there is no edit to undo
+	ErrNothingToUndo = errors.New("There is nothing to undo")
+	// ErrNothingToRedo is returned when there is no edit to redo
+	ErrNothingToRedo = errors.New("There is nothing to redo")
+)
+
+// Edit is a reversible change of a document
+type Edit interface {
+	// Do makes the change
+	Do() error
+	// Undo reverts the change
+	Undo() error
+}
+
+// Merger edits absorb the edits done right after them in a merge, such as
+// the moves of a drag, so they are undone at once
+type Merger interface {
+	// Merge absorbs the next edit, which is done already, and tells whether
+	// it did
+	Merge(next Edit) bool
+}
+
+// History does the edits and keeps them to be undone and redone. The edits
+// done between BeginMerge and Seal merge into one, the others are undone
+// one by one.
+type History struct {
+	// Limit of the edits kept to be undone, unbounded when zero
+	Limit int
+
+	done    []Edit
+	undone  []Edit
+	merging bool
+	// merged tells whether an edit was kept since BeginMerge, the one the
+	// next edits merge into
+	merged bool
+}
+
+// NewHistory creates a history keeping up to limit edits
+func NewHistory(limit int) *History {
+	return &History{Limit: limit}
+}
+
+// Do does the edit and keeps it to be undone, merged with the last edit
+// when a merge is open and they merge. The edits undone can't be redone
+// anymore. A nil edit or an empty group changes nothing and is not kept.
+func (h *History) Do(edit Edit) error {
+	if group, ok := edit.(EditGroup); edit == nil || ok && len(group) == 0 {
+		return nil
+	}
+	if err := edit.Do(); err != nil {
+		return err
+	}
+	h.undone = nil
+
+	if n := len(h.done); h.merged && n > 0 {
+		if merger, ok := h.done[n-1].(Merger); ok && merger.Merge(edit) {
+			return nil
+		}
+	}
+
+	h.done = append(h.done, edit)
+	if h.Limit > 0 && len(h.done) > h.Limit {
+		h.done = append(h.done[:0], h.done[len(h.done)-h.Limit:]...)
+	}
+	h.merged = h.merging
+	return nil
+}
+
+// BeginMerge opens a merge, the edits done until Seal merge into the first
+// of them. It is called at the start of a drag.
+func (h *History) BeginMerge() {
+	h.merging, h.merged = true, false
+}
+
+// Seal ends the merge, the next edit is undone on its own. It is called at
+// the end of a drag.
+func (h *History) Seal() {
+	h.merging, h.merged = false, false
+}
+
+// Undo reverts the last edit done
+func (h *History) Undo() error {
+	n := len(h.done)
+	if n == 0 {
+		return ErrNothingToUndo
+	}
+
+	edit := h.done[n-1]
+	if err := edit.Undo(); err != nil {
+		return err
+	}
+	h.done = h.done[:n-1]
+	h.undone = append(h.undone, edit)
+	h.Seal()
+	return nil
+}
+
+// Redo does the last edit undone again
+func (h *History) Redo() error {
+	n := len(h.undone)
+	if n == 0 {
+		return ErrNothingToRedo
+	}
+
+	edit := h.undone[n-1]
+	if err := edit.Do(); err != nil {
+		return err
+	}
+	h.undone = h.undone[:n-1]
+	h.done = append(h.done, edit)
+	h.Seal()
+	return nil
+}
+
+// CanUndo tells whether there is an edit to undo
+func (h *History) CanUndo() bool {
+	return len(h.done) > 0
+}
+
+// CanRedo tells whether there is an edit to redo
+func (h *History) CanRedo() bool {
+	return len(h.undone) > 0
+}
+
+// Clear drops the edits
+func (h *History) Clear() {
+	h.done, h.undone = nil, nil
+	h.Seal()
+}
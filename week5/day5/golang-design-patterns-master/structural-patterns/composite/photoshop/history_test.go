@@ -0,0 +1,233 @@
+package photoshop
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// describe returns the squares and circles of the layer, from the bottom to
+// the top, and whether they are styled
+func describe(layer *Layer) string {
+	var elements []string
+	for _, element := range layer.Elements {
+		switch e := element.(type) {
+		case *Square:
+			elements = append(elements, fmt.Sprintf("square %v,%v %v %t", e.Location.X, e.Location.Y, e.Side, e.Style != nil))
+		case *Circle:
+			elements = append(elements, fmt.Sprintf("circle %v,%v %v %t", e.Center.X, e.Center.Y, e.Radius, e.Style != nil))
+		}
+	}
+	return strings.Join(elements, "; ")
+}
+
+func TestEdits(t *testing.T) {
+	const original = "square 0,0 10 false; circle 5,5 5 false"
+
+	tests := []struct {
+		name string
+		edit func(layer *Layer, square *Square, circle *Circle) Edit
+		done string
+	}{
+		{
+			name: "add",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return NewAddElement(layer, &Square{Location: Point{X: 1, Y: 1}, Side: 1})
+			},
+			done: original + "; square 1,1 1 false",
+		},
+		{
+			name: "remove",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return &RemoveElement{Layer: layer, Element: square}
+			},
+			done: "circle 5,5 5 false",
+		},
+		{
+			name: "move",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return &MoveElement{Element: circle, Offset: Point{X: 1, Y: 2}}
+			},
+			done: "square 0,0 10 false; circle 6,7 5 false",
+		},
+		{
+			name: "resize",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return &ResizeElement{Element: square, Size: Size{Width: 20, Height: 20}}
+			},
+			done: "square 0,0 20 false; circle 5,5 5 false",
+		},
+		{
+			name: "change style",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return &ChangeStyle{Element: circle, Style: &Style{Fill: color.White}}
+			},
+			done: "square 0,0 10 false; circle 5,5 5 true",
+		},
+		{
+			name: "reorder",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return &ReorderElement{Layer: layer, Element: square, Index: 1}
+			},
+			done: "circle 5,5 5 false; square 0,0 10 false",
+		},
+		{
+			name: "group",
+			edit: func(layer *Layer, square *Square, circle *Circle) Edit {
+				return EditGroup{
+					&MoveElement{Element: square, Offset: Point{X: 3}},
+					&MoveElement{Element: circle, Offset: Point{X: 3}},
+				}
+			},
+			done: "square 3,0 10 false; circle 8,5 5 false",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			square := &Square{Side: 10}
+			circle := &Circle{Center: Point{X: 5, Y: 5}, Radius: 5}
+			layer := NewLayer("Layer", square, circle)
+
+			history := NewHistory(0)
+			if err := history.Do(test.edit(layer, square, circle)); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(layer); got != test.done {
+				t.Errorf("done %s, want %s", got, test.done)
+			}
+
+			if err := history.Undo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(layer); got != original {
+				t.Errorf("undone %s, want %s", got, original)
+			}
+
+			if err := history.Redo(); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(layer); got != test.done {
+				t.Errorf("redone %s, want %s", got, test.done)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	circle := &Circle{Center: Point{X: 5, Y: 5}, Radius: 5}
+	layer := NewLayer("Layer", circle)
+	history := NewHistory(3)
+
+	// the moves of a drag merge into one edit until the history is sealed
+	history.BeginMerge()
+	for i := 0; i < 5; i++ {
+		if err := history.Do(&MoveElement{Element: circle, Offset: Point{X: 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history.Seal()
+	history.Do(&MoveElement{Element: circle, Offset: Point{X: 1}})
+
+	if err := history.Undo(); err != nil || circle.Center.X != 10 {
+		t.Fatalf("undid the last move to %v: %v", circle.Center, err)
+	}
+	if err := history.Undo(); err != nil || circle.Center.X != 5 {
+		t.Fatalf("undid the drag to %v: %v", circle.Center, err)
+	}
+	if err := history.Undo(); err != ErrNothingToUndo {
+		t.Errorf("undoing an empty history returned %v", err)
+	}
+
+	// doing an edit drops the edits undone
+	history.Redo()
+	history.Do(&ChangeStyle{Element: circle, Style: &Style{}})
+	if history.CanRedo() {
+		t.Errorf("an undone edit can be redone after a new edit")
+	}
+	if err := history.Redo(); err != ErrNothingToRedo {
+		t.Errorf("redoing without an undone edit returned %v", err)
+	}
+
+	// the history keeps up to its limit of edits
+	history.Clear()
+	for i := 0; i < 5; i++ {
+		history.Do(&MoveElement{Element: circle, Offset: Point{X: 1}})
+	}
+	for history.CanUndo() {
+		history.Undo()
+	}
+	if circle.Center.X != 12 {
+		t.Errorf("undid the moves to %v, want the 3 last ones undone", circle.Center)
+	}
+
+	// a failed edit is not kept
+	layer.Locked = true
+	if err := history.Do(&RemoveElement{Layer: layer, Element: circle}); err != ErrLocked {
+		t.Errorf("removing from a locked layer returned %v", err)
+	}
+	if history.CanUndo() {
+		t.Errorf("the failed edit is kept")
+	}
+}
+
+func TestHistoryMerge(t *testing.T) {
+	circle := &Circle{Center: Point{X: 5, Y: 5}, Radius: 5}
+	move := func() Edit { return &MoveElement{Element: circle, Offset: Point{X: 1}} }
+
+	tests := []struct {
+		name string
+		do   func(history *History)
+		// steps is the number of undos back to the start
+		steps int
+	}{
+		{"without a merge", func(history *History) {
+			history.Do(move())
+			history.Do(move())
+		}, 2},
+		{"within a merge", func(history *History) {
+			history.BeginMerge()
+			history.Do(move())
+			history.Do(move())
+			history.Seal()
+		}, 1},
+		{"before a merge", func(history *History) {
+			history.Do(move())
+			history.BeginMerge()
+			history.Do(move())
+			history.Do(move())
+		}, 2},
+		{"after a seal", func(history *History) {
+			history.BeginMerge()
+			history.Do(move())
+			history.Seal()
+			history.Do(move())
+		}, 2},
+		{"across an undo", func(history *History) {
+			history.BeginMerge()
+			history.Do(move())
+			history.Do(move())
+			history.Undo()
+			history.Do(move())
+		}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			circle.Center = Point{X: 5, Y: 5}
+			history := NewHistory(0)
+			test.do(history)
+
+			steps := 0
+			for ; history.CanUndo(); steps++ {
+				if err := history.Undo(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if steps != test.steps || circle.Center.X != 5 {
+				t.Errorf("undid %d steps back to %v, want %d back to the start", steps, circle.Center, test.steps)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package photoshop
+
+import "errors"
+
+var (
+	// ErrLocked is returned when the elements of a locked layer are changed
+	ErrLocked = errors.New("The layer is locked")
+	// ErrNotFound is returned when an element is not in the layer
+	ErrNotFound = errors.New("The element is not in the layer")
+	// ErrInvalidIndex is returned when an index is out of the elements
+	ErrInvalidIndex = errors.New("The index is out of the elements")
+	// ErrCycle is returned when a layer is put into itself or into a layer
+	// of its tree
+	ErrCycle = errors.New("A layer cannot be put into itself")
+	// ErrNoLayer is returned when a layer is ended but none was begun
+	ErrNoLayer = errors.New("No layer was begun")
+)
+
+// Layer contains composition of visual elements. Layers are visual
+// elements themselves, so they nest as groups.
+type Layer struct {
+	// Name of the layer
+	Name string
+	// Elements of visual elements, from the bottom to the top
+	Elements []VisualElement
+	// Hidden layers are not drawn
+	Hidden bool
+	// Opacity of the layer from 0, transparent, to 1, opaque
+	Opacity float64
+	// BlendMode of the layer with the layers below
+	BlendMode BlendMode
+	// Operator composing the layer with the layers below
+	Operator Operator
+	// Filters applied in order to the pixels of the layer before they are
+	// composed with the layers below
+	Filters []Filter
+	// Locked layers can't have their elements added, removed or reordered
+	Locked bool
+}
+
+// NewLayer creates a visible, opaque layer of the elements
+func NewLayer(name string, elements ...VisualElement) *Layer {
+	return &Layer{Name: name, Elements: elements, Opacity: 1}
+}
+
+// Draw draws a layer unless it is hidden. The elements are drawn between
+// the beginning and the end of the layer, which the drawer composes with
+// the operator, blend mode and opacity of the layer.
+func (layer *Layer) Draw(drawer Drawer) error {
+	if layer.Hidden {
+		return nil
+	}
+	if err := drawer.BeginLayer(layer); err != nil {
+		return err
+	}
+
+	for _, element := range layer.Elements {
+		if err := element.Draw(drawer); err != nil {
+			return err
+		}
+	}
+
+	return drawer.EndLayer()
+}
+
+// MoveBy moves the movable elements of the layer by the offset
+func (layer *Layer) MoveBy(offset Point) {
+	for _, element := range layer.Elements {
+		if movable, ok := element.(Movable); ok {
+			movable.MoveBy(offset)
+		}
+	}
+}
+
+// IndexOf returns the index of the element in the layer, or -1
+func (layer *Layer) IndexOf(element VisualElement) int {
+	for i, other := range layer.Elements {
+		if other == element {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add puts the elements on top of the layer
+func (layer *Layer) Add(elements ...VisualElement) error {
+	if layer.Locked {
+		return ErrLocked
+	}
+	for _, element := range elements {
+		if layer.inside(element) {
+			return ErrCycle
+		}
+	}
+	layer.Elements = append(layer.Elements, elements...)
+	return nil
+}
+
+// Insert puts the element at the index, 0 being the bottom
+func (layer *Layer) Insert(index int, element VisualElement) error {
+	if layer.Locked {
+		return ErrLocked
+	}
+	if index < 0 || index > len(layer.Elements) {
+		return ErrInvalidIndex
+	}
+	if layer.inside(element) {
+		return ErrCycle
+	}
+
+	layer.Elements = append(layer.Elements, nil)
+	copy(layer.Elements[index+1:], layer.Elements[index:])
+	layer.Elements[index] = element
+	return nil
+}
+
+// inside reports whether the layer is the element or is in its tree
+func (layer *Layer) inside(element VisualElement) bool {
+	group, ok := element.(*Layer)
+	return ok && (group == layer || group.Path(layer) != nil)
+}
+
+// Remove takes the element off the layer
+func (layer *Layer) Remove(element VisualElement) error {
+	if layer.Locked {
+		return ErrLocked
+	}
+
+	i := layer.IndexOf(element)
+	if i < 0 {
+		return ErrNotFound
+	}
+	layer.Elements = append(layer.Elements[:i], layer.Elements[i+1:]...)
+	return nil
+}
+
+// Raise moves the element one step up
+func (layer *Layer) Raise(element VisualElement) error {
+	return layer.reorder(element, func(i int) int { return i + 1 })
+}
+
+// Lower moves the element one step down
+func (layer *Layer) Lower(element VisualElement) error {
+	return layer.reorder(element, func(i int) int { return i - 1 })
+}
+
+// BringToFront moves the element to the top
+func (layer *Layer) BringToFront(element VisualElement) error {
+	return layer.reorder(element, func(int) int { return len(layer.Elements) - 1 })
+}
+
+// SendToBack moves the element to the bottom
+func (layer *Layer) SendToBack(element VisualElement) error {
+	return layer.reorder(element, func(int) int { return 0 })
+}
+
+// reorder moves the element from its index to the one returned by to,
+// kept within the elements
+func (layer *Layer) reorder(element VisualElement, to func(int) int) error {
+	if layer.Locked {
+		return ErrLocked
+	}
+
+	from := layer.IndexOf(element)
+	if from < 0 {
+		return ErrNotFound
+	}
+
+	index := to(from)
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(layer.Elements) {
+		index = len(layer.Elements) - 1
+	}
+
+	for ; from < index; from++ {
+		layer.Elements[from], layer.Elements[from+1] = layer.Elements[from+1], layer.Elements[from]
+	}
+	for ; from > index; from-- {
+		layer.Elements[from], layer.Elements[from-1] = layer.Elements[from-1], layer.Elements[from]
+	}
+	return nil
+}
+
+// Find returns the first layer of the name in the tree of the layer, the
+// layer itself included, depth first, or nil
+func (layer *Layer) Find(name string) *Layer {
+	if layer.Name == name {
+		return layer
+	}
+	for _, element := range layer.Elements {
+		if group, ok := element.(*Layer); ok {
+			if found := group.Find(name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Path returns the layers from this one down to the layer holding the
+// element, or nil when the element is not in the tree
+func (layer *Layer) Path(element VisualElement) []*Layer {
+	if layer.IndexOf(element) >= 0 {
+		return []*Layer{layer}
+	}
+	for _, child := range layer.Elements {
+		if group, ok := child.(*Layer); ok {
+			if path := group.Path(element); path != nil {
+				return append([]*Layer{layer}, path...)
+			}
+		}
+	}
+	return nil
+}
+
+// Parent returns the layer holding the element in the tree of the layer,
+// or nil
+func (layer *Layer) Parent(element VisualElement) *Layer {
+	path := layer.Path(element)
+	if path == nil {
+		return nil
+	}
+	return path[len(path)-1]
+}
+
+// Move takes the element of the tree off its layer and puts it in the
+// group at the index. The group is this layer or one in its tree. Neither
+// layer, nor any group above them, may be locked.
+func (layer *Layer) Move(element VisualElement, group *Layer, index int) error {
+	from := layer.Path(element)
+	if from == nil {
+		return ErrNotFound
+	}
+
+	to := append(layer.Path(group), group)
+	if group != layer && len(to) == 1 {
+		return ErrNotFound
+	}
+	for _, ancestor := range to {
+		if ancestor == element {
+			return ErrCycle
+		}
+	}
+
+	for _, path := range [][]*Layer{from, to} {
+		for _, ancestor := range path {
+			if ancestor.Locked {
+				return ErrLocked
+			}
+		}
+	}
+
+	parent := from[len(from)-1]
+	if index < 0 || index > len(group.Elements) || (parent == group && index >= len(group.Elements)) {
+		return ErrInvalidIndex
+	}
+
+	if err := parent.Remove(element); err != nil {
+		return err
+	}
+	return group.Insert(index, element)
+}
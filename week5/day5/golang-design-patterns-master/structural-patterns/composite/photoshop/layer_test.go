@@ -0,0 +1,176 @@
+package photoshop
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sides returns the sides of the squares of the layer, to tell them apart
+func sides(layer *Layer) []float64 {
+	var sides []float64
+	for _, element := range layer.Elements {
+		if square, ok := element.(*Square); ok {
+			sides = append(sides, square.Side)
+		}
+	}
+	return sides
+}
+
+func TestLayerReorder(t *testing.T) {
+	a, b, c := &Square{Side: 1}, &Square{Side: 2}, &Square{Side: 3}
+
+	tests := []struct {
+		name    string
+		reorder func(layer *Layer) error
+		want    []float64
+		err     error
+	}{
+		{"raise", func(layer *Layer) error { return layer.Raise(a) }, []float64{2, 1, 3}, nil},
+		{"raise the top", func(layer *Layer) error { return layer.Raise(c) }, []float64{1, 2, 3}, nil},
+		{"lower", func(layer *Layer) error { return layer.Lower(c) }, []float64{1, 3, 2}, nil},
+		{"bring to front", func(layer *Layer) error { return layer.BringToFront(a) }, []float64{2, 3, 1}, nil},
+		{"send to back", func(layer *Layer) error { return layer.SendToBack(c) }, []float64{3, 1, 2}, nil},
+		{"insert", func(layer *Layer) error { return layer.Insert(1, &Square{Side: 4}) }, []float64{1, 4, 2, 3}, nil},
+		{"insert out of the elements", func(layer *Layer) error { return layer.Insert(4, &Square{Side: 4}) }, []float64{1, 2, 3}, ErrInvalidIndex},
+		{"remove", func(layer *Layer) error { return layer.Remove(b) }, []float64{1, 3}, nil},
+		{"missing", func(layer *Layer) error { return layer.Raise(&Square{Side: 4}) }, []float64{1, 2, 3}, ErrNotFound},
+		{
+			name: "locked",
+			reorder: func(layer *Layer) error {
+				layer.Locked = true
+				return layer.SendToBack(c)
+			},
+			want: []float64{1, 2, 3},
+			err:  ErrLocked,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layer := NewLayer("Layer", a, b, c)
+			if err := test.reorder(layer); err != test.err {
+				t.Errorf("error is %v, want %v", err, test.err)
+			}
+			if got := sides(layer); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("the squares are %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// layerTree is a document of two squares and a group of a square and of an
+// empty group
+type layerTree struct {
+	root, inner, outer *Layer
+	a, b, c            *Square
+}
+
+func newLayerTree() *layerTree {
+	tree := &layerTree{a: &Square{Side: 1}, b: &Square{Side: 2}, c: &Square{Side: 3}}
+	tree.outer = NewLayer("Outer")
+	tree.inner = NewLayer("Inner", tree.c, tree.outer)
+	tree.root = NewLayer("Document", tree.a, tree.b, tree.inner)
+	return tree
+}
+
+func TestLayerMove(t *testing.T) {
+	a := func(tree *layerTree) VisualElement { return tree.a }
+	c := func(tree *layerTree) VisualElement { return tree.c }
+	root := func(tree *layerTree) *Layer { return tree.root }
+	inner := func(tree *layerTree) *Layer { return tree.inner }
+	outer := func(tree *layerTree) *Layer { return tree.outer }
+
+	tests := []struct {
+		name    string
+		element func(tree *layerTree) VisualElement
+		group   func(tree *layerTree) *Layer
+		index   int
+		locked  bool
+		err     error
+		parent  func(tree *layerTree) *Layer
+	}{
+		{name: "into a group", element: a, group: outer, parent: outer},
+		{name: "within the layer", element: a, group: root, index: 1, parent: root},
+		{name: "out of a group", element: c, group: root, parent: root},
+		{
+			name:    "into itself",
+			element: func(tree *layerTree) VisualElement { return tree.inner },
+			group:   outer,
+			err:     ErrCycle,
+			parent:  root,
+		},
+		{
+			name:    "group out of the tree",
+			element: a,
+			group:   func(tree *layerTree) *Layer { return NewLayer("Other") },
+			err:     ErrNotFound,
+			parent:  root,
+		},
+		{name: "out of a locked group", element: c, group: root, locked: true, err: ErrLocked, parent: inner},
+		{name: "into a group of a locked group", element: a, group: outer, locked: true, err: ErrLocked, parent: root},
+		{name: "index out of the group", element: a, group: outer, index: 1, err: ErrInvalidIndex, parent: root},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := newLayerTree()
+			tree.inner.Locked = test.locked
+
+			element := test.element(tree)
+			if err := tree.root.Move(element, test.group(tree), test.index); err != test.err {
+				t.Errorf("error is %v, want %v", err, test.err)
+			}
+			if parent, want := tree.root.Parent(element), test.parent(tree); parent != want {
+				t.Errorf("the element is in %v, want %v", parent.Name, want.Name)
+			}
+		})
+	}
+
+	tree := newLayerTree()
+	if err := tree.root.Move(&Square{}, tree.outer, 0); err != ErrNotFound {
+		t.Errorf("moving a missing element returned %v", err)
+	}
+}
+
+func TestLayerAddCycle(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(tree *layerTree) error
+		err  error
+	}{
+		{"itself", func(tree *layerTree) error { return tree.outer.Add(tree.outer) }, ErrCycle},
+		{"its parent", func(tree *layerTree) error { return tree.outer.Add(tree.b, tree.inner) }, ErrCycle},
+		{"its root", func(tree *layerTree) error { return tree.outer.Insert(0, tree.root) }, ErrCycle},
+		{"its root by an edit", func(tree *layerTree) error { return NewAddElement(tree.outer, tree.root).Do() }, ErrCycle},
+		{"another group", func(tree *layerTree) error { return tree.outer.Add(NewLayer("Other", tree.a)) }, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree := newLayerTree()
+			if err := test.add(tree); err != test.err {
+				t.Errorf("error is %v, want %v", err, test.err)
+			}
+			if test.err != nil && len(tree.outer.Elements) != 0 {
+				t.Errorf("the group holds %d elements, want none", len(tree.outer.Elements))
+			}
+		})
+	}
+}
+
+func TestLayerFind(t *testing.T) {
+	tree := newLayerTree()
+
+	if found := tree.root.Find("Outer"); found != tree.outer {
+		t.Errorf("found %v, want the outer group", found)
+	}
+	if found := tree.root.Find("Missing"); found != nil {
+		t.Errorf("found %v, want none", found)
+	}
+	if path := tree.root.Path(tree.c); !reflect.DeepEqual(path, []*Layer{tree.root, tree.inner}) {
+		t.Errorf("the path of a nested square is %v", path)
+	}
+	if path := tree.root.Path(&Square{}); path != nil {
+		t.Errorf("the path of a missing square is %v", path)
+	}
+}
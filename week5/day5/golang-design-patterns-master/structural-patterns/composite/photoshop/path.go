@@ -0,0 +1,194 @@
+package photoshop
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/internal/geometry"
+)
+
+// flatness is the largest distance between a curve and the polygon
+// approximating it
+const flatness = 0.25
+
+// PathOp is the operation of a path segment
+type PathOp uint8
+
+const (
+	// PathMoveTo starts a new subpath at the point
+	PathMoveTo PathOp = iota
+	// PathLineTo draws a straight line to the point
+	PathLineTo
+	// PathQuadTo draws a quadratic bezier curve through a control point to
+	// the point
+	PathQuadTo
+	// PathCubicTo draws a cubic bezier curve through two control points to
+	// the point
+	PathCubicTo
+	// PathClose closes the subpath with a line to its start
+	PathClose
+)
+
+var pathOpNames = [...]string{"move_to", "line_to", "quad_to", "cubic_to", "close"}
+
+// String returns the name of the operation
+func (op PathOp) String() string {
+	if int(op) < len(pathOpNames) {
+		return pathOpNames[op]
+	}
+	return fmt.Sprintf("PathOp(%d)", op)
+}
+
+// MarshalText encodes the operation as its name
+func (op PathOp) MarshalText() ([]byte, error) {
+	if int(op) >= len(pathOpNames) {
+		return nil, fmt.Errorf("Unknown path op %d", op)
+	}
+	return []byte(pathOpNames[op]), nil
+}
+
+// UnmarshalText decodes the operation of the name
+func (op *PathOp) UnmarshalText(text []byte) error {
+	for i, name := range pathOpNames {
+		if name == string(text) {
+			*op = PathOp(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown path op %q", text)
+}
+
+// PathSegment is a single operation of a path. Points holds the control
+// points followed by the end point.
+type PathSegment struct {
+	// Op of the segment
+	Op PathOp `json:"op"`
+	// Points of the segment
+	Points []Point `json:"points,omitempty"`
+}
+
+// Path is a sequence of lines and bezier curves. Its subpaths are filled by
+// the nonzero winding rule, as if they were closed.
+type Path struct {
+	// Segments of the path
+	Segments []PathSegment `json:"segments"`
+}
+
+// MoveTo starts a new subpath at the point
+func (p *Path) MoveTo(to Point) *Path {
+	return p.add(PathMoveTo, to)
+}
+
+// LineTo draws a straight line to the point
+func (p *Path) LineTo(to Point) *Path {
+	return p.add(PathLineTo, to)
+}
+
+// QuadTo draws a quadratic bezier curve through the control point
+func (p *Path) QuadTo(control, to Point) *Path {
+	return p.add(PathQuadTo, control, to)
+}
+
+// CubicTo draws a cubic bezier curve through the control points
+func (p *Path) CubicTo(control1, control2, to Point) *Path {
+	return p.add(PathCubicTo, control1, control2, to)
+}
+
+// Close closes the subpath
+func (p *Path) Close() *Path {
+	return p.add(PathClose)
+}
+
+func (p *Path) add(op PathOp, points ...Point) *Path {
+	p.Segments = append(p.Segments, PathSegment{Op: op, Points: points})
+	return p
+}
+
+// polygonPath returns the path of the closed polygons
+func polygonPath(polygons [][]Point) *Path {
+	path := &Path{}
+	for _, polygon := range polygons {
+		if len(polygon) < 2 {
+			continue
+		}
+		path.MoveTo(polygon[0])
+		for _, p := range polygon[1:] {
+			path.LineTo(p)
+		}
+		path.Close()
+	}
+	return path
+}
+
+// subpath is a flattened subpath
+type subpath struct {
+	points []Point
+	closed bool
+}
+
+// flatten approximates the subpaths of the path with polylines
+func (p *Path) flatten() []subpath {
+	flattener := &geometry.Flattener{Flatness: flatness}
+	var points []geometry.Point
+	for _, segment := range p.Segments {
+		points = points[:0]
+		for _, point := range segment.Points {
+			points = append(points, geometry.Point(point))
+		}
+		flattener.Segment(geometry.Op(segment.Op), points)
+	}
+
+	flattened := flattener.Subpaths()
+	subpaths := make([]subpath, len(flattened))
+	for i, sub := range flattened {
+		subpaths[i] = subpath{points: fromGeometry(sub.Points), closed: sub.Closed}
+	}
+	return subpaths
+}
+
+// fromGeometry converts the points of the shared geometry
+func fromGeometry(points []geometry.Point) []Point {
+	converted := make([]Point, len(points))
+	for i, point := range points {
+		converted[i] = Point(point)
+	}
+	return converted
+}
+
+// polygons returns the flattened subpaths of the path, all closed
+func (p *Path) polygons() [][]Point {
+	var polygons [][]Point
+	for _, sub := range p.flatten() {
+		polygons = append(polygons, sub.points)
+	}
+	return polygons
+}
+
+// ellipsePolygon approximates the ellipse inscribed in the rectangle
+func ellipsePolygon(r Rect) []Point {
+	rx, ry := r.Size.Width/2, r.Size.Height/2
+	n := curveSegments(math.Max(math.Abs(rx), math.Abs(ry)))
+
+	points := make([]Point, n)
+	for i := range points {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		points[i] = Point{X: r.Location.X + rx + rx*cos, Y: r.Location.Y + ry + ry*sin}
+	}
+	return points
+}
+
+// curveSegments returns how many segments approximate a circle of the
+// radius within the flatness
+func curveSegments(radius float64) int {
+	if radius <= flatness {
+		return 8
+	}
+	n := int(math.Ceil(math.Pi / math.Acos(1-flatness/radius)))
+	if n < 8 {
+		n = 8
+	}
+	if n > 1024 {
+		n = 1024
+	}
+	return n
+}
@@ -1,80 +1,172 @@
 package photoshop
 
-import "fmt"
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+)
 
 // Point represents a point on the screen
 type Point struct {
 	// X is the x-coordinate
-	X float64
+	X float64 `json:"x"`
 	// Y is the y-coordinate
-	Y float64
+	Y float64 `json:"y"`
 }
 
 // Size represents a width and height size
 type Size struct {
 	// Width is the rectangle width
-	Width float64
+	Width float64 `json:"width"`
 	// Height is the rectangle height
-	Height float64
+	Height float64 `json:"height"`
 }
 
 // Rect represents an rectangle
 type Rect struct {
 	// Location of the rectangle
-	Location Point
+	Location Point `json:"location"`
 	// Size of the rectanlge sides
-	Size Size
+	Size Size `json:"size"`
 }
 
-// Drawer draws shapes
-type Drawer struct{}
+// Style is the paint of a shape
+type Style struct {
+	// Fill color of the inside, none when nil
+	Fill color.Color
+	// Stroke color of the outline, none when nil
+	Stroke color.Color
+	// StrokeWidth of the outline, centered on it
+	StrokeWidth float64
+}
+
+// DefaultStyle fills the shapes in black
+var DefaultStyle = Style{Fill: color.Black}
+
+// styleOrDefault returns the style, or the default style when nil
+func styleOrDefault(style *Style) Style {
+	if style == nil {
+		return DefaultStyle
+	}
+	return *style
+}
+
+// Drawer draws the visual elements on a target
+type Drawer interface {
+	// SetStyle sets the style of the shapes drawn next
+	SetStyle(style Style)
+	// DrawEllipseInRect draws an ellipse in rectangle
+	DrawEllipseInRect(r Rect) error
+	// DrawRect draws rectangle
+	DrawRect(r Rect) error
+	// DrawPath draws a path
+	DrawPath(path *Path) error
+	// DrawText draws a text run whose top left corner is at the origin
+	DrawText(text string, origin Point, font Font) error
+	// BeginLayer starts drawing the elements of a layer
+	BeginLayer(layer *Layer) error
+	// EndLayer ends drawing the elements of the last layer begun and
+	// composes them with the elements below
+	EndLayer() error
+}
+
+// Printer drawer prints the shapes, a line each
+type Printer struct {
+	// Writer the shapes are printed to, the standard output when nil
+	Writer io.Writer
+}
+
+// SetStyle ignores the style, it isn't printed
+func (p *Printer) SetStyle(style Style) {}
 
 // DrawEllipseInRect draws an ellipse in rectangle
-func (d *Drawer) DrawEllipseInRect(r Rect) error {
-	fmt.Printf("Drawing ellipse in rect %v", r)
-	return nil
+func (p *Printer) DrawEllipseInRect(r Rect) error {
+	_, err := fmt.Fprintf(p.writer(), "Drawing ellipse in rect %v\n", r)
+	return err
 }
 
 // DrawRect draws rectangle
-func (d *Drawer) DrawRect(r Rect) error {
-	fmt.Printf("Drawing rect %v", r)
+func (p *Printer) DrawRect(r Rect) error {
+	_, err := fmt.Fprintf(p.writer(), "Drawing rect %v\n", r)
+	return err
+}
+
+// DrawPath draws a path
+func (p *Printer) DrawPath(path *Path) error {
+	_, err := fmt.Fprintf(p.writer(), "Drawing path of %d segments\n", len(path.Segments))
+	return err
+}
+
+// DrawText draws a text run
+func (p *Printer) DrawText(text string, origin Point, font Font) error {
+	_, err := fmt.Fprintf(p.writer(), "Drawing text %q at %v\n", text, origin)
+	return err
+}
+
+// BeginLayer does nothing, the layers aren't printed
+func (p *Printer) BeginLayer(layer *Layer) error {
 	return nil
 }
 
+// EndLayer does nothing, the layers aren't printed
+func (p *Printer) EndLayer() error {
+	return nil
+}
+
+// writer returns the writer the shapes are printed to
+func (p *Printer) writer() io.Writer {
+	if p.Writer == nil {
+		return os.Stdout
+	}
+	return p.Writer
+}
+
 // VisualElement that is drawn on the screen
 type VisualElement interface {
 	// Draw draws the visual element
-	Draw(drawer *Drawer) error
+	Draw(drawer Drawer) error
+	// Bounds returns the smallest rectangle containing everything drawn,
+	// strokes included
+	Bounds() Rect
 }
 
-// Layer contains composition of visual elements
-type Layer struct {
-	// Elements of visual elements
-	Elements []VisualElement
+// Movable elements can be moved
+type Movable interface {
+	// MoveBy moves the element by the offset
+	MoveBy(offset Point)
 }
 
-// Draw draws a layer
-func (layer *Layer) Draw(drawer *Drawer) error {
-	for _, element := range layer.Elements {
-		if err := element.Draw(drawer); err != nil {
-			return err
-		}
-		fmt.Println()
-	}
+// Resizable elements can be resized
+type Resizable interface {
+	// Size returns the size of the element
+	Size() Size
+	// Resize resizes the element, keeping its top left corner
+	Resize(size Size)
+}
 
-	return nil
+// Styled elements have a style
+type Styled interface {
+	// CurrentStyle returns the style, nil for the default style
+	CurrentStyle() *Style
+	// SetStyle sets the style, nil for the default style
+	SetStyle(style *Style)
 }
 
 // Square represents a square
 type Square struct {
 	// Location of the square
-	Location Point
+	Location Point `json:"location"`
 	// Side size
-	Side float64
+	Side float64 `json:"side"`
+	// Style of the square, the default style when nil
+	Style *Style `json:"style,omitempty"`
 }
 
 // Draw draws a square
-func (square *Square) Draw(drawer *Drawer) error {
+func (square *Square) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(square.Style))
 	return drawer.DrawRect(Rect{
 		Location: square.Location,
 		Size: Size{
@@ -84,16 +176,44 @@ func (square *Square) Draw(drawer *Drawer) error {
 	})
 }
 
+// MoveBy moves the square by the offset
+func (square *Square) MoveBy(offset Point) {
+	square.Location.X += offset.X
+	square.Location.Y += offset.Y
+}
+
+// Size returns the size of the square
+func (square *Square) Size() Size {
+	return Size{Width: square.Side, Height: square.Side}
+}
+
+// Resize resizes the square to the smaller side of the size
+func (square *Square) Resize(size Size) {
+	square.Side = math.Min(size.Width, size.Height)
+}
+
+// CurrentStyle returns the style of the square
+func (square *Square) CurrentStyle() *Style {
+	return square.Style
+}
+
+// SetStyle sets the style of the square
+func (square *Square) SetStyle(style *Style) {
+	square.Style = style
+}
+
 // Circle represents a circle shape
 type Circle struct {
 	// Center of the circle
-	Center Point
+	Center Point `json:"center"`
 	// Radius of the circle
-	Radius float64
+	Radius float64 `json:"radius"`
+	// Style of the circle, the default style when nil
+	Style *Style `json:"style,omitempty"`
 }
 
 // Draw draws a circle
-func (circle *Circle) Draw(drawer *Drawer) error {
+func (circle *Circle) Draw(drawer Drawer) error {
 	rect := Rect{
 		Location: Point{
 			X: circle.Center.X - circle.Radius,
@@ -105,5 +225,34 @@ func (circle *Circle) Draw(drawer *Drawer) error {
 		},
 	}
 
+	drawer.SetStyle(styleOrDefault(circle.Style))
 	return drawer.DrawEllipseInRect(rect)
 }
+
+// MoveBy moves the circle by the offset
+func (circle *Circle) MoveBy(offset Point) {
+	circle.Center.X += offset.X
+	circle.Center.Y += offset.Y
+}
+
+// Size returns the size of the box of the circle
+func (circle *Circle) Size() Size {
+	return Size{Width: 2 * circle.Radius, Height: 2 * circle.Radius}
+}
+
+// Resize resizes the circle to fit the smaller side of the size
+func (circle *Circle) Resize(size Size) {
+	corner := Point{X: circle.Center.X - circle.Radius, Y: circle.Center.Y - circle.Radius}
+	circle.Radius = math.Min(size.Width, size.Height) / 2
+	circle.Center = Point{X: corner.X + circle.Radius, Y: corner.Y + circle.Radius}
+}
+
+// CurrentStyle returns the style of the circle
+func (circle *Circle) CurrentStyle() *Style {
+	return circle.Style
+}
+
+// SetStyle sets the style of the circle
+func (circle *Circle) SetStyle(style *Style) {
+	circle.Style = style
+}
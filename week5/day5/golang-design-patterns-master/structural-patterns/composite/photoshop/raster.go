@@ -0,0 +1,333 @@
+package photoshop
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Raster drawer paints the shapes anti-aliased on an image. Every layer is
+// painted on a buffer of its own, then composed with the layers below.
+type Raster struct {
+	// Image painted on
+	Image *image.RGBA
+
+	style   Style
+	buffers []*image.RGBA
+	layers  []*Layer
+}
+
+// NewRaster creates a raster drawer of a transparent image of the size
+func NewRaster(width, height int) *Raster {
+	return &Raster{Image: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+// SetStyle sets the style of the shapes drawn next
+func (d *Raster) SetStyle(style Style) {
+	d.style = style
+}
+
+// DrawEllipseInRect draws an ellipse in rectangle
+func (d *Raster) DrawEllipseInRect(r Rect) error {
+	d.paint(r, ellipseCoverage)
+	return nil
+}
+
+// DrawRect draws rectangle
+func (d *Raster) DrawRect(r Rect) error {
+	d.paint(r, rectCoverage)
+	return nil
+}
+
+// DrawPath draws a path, filled by the nonzero winding rule
+func (d *Raster) DrawPath(path *Path) error {
+	subpaths := path.flatten()
+	if d.style.Fill != nil {
+		d.fill(path.polygons(), d.style.Fill)
+	}
+	if d.style.Stroke != nil && d.style.StrokeWidth > 0 {
+		d.fill(strokePolygons(subpaths, d.style.StrokeWidth), d.style.Stroke)
+	}
+	return nil
+}
+
+// DrawText draws a text run with the built-in font, in the fill color or
+// in the stroke color when there is no fill
+func (d *Raster) DrawText(text string, origin Point, font Font) error {
+	c := d.style.Fill
+	if c == nil {
+		c = d.style.Stroke
+	}
+	if c != nil {
+		d.fill(textPolygons(text, origin, font), c)
+	}
+	return nil
+}
+
+// BeginLayer paints the shapes drawn next on a transparent buffer
+func (d *Raster) BeginLayer(layer *Layer) error {
+	d.buffers = append(d.buffers, image.NewRGBA(d.Image.Bounds()))
+	d.layers = append(d.layers, layer)
+	return nil
+}
+
+// EndLayer filters the buffer of the last layer begun and composes it with
+// the image or the buffer below
+func (d *Raster) EndLayer() error {
+	n := len(d.buffers)
+	if n == 0 {
+		return ErrNoLayer
+	}
+
+	buffer, layer := d.buffers[n-1], d.layers[n-1]
+	d.buffers, d.layers = d.buffers[:n-1], d.layers[:n-1]
+	buffer = applyFilters(buffer, layer.Filters)
+	compose(d.target(), buffer, layer.Operator, layer.BlendMode, layer.opacity())
+	return nil
+}
+
+// target returns the buffer of the last layer begun, or the image
+func (d *Raster) target() *image.RGBA {
+	if n := len(d.buffers); n > 0 {
+		return d.buffers[n-1]
+	}
+	return d.Image
+}
+
+// coverage returns the fraction of the pixel at x, y inside the shape
+// fitting the rectangle
+type coverage func(r Rect, x, y int) float64
+
+// paint fills and strokes the shape fitting the rectangle on the target
+// with the style, anti-aliased by its coverage of the pixels
+func (d *Raster) paint(r Rect, shape coverage) {
+	half := 0.0
+	if d.style.Stroke != nil {
+		half = d.style.StrokeWidth / 2
+	}
+	outer, inner := inset(r, -half), inset(r, half)
+
+	img := d.target()
+	bounds := image.Rect(
+		int(math.Floor(outer.Location.X)), int(math.Floor(outer.Location.Y)),
+		int(math.Ceil(outer.Location.X+outer.Size.Width)), int(math.Ceil(outer.Location.Y+outer.Size.Height)),
+	).Intersect(img.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if d.style.Fill != nil {
+				paintPixel(img, x, y, d.style.Fill, shape(r, x, y))
+			}
+			if d.style.Stroke != nil && half > 0 {
+				ring := shape(outer, x, y)
+				if inner.Size.Width > 0 && inner.Size.Height > 0 {
+					ring -= shape(inner, x, y)
+				}
+				paintPixel(img, x, y, d.style.Stroke, ring)
+			}
+		}
+	}
+}
+
+// paintPixel paints the color over the pixel of the image at x, y by the
+// coverage
+func paintPixel(img *image.RGBA, x, y int, c color.Color, cover float64) {
+	if cover <= 0 {
+		return
+	}
+
+	r, g, b, a := c.RGBA()
+	k := math.Min(1, cover) / 0xffff
+	src := [4]float64{float64(r) * k, float64(g) * k, float64(b) * k, float64(a) * k}
+
+	pix := img.Pix[img.PixOffset(x, y):][:4:4]
+	for ch := range pix {
+		v := src[ch] + float64(pix[ch])/0xff*(1-src[3])
+		pix[ch] = uint8(math.Min(1, v)*0xff + 0.5)
+	}
+}
+
+// rectCoverage returns the area of the pixel at x, y inside the rectangle
+func rectCoverage(r Rect, x, y int) float64 {
+	w := overlap(float64(x), r.Location.X, r.Location.X+r.Size.Width)
+	h := overlap(float64(y), r.Location.Y, r.Location.Y+r.Size.Height)
+	return w * h
+}
+
+// overlap returns the length of the unit segment from v within from and to
+func overlap(v, from, to float64) float64 {
+	return math.Max(0, math.Min(v+1, to)-math.Max(v, from))
+}
+
+// ellipseSamples is the number of samples per pixel side of the coverage of
+// an ellipse
+const ellipseSamples = 4
+
+// ellipseCoverage returns the fraction of the samples of the pixel at x, y
+// inside the ellipse fitting the rectangle
+func ellipseCoverage(r Rect, x, y int) float64 {
+	rx, ry := r.Size.Width/2, r.Size.Height/2
+	if rx <= 0 || ry <= 0 {
+		return 0
+	}
+	cx, cy := r.Location.X+rx, r.Location.Y+ry
+
+	inside := 0
+	for sy := 0; sy < ellipseSamples; sy++ {
+		for sx := 0; sx < ellipseSamples; sx++ {
+			dx := (float64(x) + (float64(sx)+0.5)/ellipseSamples - cx) / rx
+			dy := (float64(y) + (float64(sy)+0.5)/ellipseSamples - cy) / ry
+			if dx*dx+dy*dy <= 1 {
+				inside++
+			}
+		}
+	}
+	return float64(inside) / (ellipseSamples * ellipseSamples)
+}
+
+// inset returns the rectangle shrunk by the distance on every side
+func inset(r Rect, d float64) Rect {
+	return Rect{
+		Location: Point{X: r.Location.X + d, Y: r.Location.Y + d},
+		Size:     Size{Width: r.Size.Width - 2*d, Height: r.Size.Height - 2*d},
+	}
+}
+
+// scanlineSamples is the number of rows sampled per pixel when polygons are
+// filled
+const scanlineSamples = 4
+
+// crossing is where an edge of a polygon crosses a sampled row
+type crossing struct {
+	x       float64
+	winding int
+}
+
+// fill paints the polygons, filled by the nonzero winding rule, on the
+// target with the color, anti-aliased by their coverage of the pixels
+func (d *Raster) fill(polygons [][]Point, c color.Color) {
+	var points []Point
+	for _, polygon := range polygons {
+		points = append(points, polygon...)
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	img := d.target()
+	box := pointBounds(points)
+	bounds := image.Rect(
+		int(math.Floor(box.Location.X)), int(math.Floor(box.Location.Y)),
+		int(math.Ceil(box.Max().X)), int(math.Ceil(box.Max().Y)),
+	).Intersect(img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	coverage := make([]float64, bounds.Dx())
+	var crossings []crossing
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := range coverage {
+			coverage[x] = 0
+		}
+
+		for sample := 0; sample < scanlineSamples; sample++ {
+			sy := float64(y) + (float64(sample)+0.5)/scanlineSamples
+			crossings = crossings[:0]
+			for _, polygon := range polygons {
+				for i, p0 := range polygon {
+					p1 := polygon[(i+1)%len(polygon)]
+					if (p0.Y <= sy) == (p1.Y <= sy) {
+						continue
+					}
+					winding := 1
+					if p1.Y < p0.Y {
+						winding = -1
+					}
+					crossings = append(crossings, crossing{x: p0.X + (sy-p0.Y)*(p1.X-p0.X)/(p1.Y-p0.Y), winding: winding})
+				}
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding, start := 0, 0.0
+			for _, cross := range crossings {
+				if winding == 0 {
+					start = cross.x
+				}
+				winding += cross.winding
+				if winding == 0 {
+					for x := int(math.Floor(start)); x < int(math.Ceil(cross.x)); x++ {
+						if x >= bounds.Min.X && x < bounds.Max.X {
+							coverage[x-bounds.Min.X] += overlap(float64(x), start, cross.x) / scanlineSamples
+						}
+					}
+				}
+			}
+		}
+
+		for x, cover := range coverage {
+			paintPixel(img, bounds.Min.X+x, y, c, cover)
+		}
+	}
+}
+
+// strokePolygons returns the polygons covering the subpaths stroked with the
+// width, every segment a quad and every vertex a disc for round joins and
+// caps. They are all oriented alike, so their overlaps add up.
+func strokePolygons(subpaths []subpath, width float64) [][]Point {
+	half := width / 2
+	var polygons [][]Point
+	for _, sub := range subpaths {
+		points := sub.points
+		if sub.closed {
+			points = append(append([]Point(nil), points...), points[0])
+		}
+
+		for i, p := range points {
+			polygons = append(polygons, orient(ellipsePolygon(Rect{
+				Location: Point{X: p.X - half, Y: p.Y - half},
+				Size:     Size{Width: width, Height: width},
+			})))
+			if i == 0 {
+				continue
+			}
+
+			q := points[i-1]
+			length := math.Hypot(p.X-q.X, p.Y-q.Y)
+			if length == 0 {
+				continue
+			}
+			nx, ny := -(p.Y-q.Y)/length*half, (p.X-q.X)/length*half
+			polygons = append(polygons, orient([]Point{
+				{X: q.X + nx, Y: q.Y + ny},
+				{X: p.X + nx, Y: p.Y + ny},
+				{X: p.X - nx, Y: p.Y - ny},
+				{X: q.X - nx, Y: q.Y - ny},
+			}))
+		}
+	}
+	return polygons
+}
+
+// orient returns the polygon with a positive signed area so that
+// overlapping polygons add up instead of cancelling out
+func orient(points []Point) []Point {
+	if signedArea(points) < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	return points
+}
+
+// signedArea returns the area of the polygon, positive when it turns
+// clockwise on the screen
+func signedArea(points []Point) float64 {
+	area := 0.0
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area / 2
+}
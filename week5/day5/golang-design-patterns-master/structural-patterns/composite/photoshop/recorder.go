@@ -0,0 +1,127 @@
+package photoshop
+
+// Op is the drawing operation of a recorded command
+type Op uint8
+
+const (
+	// OpSetStyle sets the style
+	OpSetStyle Op = iota + 1
+	// OpEllipse draws an ellipse in rectangle
+	OpEllipse
+	// OpRect draws rectangle
+	OpRect
+	// OpPath draws a path
+	OpPath
+	// OpText draws a text run
+	OpText
+	// OpBeginLayer begins a layer
+	OpBeginLayer
+	// OpEndLayer ends the last layer begun
+	OpEndLayer
+)
+
+// Command is a recorded drawing call
+type Command struct {
+	// Op of the call
+	Op Op
+	// Style of OpSetStyle
+	Style Style
+	// Rect of OpEllipse and OpRect
+	Rect Rect
+	// Path of OpPath
+	Path *Path
+	// Text of OpText
+	Text string
+	// Origin of OpText
+	Origin Point
+	// Font of OpText
+	Font Font
+	// Layer of OpBeginLayer
+	Layer *Layer
+}
+
+// Play makes the call of the command on the drawer
+func (c Command) Play(d Drawer) error {
+	switch c.Op {
+	case OpSetStyle:
+		d.SetStyle(c.Style)
+		return nil
+	case OpEllipse:
+		return d.DrawEllipseInRect(c.Rect)
+	case OpRect:
+		return d.DrawRect(c.Rect)
+	case OpPath:
+		return d.DrawPath(c.Path)
+	case OpText:
+		return d.DrawText(c.Text, c.Origin, c.Font)
+	case OpBeginLayer:
+		return d.BeginLayer(c.Layer)
+	case OpEndLayer:
+		return d.EndLayer()
+	default:
+		return nil
+	}
+}
+
+// Recorder drawer records the calls made on it, to be inspected or played
+// on other drawers
+type Recorder struct {
+	// Commands recorded, in the order of the calls
+	Commands []Command
+}
+
+// SetStyle records setting the style
+func (r *Recorder) SetStyle(style Style) {
+	r.Commands = append(r.Commands, Command{Op: OpSetStyle, Style: style})
+}
+
+// DrawEllipseInRect records drawing an ellipse in rectangle
+func (r *Recorder) DrawEllipseInRect(rect Rect) error {
+	r.Commands = append(r.Commands, Command{Op: OpEllipse, Rect: rect})
+	return nil
+}
+
+// DrawRect records drawing rectangle
+func (r *Recorder) DrawRect(rect Rect) error {
+	r.Commands = append(r.Commands, Command{Op: OpRect, Rect: rect})
+	return nil
+}
+
+// DrawPath records drawing a path
+func (r *Recorder) DrawPath(path *Path) error {
+	r.Commands = append(r.Commands, Command{Op: OpPath, Path: path})
+	return nil
+}
+
+// DrawText records drawing a text run
+func (r *Recorder) DrawText(text string, origin Point, font Font) error {
+	r.Commands = append(r.Commands, Command{Op: OpText, Text: text, Origin: origin, Font: font})
+	return nil
+}
+
+// BeginLayer records beginning the layer
+func (r *Recorder) BeginLayer(layer *Layer) error {
+	r.Commands = append(r.Commands, Command{Op: OpBeginLayer, Layer: layer})
+	return nil
+}
+
+// EndLayer records ending the last layer begun
+func (r *Recorder) EndLayer() error {
+	r.Commands = append(r.Commands, Command{Op: OpEndLayer})
+	return nil
+}
+
+// Reset drops the recorded commands
+func (r *Recorder) Reset() {
+	r.Commands = nil
+}
+
+// Play makes the recorded calls on the drawer
+func (r *Recorder) Play(d Drawer) error {
+	for _, c := range r.Commands {
+		if err := c.Play(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package photoshop
+
+import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// drawersDocument returns a document of a red square, a half transparent
+// group of a circle and a hidden group
+func drawersDocument() *Layer {
+	square := &Square{Side: 10, Style: &Style{Fill: color.RGBA{R: 0xff, A: 0xff}}}
+
+	group := NewLayer("Group", &Circle{Center: Point{X: 5, Y: 5}, Radius: 3})
+	group.Opacity = 0.5
+
+	hidden := NewLayer("Hidden", square)
+	hidden.Hidden = true
+
+	return NewLayer("Document", square, group, hidden)
+}
+
+func TestRecorder(t *testing.T) {
+	document := drawersDocument()
+
+	recorder := &Recorder{}
+	if err := document.Draw(recorder); err != nil {
+		t.Fatal(err)
+	}
+
+	var ops []Op
+	for _, command := range recorder.Commands {
+		ops = append(ops, command.Op)
+	}
+	want := []Op{OpBeginLayer, OpSetStyle, OpRect, OpBeginLayer, OpSetStyle, OpEllipse, OpEndLayer, OpEndLayer}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("recorded %v, want %v", ops, want)
+	}
+
+	img, err := Render(document, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := NewRaster(10, 10)
+	if err := recorder.Play(replayed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Image.Pix, img.Pix) {
+		t.Errorf("the replayed document differs from the rendered one")
+	}
+
+	// the black circle at half opacity over the red square
+	if c := img.RGBAAt(5, 5); c.R != 0x80 || c.G != 0 || c.A != 0xff {
+		t.Errorf("the center is %v", c)
+	}
+}
+
+func TestDrawers(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(document *Layer) (string, error)
+		want []string
+		not  []string
+	}{
+		{
+			name: "svg",
+			draw: func(document *Layer) (string, error) {
+				svg := NewSVG(10, 10)
+				err := document.Draw(svg)
+				return svg.String(), err
+			},
+			want: []string{`<g id="Document">`, `<g id="Group" opacity="0.5">`, "<rect", "<ellipse"},
+			not:  []string{`id="Hidden"`},
+		},
+		{
+			name: "printer",
+			draw: func(document *Layer) (string, error) {
+				var out bytes.Buffer
+				err := document.Draw(&Printer{Writer: &out})
+				return out.String(), err
+			},
+			want: []string{"Drawing rect {{0 0} {10 10}}\nDrawing ellipse in rect {{2 2} {6 6}}\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := test.draw(drawersDocument())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("%q is not drawn in\n%s", want, out)
+				}
+			}
+			for _, not := range test.not {
+				if strings.Contains(out, not) {
+					t.Errorf("%q is drawn in\n%s", not, out)
+				}
+			}
+		})
+	}
+}
+
+func TestEndLayerWithoutLayer(t *testing.T) {
+	for name, drawer := range map[string]Drawer{"raster": NewRaster(10, 10), "svg": NewSVG(10, 10)} {
+		if err := drawer.EndLayer(); err != ErrNoLayer {
+			t.Errorf("the %s drawer ended no layer with %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package photoshop
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// registry maps the kinds of the values of an interface documents encode,
+// such as the visual elements, to the types of the values
+type registry struct {
+	sync.RWMutex
+
+	// name of the values, in the errors
+	name      string
+	factories map[string]func() interface{}
+	kinds     map[reflect.Type]string
+}
+
+// elementKinds registers the kinds of the visual elements
+var elementKinds = newRegistry("element")
+
+func init() {
+	RegisterElement("layer", func() VisualElement { return &Layer{} })
+	RegisterElement("square", func() VisualElement { return &Square{} })
+	RegisterElement("circle", func() VisualElement { return &Circle{} })
+	RegisterElement("rectangle", func() VisualElement { return &Rectangle{} })
+	RegisterElement("ellipse", func() VisualElement { return &Ellipse{} })
+	RegisterElement("polygon", func() VisualElement { return &Polygon{} })
+	RegisterElement("star", func() VisualElement { return &Star{} })
+	RegisterElement("path", func() VisualElement { return &PathElement{} })
+	RegisterElement("text", func() VisualElement { return &Text{} })
+}
+
+// RegisterElement registers the kind of the elements the factory makes, so
+// documents encode and decode them. The elements are encoded with
+// encoding/json, their kind added as the "type" field. It panics when the
+// kind or the type of the elements is registered already.
+func RegisterElement(kind string, factory func() VisualElement) {
+	elementKinds.register(kind, func() interface{} { return factory() })
+}
+
+// encodeElement returns the JSON encoding of the element with its kind
+func encodeElement(element VisualElement) (json.RawMessage, error) {
+	return elementKinds.encode(element)
+}
+
+// decodeElement returns the element of the JSON encoding, made by the
+// factory of its kind
+func decodeElement(data json.RawMessage) (VisualElement, error) {
+	value, err := elementKinds.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return value.(VisualElement), nil
+}
+
+// newRegistry creates an empty registry of the values of the name
+func newRegistry(name string) *registry {
+	return &registry{
+		name:      name,
+		factories: map[string]func() interface{}{},
+		kinds:     map[reflect.Type]string{},
+	}
+}
+
+// register registers the kind of the values the factory makes
+func (r *registry) register(kind string, factory func() interface{}) {
+	r.Lock()
+	defer r.Unlock()
+
+	t := reflect.TypeOf(factory())
+	if _, ok := r.factories[kind]; ok {
+		panic(fmt.Sprintf("photoshop: %s kind %q registered twice", r.name, kind))
+	}
+	if _, ok := r.kinds[t]; ok {
+		panic(fmt.Sprintf("photoshop: %s type %v registered twice", r.name, t))
+	}
+
+	r.factories[kind] = factory
+	r.kinds[t] = kind
+}
+
+// encode returns the JSON encoding of the value with its kind
+func (r *registry) encode(value interface{}) (json.RawMessage, error) {
+	r.RLock()
+	kind, ok := r.kinds[reflect.TypeOf(value)]
+	r.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unregistered %s type %T", r.name, value)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != '{' {
+		return nil, fmt.Errorf("The %s type %T is not encoded as a JSON object", r.name, value)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, `{"type":%q`, kind)
+	if len(data) > 2 {
+		out.WriteByte(',')
+	}
+	out.Write(data[1:])
+	return out.Bytes(), nil
+}
+
+// decode returns the value of the JSON encoding, made by the factory of its
+// kind
+func (r *registry) decode(data json.RawMessage) (interface{}, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	r.RLock()
+	factory, ok := r.factories[header.Type]
+	r.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown %s type %q", r.name, header.Type)
+	}
+
+	value := factory()
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,164 @@
+package photoshop
+
+// Selection is the elements selected in the tree of a layer. Layers are
+// selected as a whole, with the elements of their group.
+type Selection struct {
+	// Root layer of the tree the elements are selected in
+	Root *Layer
+
+	elements []VisualElement
+}
+
+// NewSelection creates an empty selection in the tree of the layer
+func NewSelection(root *Layer) *Selection {
+	return &Selection{Root: root}
+}
+
+// Elements returns the selected elements in the order they were selected
+func (s *Selection) Elements() []VisualElement {
+	return s.elements
+}
+
+// Topmost returns the selected elements which aren't in a selected layer,
+// in the order they were selected. Edits of the whole selection apply to
+// them, so that the elements of a selected layer are edited once.
+func (s *Selection) Topmost() []VisualElement {
+	var elements []VisualElement
+	for _, element := range s.elements {
+		if !s.inSelectedLayer(element) {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// inSelectedLayer tells whether a layer above the element is selected
+func (s *Selection) inSelectedLayer(element VisualElement) bool {
+	for _, layer := range s.Root.Path(element) {
+		if s.Contains(layer) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of selected elements
+func (s *Selection) Len() int {
+	return len(s.elements)
+}
+
+// Contains tells whether the element is selected
+func (s *Selection) Contains(element VisualElement) bool {
+	for _, selected := range s.elements {
+		if selected == element {
+			return true
+		}
+	}
+	return false
+}
+
+// Select selects only the elements of the tree
+func (s *Selection) Select(elements ...VisualElement) error {
+	s.Clear()
+	return s.Add(elements...)
+}
+
+// Add adds the elements of the tree to the selection
+func (s *Selection) Add(elements ...VisualElement) error {
+	for _, element := range elements {
+		if s.Root.Path(element) == nil {
+			return ErrNotFound
+		}
+	}
+
+	for _, element := range elements {
+		if !s.Contains(element) {
+			s.elements = append(s.elements, element)
+		}
+	}
+	return nil
+}
+
+// Remove drops the elements from the selection
+func (s *Selection) Remove(elements ...VisualElement) {
+	for _, element := range elements {
+		for i, selected := range s.elements {
+			if selected == element {
+				s.elements = append(s.elements[:i:i], s.elements[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Toggle selects the element when it isn't selected, otherwise drops it
+func (s *Selection) Toggle(element VisualElement) error {
+	if s.Contains(element) {
+		s.Remove(element)
+		return nil
+	}
+	return s.Add(element)
+}
+
+// Clear drops all the elements from the selection
+func (s *Selection) Clear() {
+	s.elements = nil
+}
+
+// Prune drops the elements which aren't in the tree anymore
+func (s *Selection) Prune() {
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		if s.Root.Path(s.elements[i]) == nil {
+			s.elements = append(s.elements[:i:i], s.elements[i+1:]...)
+		}
+	}
+}
+
+// Bounds returns the rectangle of the selected elements, empty when none is
+// selected
+func (s *Selection) Bounds() Rect {
+	var bounds Rect
+	for _, element := range s.elements {
+		bounds = bounds.Union(element.Bounds())
+	}
+	return bounds
+}
+
+// SelectAt selects only the topmost element of the tree at the point. The
+// elements of a layer are selected as the layer, unless it is the root. It
+// returns the element selected, or nil when there is none at the point.
+func (s *Selection) SelectAt(p Point) VisualElement {
+	s.Clear()
+	for i := len(s.Root.Elements) - 1; i >= 0; i-- {
+		element := s.Root.Elements[i]
+		if layer, ok := element.(*Layer); ok && (layer.Hidden || layer.ElementAt(p) == nil) {
+			continue
+		}
+		if element.Bounds().Contains(p) {
+			s.elements = append(s.elements, element)
+			return element
+		}
+	}
+	return nil
+}
+
+// ElementAt returns the topmost visible element of the tree of the layer,
+// layers excluded, whose bounds contain the point, or nil
+func (layer *Layer) ElementAt(p Point) VisualElement {
+	for i := len(layer.Elements) - 1; i >= 0; i-- {
+		element := layer.Elements[i]
+		if group, ok := element.(*Layer); ok {
+			if group.Hidden {
+				continue
+			}
+			if found := group.ElementAt(p); found != nil {
+				return found
+			}
+			continue
+		}
+		if element.Bounds().Contains(p) {
+			return element
+		}
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Rectangle represents a rectangle of any size
+type Rectangle struct {
+	// Rect of the rectangle
+	Rect Rect `json:"rect"`
+	// Style of the rectangle, the default style when nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws a rectangle
+func (rectangle *Rectangle) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(rectangle.Style))
+	return drawer.DrawRect(rectangle.Rect)
+}
+
+// Bounds returns the rectangle of the painted rectangle
+func (rectangle *Rectangle) Bounds() Rect {
+	return strokeBounds(rectangle.Rect, rectangle.Style)
+}
+
+// MoveBy moves the rectangle by the offset
+func (rectangle *Rectangle) MoveBy(offset Point) {
+	rectangle.Rect.Location = translate(rectangle.Rect.Location, offset)
+}
+
+// Size returns the size of the rectangle
+func (rectangle *Rectangle) Size() Size {
+	return rectangle.Rect.Size
+}
+
+// Resize resizes the rectangle
+func (rectangle *Rectangle) Resize(size Size) {
+	rectangle.Rect.Size = size
+}
+
+// CurrentStyle returns the style of the rectangle
+func (rectangle *Rectangle) CurrentStyle() *Style {
+	return rectangle.Style
+}
+
+// SetStyle sets the style of the rectangle
+func (rectangle *Rectangle) SetStyle(style *Style) {
+	rectangle.Style = style
+}
+
+// Ellipse represents an ellipse shape
+type Ellipse struct {
+	// Center of the ellipse
+	Center Point `json:"center"`
+	// RadiusX is the horizontal radius
+	RadiusX float64 `json:"radius_x"`
+	// RadiusY is the vertical radius
+	RadiusY float64 `json:"radius_y"`
+	// Style of the ellipse, the default style when nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws an ellipse
+func (ellipse *Ellipse) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(ellipse.Style))
+	return drawer.DrawEllipseInRect(ellipse.box())
+}
+
+// Bounds returns the rectangle of the painted ellipse
+func (ellipse *Ellipse) Bounds() Rect {
+	return strokeBounds(ellipse.box(), ellipse.Style)
+}
+
+// MoveBy moves the ellipse by the offset
+func (ellipse *Ellipse) MoveBy(offset Point) {
+	ellipse.Center = translate(ellipse.Center, offset)
+}
+
+// Size returns the size of the box of the ellipse
+func (ellipse *Ellipse) Size() Size {
+	return ellipse.box().Size
+}
+
+// Resize resizes the ellipse to fit the size
+func (ellipse *Ellipse) Resize(size Size) {
+	corner := ellipse.box().Location
+	ellipse.RadiusX, ellipse.RadiusY = size.Width/2, size.Height/2
+	ellipse.Center = Point{X: corner.X + ellipse.RadiusX, Y: corner.Y + ellipse.RadiusY}
+}
+
+// CurrentStyle returns the style of the ellipse
+func (ellipse *Ellipse) CurrentStyle() *Style {
+	return ellipse.Style
+}
+
+// SetStyle sets the style of the ellipse
+func (ellipse *Ellipse) SetStyle(style *Style) {
+	ellipse.Style = style
+}
+
+// box returns the rectangle the ellipse is inscribed in
+func (ellipse *Ellipse) box() Rect {
+	return Rect{
+		Location: Point{X: ellipse.Center.X - ellipse.RadiusX, Y: ellipse.Center.Y - ellipse.RadiusY},
+		Size:     Size{Width: 2 * ellipse.RadiusX, Height: 2 * ellipse.RadiusY},
+	}
+}
+
+// Polygon represents a closed polygon
+type Polygon struct {
+	// Points of the vertices of the polygon
+	Points []Point `json:"points"`
+	// Style of the polygon, the default style when nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws a polygon
+func (polygon *Polygon) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(polygon.Style))
+	return drawer.DrawPath(polygonPath([][]Point{polygon.Points}))
+}
+
+// Bounds returns the rectangle of the painted polygon
+func (polygon *Polygon) Bounds() Rect {
+	return strokeBounds(pointBounds(polygon.Points), polygon.Style)
+}
+
+// MoveBy moves the polygon by the offset
+func (polygon *Polygon) MoveBy(offset Point) {
+	for i := range polygon.Points {
+		polygon.Points[i] = translate(polygon.Points[i], offset)
+	}
+}
+
+// Size returns the size of the rectangle of the vertices of the polygon
+func (polygon *Polygon) Size() Size {
+	return pointBounds(polygon.Points).Size
+}
+
+// Resize scales the polygon to the size
+func (polygon *Polygon) Resize(size Size) {
+	from := pointBounds(polygon.Points)
+	for i, p := range polygon.Points {
+		polygon.Points[i] = scalePoint(p, from, size)
+	}
+}
+
+// CurrentStyle returns the style of the polygon
+func (polygon *Polygon) CurrentStyle() *Style {
+	return polygon.Style
+}
+
+// SetStyle sets the style of the polygon
+func (polygon *Polygon) SetStyle(style *Style) {
+	polygon.Style = style
+}
+
+// maxStarPoints is the most points a star is drawn with, the stars of more
+// points are drawn with as many
+const maxStarPoints = 1024
+
+// Star represents a star of points alternating between an outer and an
+// inner circle
+type Star struct {
+	// Center of the star
+	Center Point `json:"center"`
+	// Points of the star, up to 1024
+	Points int `json:"points"`
+	// OuterRadius is the radius of the tips
+	OuterRadius float64 `json:"outer_radius"`
+	// InnerRadius is the radius of the notches between the tips
+	InnerRadius float64 `json:"inner_radius"`
+	// Rotation clockwise in degrees, the first tip is straight up when zero
+	Rotation float64 `json:"rotation,omitempty"`
+	// Style of the star, the default style when nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws a star
+func (star *Star) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(star.Style))
+	return drawer.DrawPath(polygonPath([][]Point{star.vertices()}))
+}
+
+// Bounds returns the rectangle of the painted star
+func (star *Star) Bounds() Rect {
+	return strokeBounds(pointBounds(star.vertices()), star.Style)
+}
+
+// MoveBy moves the star by the offset
+func (star *Star) MoveBy(offset Point) {
+	star.Center = translate(star.Center, offset)
+}
+
+// Size returns the size of the box of the outer circle of the star
+func (star *Star) Size() Size {
+	return Size{Width: 2 * star.OuterRadius, Height: 2 * star.OuterRadius}
+}
+
+// Resize scales the star so that its outer circle fits the smaller side of
+// the size
+func (star *Star) Resize(size Size) {
+	corner := Point{X: star.Center.X - star.OuterRadius, Y: star.Center.Y - star.OuterRadius}
+	outer := math.Min(size.Width, size.Height) / 2
+	if star.OuterRadius != 0 {
+		star.InnerRadius *= outer / star.OuterRadius
+	}
+	star.OuterRadius = outer
+	star.Center = Point{X: corner.X + outer, Y: corner.Y + outer}
+}
+
+// CurrentStyle returns the style of the star
+func (star *Star) CurrentStyle() *Style {
+	return star.Style
+}
+
+// SetStyle sets the style of the star
+func (star *Star) SetStyle(style *Style) {
+	star.Style = style
+}
+
+// jsonStar is the JSON encoding of a star
+type jsonStar Star
+
+// UnmarshalJSON decodes a star, rejecting more points than it is drawn with
+func (star *Star) UnmarshalJSON(data []byte) error {
+	var in jsonStar
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Points > maxStarPoints {
+		return fmt.Errorf("Invalid star of %d points", in.Points)
+	}
+
+	*star = Star(in)
+	return nil
+}
+
+// vertices returns the tips and the notches of the star, of up to
+// maxStarPoints points
+func (star *Star) vertices() []Point {
+	n := star.Points
+	if n < 2 {
+		return nil
+	}
+	if n > maxStarPoints {
+		n = maxStarPoints
+	}
+
+	points := make([]Point, 2*n)
+	for i := range points {
+		radius := star.OuterRadius
+		if i%2 == 1 {
+			radius = star.InnerRadius
+		}
+		angle := (star.Rotation-90)*math.Pi/180 + float64(i)*math.Pi/float64(n)
+		sin, cos := math.Sincos(angle)
+		points[i] = Point{X: star.Center.X + radius*cos, Y: star.Center.Y + radius*sin}
+	}
+	return points
+}
+
+// PathElement represents a free path, such as the result of a boolean
+// operation
+type PathElement struct {
+	// Path of the element
+	Path *Path `json:"path"`
+	// Style of the path, the default style when nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws a path
+func (element *PathElement) Draw(drawer Drawer) error {
+	if element.Path == nil {
+		return nil
+	}
+	drawer.SetStyle(styleOrDefault(element.Style))
+	return drawer.DrawPath(element.Path)
+}
+
+// Bounds returns the rectangle of the control points of the painted path,
+// which contains its curves
+func (element *PathElement) Bounds() Rect {
+	return strokeBounds(pointBounds(element.points()), element.Style)
+}
+
+// MoveBy moves the path by the offset
+func (element *PathElement) MoveBy(offset Point) {
+	element.mapPoints(func(p Point) Point { return translate(p, offset) })
+}
+
+// Size returns the size of the rectangle of the control points of the path
+func (element *PathElement) Size() Size {
+	return pointBounds(element.points()).Size
+}
+
+// Resize scales the path to the size
+func (element *PathElement) Resize(size Size) {
+	from := pointBounds(element.points())
+	element.mapPoints(func(p Point) Point { return scalePoint(p, from, size) })
+}
+
+// CurrentStyle returns the style of the path
+func (element *PathElement) CurrentStyle() *Style {
+	return element.Style
+}
+
+// SetStyle sets the style of the path
+func (element *PathElement) SetStyle(style *Style) {
+	element.Style = style
+}
+
+// points returns the points of the segments of the path
+func (element *PathElement) points() []Point {
+	if element.Path == nil {
+		return nil
+	}
+
+	var points []Point
+	for _, segment := range element.Path.Segments {
+		points = append(points, segment.Points...)
+	}
+	return points
+}
+
+// mapPoints replaces the points of the segments of the path by fn of them
+func (element *PathElement) mapPoints(fn func(Point) Point) {
+	if element.Path == nil {
+		return
+	}
+
+	for _, segment := range element.Path.Segments {
+		for i, p := range segment.Points {
+			segment.Points[i] = fn(p)
+		}
+	}
+}
+
+// Text represents a text run
+type Text struct {
+	// Text of the run
+	Text string `json:"text"`
+	// Origin is the top left corner of the text
+	Origin Point `json:"origin"`
+	// Font of the text
+	Font Font `json:"font"`
+	// Style of the text, filled with the fill color, the default style when
+	// nil
+	Style *Style `json:"style,omitempty"`
+}
+
+// Draw draws a text
+func (text *Text) Draw(drawer Drawer) error {
+	drawer.SetStyle(styleOrDefault(text.Style))
+	return drawer.DrawText(text.Text, text.Origin, text.Font)
+}
+
+// Bounds returns the box of the text
+func (text *Text) Bounds() Rect {
+	return Rect{Location: text.Origin, Size: MeasureText(text.Text, text.Font)}
+}
+
+// MoveBy moves the text by the offset
+func (text *Text) MoveBy(offset Point) {
+	text.Origin = translate(text.Origin, offset)
+}
+
+// CurrentStyle returns the style of the text
+func (text *Text) CurrentStyle() *Style {
+	return text.Style
+}
+
+// SetStyle sets the style of the text
+func (text *Text) SetStyle(style *Style) {
+	text.Style = style
+}
+
+// translate returns the point moved by the offset
+func translate(p, offset Point) Point {
+	return Point{X: p.X + offset.X, Y: p.Y + offset.Y}
+}
+
+// scalePoint returns the point of the rectangle scaled to the size,
+// keeping its top left corner
+func scalePoint(p Point, from Rect, to Size) Point {
+	sx, sy := 1.0, 1.0
+	if from.Size.Width != 0 {
+		sx = to.Width / from.Size.Width
+	}
+	if from.Size.Height != 0 {
+		sy = to.Height / from.Size.Height
+	}
+
+	return Point{
+		X: from.Location.X + (p.X-from.Location.X)*sx,
+		Y: from.Location.Y + (p.Y-from.Location.Y)*sy,
+	}
+}
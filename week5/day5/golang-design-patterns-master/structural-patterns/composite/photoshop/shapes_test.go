@@ -0,0 +1,73 @@
+package photoshop
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStarVertices(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   int
+		vertices int
+	}{
+		{"five points", 5, 10},
+		{"too few points", 1, 0},
+		{"negative", -3, 0},
+		{"too many points", math.MaxInt32, 2 * maxStarPoints},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			star := &Star{Center: Point{X: 10, Y: 10}, Points: test.points, OuterRadius: 8, InnerRadius: 4}
+			vertices := star.vertices()
+			if len(vertices) != test.vertices {
+				t.Fatalf("the star has %d vertices, want %d", len(vertices), test.vertices)
+			}
+
+			for i, v := range vertices {
+				radius := star.OuterRadius
+				if i%2 == 1 {
+					radius = star.InnerRadius
+				}
+				if d := math.Hypot(v.X-star.Center.X, v.Y-star.Center.Y); math.Abs(d-radius) > 1e-9 {
+					t.Fatalf("vertex %d is %g from the center, want %g", i, d, radius)
+				}
+			}
+		})
+	}
+}
+
+func TestStarUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		points int
+		err    string
+	}{
+		{name: "star", data: `{"center":{"x":1,"y":2},"points":7,"outer_radius":5}`, points: 7},
+		{name: "most points", data: `{"points":1024}`, points: maxStarPoints},
+		{name: "too many points", data: `{"points":1000000000000}`, err: "star of 1000000000000 points"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var star Star
+			err := json.Unmarshal([]byte(test.data), &star)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error is %v, want one about %s", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if star.Points != test.points {
+				t.Errorf("the star has %d points, want %d", star.Points, test.points)
+			}
+		})
+	}
+}
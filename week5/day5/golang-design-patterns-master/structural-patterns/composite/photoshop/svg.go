@@ -0,0 +1,174 @@
+package photoshop
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SVG drawer writes the shapes as a scalable vector graphics document.
+// Layers are groups with their opacity and blend mode. SVG has no
+// Porter-Duff operators for groups, so every layer is painted over the ones
+// below, and the filters of the layers are left out.
+type SVG struct {
+	// Width of the document
+	Width float64
+	// Height of the document
+	Height float64
+
+	style Style
+	depth int
+	body  bytes.Buffer
+}
+
+// NewSVG creates a SVG drawer of a document of the size
+func NewSVG(width, height float64) *SVG {
+	return &SVG{Width: width, Height: height}
+}
+
+// SetStyle sets the style of the shapes drawn next
+func (s *SVG) SetStyle(style Style) {
+	s.style = style
+}
+
+// DrawEllipseInRect draws an ellipse in rectangle
+func (s *SVG) DrawEllipseInRect(r Rect) error {
+	rx, ry := r.Size.Width/2, r.Size.Height/2
+	s.element(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`,
+		svgNumber(r.Location.X+rx), svgNumber(r.Location.Y+ry),
+		svgNumber(math.Abs(rx)), svgNumber(math.Abs(ry)), s.paint())
+	return nil
+}
+
+// DrawRect draws rectangle
+func (s *SVG) DrawRect(r Rect) error {
+	s.element(`<rect x="%s" y="%s" width="%s" height="%s"%s/>`,
+		svgNumber(r.Location.X), svgNumber(r.Location.Y),
+		svgNumber(r.Size.Width), svgNumber(r.Size.Height), s.paint())
+	return nil
+}
+
+// DrawPath draws a path
+func (s *SVG) DrawPath(path *Path) error {
+	s.element(`<path d="%s"%s/>`, svgPathData(path), s.paint())
+	return nil
+}
+
+// DrawText draws a text run with the fill color, or with the stroke color
+// when there is no fill
+func (s *SVG) DrawText(text string, origin Point, font Font) error {
+	fill := s.style.Fill
+	if fill == nil {
+		fill = s.style.Stroke
+	}
+	if fill == nil {
+		return nil
+	}
+
+	s.element(`<text x="%s" y="%s" font-family="%s" font-size="%s" dominant-baseline="hanging"%s>%s</text>`,
+		svgNumber(origin.X), svgNumber(origin.Y), html.EscapeString(font.Family), svgNumber(font.Size),
+		svgPaint("fill", fill), html.EscapeString(text))
+	return nil
+}
+
+// BeginLayer opens a group of the layer
+func (s *SVG) BeginLayer(layer *Layer) error {
+	var attrs string
+	if layer.Name != "" {
+		attrs += ` id="` + html.EscapeString(layer.Name) + `"`
+	}
+	if opacity := layer.opacity(); opacity < 1 {
+		attrs += ` opacity="` + svgNumber(opacity) + `"`
+	}
+	if layer.BlendMode != BlendNormal {
+		attrs += ` style="mix-blend-mode:` + layer.BlendMode.String() + `"`
+	}
+
+	s.element(`<g%s>`, attrs)
+	s.depth++
+	return nil
+}
+
+// EndLayer closes the group of the last layer begun
+func (s *SVG) EndLayer() error {
+	if s.depth == 0 {
+		return ErrNoLayer
+	}
+	s.depth--
+	s.element("</g>")
+	return nil
+}
+
+// WriteTo writes the SVG document
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`+"\n",
+		svgNumber(s.Width), svgNumber(s.Height))
+	doc.Write(s.body.Bytes())
+	doc.WriteString(strings.Repeat("</g>\n", s.depth))
+	doc.WriteString("</svg>\n")
+	return doc.WriteTo(w)
+}
+
+// String returns the SVG document
+func (s *SVG) String() string {
+	var buf bytes.Buffer
+	s.WriteTo(&buf)
+	return buf.String()
+}
+
+// element writes an element to the body, indented by the depth of the
+// layers
+func (s *SVG) element(format string, args ...interface{}) {
+	s.body.WriteString(strings.Repeat("  ", s.depth+1))
+	fmt.Fprintf(&s.body, format, args...)
+	s.body.WriteByte('\n')
+}
+
+// paint returns the fill and stroke attributes of the style
+func (s *SVG) paint() string {
+	attrs := ` fill="none"`
+	if s.style.Fill != nil {
+		attrs = svgPaint("fill", s.style.Fill)
+	}
+	if s.style.Stroke != nil && s.style.StrokeWidth > 0 {
+		attrs += svgPaint("stroke", s.style.Stroke)
+		attrs += ` stroke-width="` + svgNumber(s.style.StrokeWidth) + `"`
+	}
+	return attrs
+}
+
+// svgPaint returns the attributes of the color of a fill or a stroke
+func svgPaint(attr string, c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	paint := fmt.Sprintf(` %s="#%02x%02x%02x"`, attr, n.R, n.G, n.B)
+	if n.A < 0xff {
+		paint += fmt.Sprintf(` %s-opacity="%s"`, attr, svgNumber(float64(n.A)/0xff))
+	}
+	return paint
+}
+
+// svgPathData returns the data attribute of a path
+func svgPathData(path *Path) string {
+	commands := map[PathOp]string{PathMoveTo: "M", PathLineTo: "L", PathQuadTo: "Q", PathCubicTo: "C", PathClose: "Z"}
+
+	var data []string
+	for _, segment := range path.Segments {
+		command := commands[segment.Op]
+		for _, p := range segment.Points {
+			command += svgNumber(p.X) + "," + svgNumber(p.Y) + " "
+		}
+		data = append(data, strings.TrimSpace(command))
+	}
+	return strings.Join(data, " ")
+}
+
+// svgNumber formats a coordinate with at most three decimals
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
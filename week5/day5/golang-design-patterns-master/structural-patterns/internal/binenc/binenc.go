@@ -0,0 +1,209 @@
+// Package binenc writes and reads the varints and little-endian numbers of
+// the binary forms of the drawing backends. Writers and readers keep their
+// first error and do nothing after it, so the forms are checked once at the
+// end.
+package binenc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Writer writes binary values, keeping the first error
+type Writer struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+	err     error
+}
+
+// NewWriter creates a buffered writer of binary values
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Err returns the first error
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Flush writes the buffered values and returns the first error
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// Raw writes the bytes as they are
+func (w *Writer) Raw(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+
+// Byte writes a byte
+func (w *Writer) Byte(b byte) {
+	w.scratch[0] = b
+	w.Raw(w.scratch[:1])
+}
+
+// Uvarint writes an unsigned varint
+func (w *Writer) Uvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.Raw(w.scratch[:n])
+}
+
+// Varint writes a signed varint
+func (w *Writer) Varint(v int64) {
+	n := binary.PutVarint(w.scratch[:], v)
+	w.Raw(w.scratch[:n])
+}
+
+// Uint16 writes a little-endian 16-bit number
+func (w *Writer) Uint16(v uint16) {
+	binary.LittleEndian.PutUint16(w.scratch[:], v)
+	w.Raw(w.scratch[:2])
+}
+
+// Float writes the 8 little-endian bytes of a float
+func (w *Writer) Float(f float64) {
+	binary.LittleEndian.PutUint64(w.scratch[:], math.Float64bits(f))
+	w.Raw(w.scratch[:8])
+}
+
+// Bytes writes the length of the bytes followed by the bytes
+func (w *Writer) Bytes(p []byte) {
+	w.Uvarint(uint64(len(p)))
+	w.Raw(p)
+}
+
+// Reader reads binary values, keeping the first error
+type Reader struct {
+	r         *bufio.Reader
+	maxLength int
+	name      string
+	err       error
+}
+
+// NewReader creates a buffered reader of binary values whose lengths are at
+// most maxLength. The name of the form starts the errors of larger lengths.
+func NewReader(r io.Reader, maxLength int, name string) *Reader {
+	return &Reader{r: bufio.NewReader(r), maxLength: maxLength, name: name}
+}
+
+// Err returns the first error
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail keeps the error unless there is one already
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Raw reads n bytes as they are
+func (r *Reader) Raw(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	p := make([]byte, n)
+	_, err := io.ReadFull(r.r, p)
+	r.Fail(unexpectedEOF(err))
+	return p
+}
+
+// Byte reads a byte
+func (r *Reader) Byte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	b, err := r.r.ReadByte()
+	r.Fail(unexpectedEOF(err))
+	return b
+}
+
+// Uvarint reads an unsigned varint
+func (r *Reader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(r.r)
+	r.Fail(unexpectedEOF(err))
+	return v
+}
+
+// Varint reads a signed varint
+func (r *Reader) Varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadVarint(r.r)
+	r.Fail(unexpectedEOF(err))
+	return v
+}
+
+// Uint16 reads a little-endian 16-bit number
+func (r *Reader) Uint16() uint16 {
+	var scratch [2]byte
+	if r.err != nil {
+		return 0
+	}
+
+	_, err := io.ReadFull(r.r, scratch[:])
+	r.Fail(unexpectedEOF(err))
+	return binary.LittleEndian.Uint16(scratch[:])
+}
+
+// Float reads the 8 little-endian bytes of a float
+func (r *Reader) Float() float64 {
+	var scratch [8]byte
+	if r.err != nil {
+		return 0
+	}
+
+	_, err := io.ReadFull(r.r, scratch[:])
+	r.Fail(unexpectedEOF(err))
+	return math.Float64frombits(binary.LittleEndian.Uint64(scratch[:]))
+}
+
+// Length reads a count of items or bytes, at most the largest length
+func (r *Reader) Length() int {
+	n := r.Uvarint()
+	if n > uint64(r.maxLength) {
+		r.Fail(fmt.Errorf("%s length %d is too large", r.name, n))
+		return 0
+	}
+	return int(n)
+}
+
+// Bytes reads bytes written by Writer.Bytes
+func (r *Reader) Bytes() []byte {
+	n := r.Length()
+	if r.err != nil {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	_, err := io.CopyN(&buffer, r.r, int64(n))
+	r.Fail(unexpectedEOF(err))
+	return buffer.Bytes()
+}
+
+// unexpectedEOF reports the end of the input within a value as an
+// unexpected one
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package binenc
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer)
+	w.Raw([]byte("MAGIC"))
+	w.Byte(7)
+	w.Uvarint(300)
+	w.Varint(-300)
+	w.Uint16(0xbeef)
+	w.Float(math.Pi)
+	w.Bytes([]byte("hello"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(&buffer, 16, "Test")
+	if magic := r.Raw(5); string(magic) != "MAGIC" {
+		t.Errorf("the magic is %q", magic)
+	}
+	if b := r.Byte(); b != 7 {
+		t.Errorf("the byte is %d, want 7", b)
+	}
+	if v := r.Uvarint(); v != 300 {
+		t.Errorf("the uvarint is %d, want 300", v)
+	}
+	if v := r.Varint(); v != -300 {
+		t.Errorf("the varint is %d, want -300", v)
+	}
+	if v := r.Uint16(); v != 0xbeef {
+		t.Errorf("the uint16 is %#x, want 0xbeef", v)
+	}
+	if f := r.Float(); f != math.Pi {
+		t.Errorf("the float is %g, want pi", f)
+	}
+	if p := r.Bytes(); string(p) != "hello" {
+		t.Errorf("the bytes are %q, want hello", p)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reading past the end fails and keeps failing
+	if r.Byte(); r.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("error is %v, want %v", r.Err(), io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		read  func(r *Reader)
+		err   string
+	}{
+		{"truncated float", []byte{1, 2, 3}, func(r *Reader) { r.Float() }, "unexpected EOF"},
+		{"truncated bytes", []byte{5, 'a', 'b'}, func(r *Reader) { r.Bytes() }, "unexpected EOF"},
+		{"too long", []byte{17}, func(r *Reader) { r.Bytes() }, "Test length 17 is too large"},
+		{"first error kept", []byte{17}, func(r *Reader) {
+			r.Length()
+			r.Fail(io.ErrClosedPipe)
+		}, "too large"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(test.input), 16, "Test")
+			test.read(r)
+			if err := r.Err(); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error is %v, want one about %s", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package bitmapfont
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"A", 6},
+		{"Hello", 30},
+		{"héllo", 30},
+	}
+
+	for _, test := range tests {
+		if width := Width(test.text); width != test.width {
+			t.Errorf("Width(%q) = %d, want %d", test.text, width, test.width)
+		}
+	}
+}
+
+func TestPixels(t *testing.T) {
+	tests := []struct {
+		text   string
+		pixels [][2]int
+	}{
+		{" ", nil},
+		{"!", [][2]int{{2, 0}, {2, 1}, {2, 2}, {2, 3}, {2, 4}, {2, 6}}},
+		{" -", [][2]int{{6, 3}, {7, 3}, {8, 3}, {9, 3}, {10, 3}}},
+	}
+
+	for _, test := range tests {
+		var pixels [][2]int
+		Pixels(test.text, func(x, y int) {
+			pixels = append(pixels, [2]int{x, y})
+		})
+
+		if len(pixels) != len(test.pixels) {
+			t.Fatalf("Pixels(%q) = %v, want %v", test.text, pixels, test.pixels)
+		}
+		for i := range pixels {
+			if pixels[i] != test.pixels[i] {
+				t.Fatalf("Pixels(%q) = %v, want %v", test.text, pixels, test.pixels)
+			}
+		}
+	}
+}
+
+func TestPixelsMissingGlyph(t *testing.T) {
+	count := func(text string) int {
+		n := 0
+		Pixels(text, func(x, y int) { n++ })
+		return n
+	}
+
+	if count("\t") != count("?") || count("é") != count("?") {
+		t.Errorf("the characters missing in the font are not drawn as '?'")
+	}
+}
@@ -0,0 +1,182 @@
+// Package geometry flattens the bezier curves and outlines the text of the
+// drawing backends
+package geometry
+
+import (
+	"math"
+
+	"github.com/svett/golang-design-patterns/structural-patterns/internal/bitmapfont"
+)
+
+// Point is a point of the plane
+type Point struct {
+	// X is the x-coordinate
+	X float64
+	// Y is the y-coordinate
+	Y float64
+}
+
+// Op is the operation of a path segment. The drawing backends number their
+// path operations the same way.
+type Op uint8
+
+const (
+	// MoveTo starts a new subpath at the point
+	MoveTo Op = iota
+	// LineTo draws a straight line to the point
+	LineTo
+	// QuadTo draws a quadratic bezier curve through a control point to the
+	// point
+	QuadTo
+	// CubicTo draws a cubic bezier curve through two control points to the
+	// point
+	CubicTo
+	// Close closes the subpath with a line to its start
+	Close
+)
+
+// Subpath is a flattened subpath
+type Subpath struct {
+	// Points of the polyline
+	Points []Point
+	// Closed is set when the subpath ends with a close segment
+	Closed bool
+}
+
+// Flattener approximates a path with polylines, segment by segment
+type Flattener struct {
+	// Flatness is the largest distance between a curve and the polyline
+	// approximating it
+	Flatness float64
+
+	subpaths []Subpath
+	current  []Point
+	pen      Point
+}
+
+// Segment adds a segment whose points are the control points followed by the
+// end point. A segment without points is skipped and a curve with the wrong
+// number of points is drawn as a line to its last point.
+func (f *Flattener) Segment(op Op, points []Point) {
+	if op == Close {
+		if len(f.current) > 0 {
+			f.pen = f.current[0]
+		}
+		f.finish(true)
+		return
+	}
+
+	if len(points) == 0 {
+		return
+	}
+	to := points[len(points)-1]
+
+	if op == MoveTo {
+		f.finish(false)
+		f.current = []Point{to}
+		f.pen = to
+		return
+	}
+
+	if len(f.current) == 0 {
+		f.current = []Point{f.pen}
+	}
+
+	switch {
+	case op == QuadTo && len(points) == 2:
+		f.current = append(f.current, f.quadPoints(f.pen, points[0], to)...)
+	case op == CubicTo && len(points) == 3:
+		f.current = append(f.current, f.cubicPoints(f.pen, points[0], points[1], to)...)
+	default:
+		f.current = append(f.current, to)
+	}
+	f.pen = to
+}
+
+// Subpaths ends the path and returns its subpaths with at least two points
+func (f *Flattener) Subpaths() []Subpath {
+	f.finish(false)
+	return f.subpaths
+}
+
+func (f *Flattener) finish(closed bool) {
+	if len(f.current) > 1 {
+		f.subpaths = append(f.subpaths, Subpath{Points: f.current, Closed: closed})
+	}
+	f.current = nil
+}
+
+// quadPoints approximates a quadratic bezier curve without its start point
+func (f *Flattener) quadPoints(p0, p1, p2 Point) []Point {
+	dd := math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y)
+	n := f.bezierSegments(dd / 4)
+
+	points := make([]Point, n)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		points[i-1] = Point{
+			X: u*u*p0.X + 2*u*t*p1.X + t*t*p2.X,
+			Y: u*u*p0.Y + 2*u*t*p1.Y + t*t*p2.Y,
+		}
+	}
+	return points
+}
+
+// cubicPoints approximates a cubic bezier curve without its start point
+func (f *Flattener) cubicPoints(p0, p1, p2, p3 Point) []Point {
+	dd := math.Max(
+		math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y),
+		math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y),
+	)
+	n := f.bezierSegments(dd * 3 / 4)
+
+	points := make([]Point, n)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		points[i-1] = Point{
+			X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+			Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+		}
+	}
+	return points
+}
+
+// bezierSegments returns how many segments approximate a bezier curve
+// whose error bound for a single segment is given
+func (f *Flattener) bezierSegments(bound float64) int {
+	n := int(math.Ceil(math.Sqrt(bound / f.Flatness)))
+	if n < 1 {
+		n = 1
+	}
+	if n > 1024 {
+		n = 1024
+	}
+	return n
+}
+
+// TextWidth returns the width of a text run in the built-in font of the size.
+// Every glyph has the same advance.
+func TextWidth(text string, size float64) float64 {
+	return float64(bitmapfont.Width(text)) * size / bitmapfont.Rows
+}
+
+// TextPolygons returns the squares of the glyph pixels of a text run in the
+// built-in font of the size whose top-left corner is at the origin
+func TextPolygons(text string, origin Point, size float64) [][]Point {
+	unit := size / bitmapfont.Rows
+	var polygons [][]Point
+
+	bitmapfont.Pixels(text, func(gx, gy int) {
+		x := origin.X + float64(gx)*unit
+		y := origin.Y + float64(gy)*unit
+		polygons = append(polygons, []Point{
+			{X: x, Y: y},
+			{X: x + unit, Y: y},
+			{X: x + unit, Y: y + unit},
+			{X: x, Y: y + unit},
+		})
+	})
+	return polygons
+}
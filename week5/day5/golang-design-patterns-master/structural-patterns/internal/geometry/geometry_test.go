@@ -0,0 +1,109 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlattenerWithinFlatness(t *testing.T) {
+	quad := func(t float64) Point {
+		u := 1 - t
+		return Point{X: 2 * u * t * 100, Y: 2 * u * t * 200}
+	}
+	cubic := func(t float64) Point {
+		u := 1 - t
+		return Point{X: 3*u*t*t*300 + t*t*t*300, Y: 3*u*u*t*300 + 3*u*t*t*300}
+	}
+
+	tests := []struct {
+		name     string
+		op       Op
+		points   []Point
+		curve    func(t float64) Point
+		flatness float64
+	}{
+		{"quad", QuadTo, []Point{{X: 100, Y: 200}, {}}, quad, 0.25},
+		{"coarse quad", QuadTo, []Point{{X: 100, Y: 200}, {}}, quad, 4},
+		{"cubic", CubicTo, []Point{{Y: 300}, {X: 300, Y: 300}, {X: 300}}, cubic, 0.25},
+		{"fine cubic", CubicTo, []Point{{Y: 300}, {X: 300, Y: 300}, {X: 300}}, cubic, 0.05},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &Flattener{Flatness: test.flatness}
+			f.Segment(MoveTo, []Point{{}})
+			f.Segment(test.op, test.points)
+			subpaths := f.Subpaths()
+			if len(subpaths) != 1 {
+				t.Fatalf("the subpaths are %v, want one", subpaths)
+			}
+
+			polyline := subpaths[0].Points
+			for i := 0; i <= 1000; i++ {
+				p := test.curve(float64(i) / 1000)
+				if d := polylineDistance(p, polyline); d > test.flatness {
+					t.Fatalf("the curve at %v is %g from the polyline, want at most %g", p, d, test.flatness)
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenerMalformedSegments(t *testing.T) {
+	f := &Flattener{Flatness: 0.25}
+	f.Segment(LineTo, []Point{{X: 10}})
+	f.Segment(QuadTo, []Point{{X: 10, Y: 10}})
+	f.Segment(CubicTo, nil)
+	f.Segment(Close, nil)
+	f.Segment(MoveTo, nil)
+	f.Segment(LineTo, []Point{{X: 5}})
+	f.Segment(Op(42), []Point{{X: 5, Y: 5}})
+
+	want := []Subpath{
+		{Points: []Point{{}, {X: 10}, {X: 10, Y: 10}}, Closed: true},
+		{Points: []Point{{}, {X: 5}, {X: 5, Y: 5}}},
+	}
+	subpaths := f.Subpaths()
+	if len(subpaths) != len(want) {
+		t.Fatalf("the subpaths are %v, want %v", subpaths, want)
+	}
+	for i := range want {
+		if subpaths[i].Closed != want[i].Closed || len(subpaths[i].Points) != len(want[i].Points) {
+			t.Fatalf("the subpaths are %v, want %v", subpaths, want)
+		}
+		for j := range want[i].Points {
+			if subpaths[i].Points[j] != want[i].Points[j] {
+				t.Fatalf("the subpaths are %v, want %v", subpaths, want)
+			}
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	if width := TextWidth("Hi", 16); width != 24 {
+		t.Errorf("the width is %g, want 24", width)
+	}
+
+	polygons := TextPolygons("-", Point{X: 10, Y: 20}, 16)
+	if len(polygons) != 5 {
+		t.Fatalf("the polygons are %v, want 5 squares", polygons)
+	}
+	if first := polygons[0]; first[0] != (Point{X: 10, Y: 26}) || first[2] != (Point{X: 12, Y: 28}) {
+		t.Errorf("the first square is %v, want from 10,26 to 12,28", first)
+	}
+}
+
+// polylineDistance returns the distance from the point to the polyline
+func polylineDistance(p Point, polyline []Point) float64 {
+	distance := math.Inf(1)
+	for i := 1; i < len(polyline); i++ {
+		a, b := polyline[i-1], polyline[i]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l))
+		}
+		distance = math.Min(distance, math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy))
+	}
+	return distance
+}